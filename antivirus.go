@@ -0,0 +1,86 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"runtime"
+	"time"
+)
+
+// Antivirus scanners lock freshly written files while scanning them, usually for well under a few seconds
+const (
+	sharingViolationRetries = 8
+	sharingViolationBackoff = 250 * time.Millisecond
+	// How long to wait before checking whether a written file was quarantined
+	quarantineCheckDelay = 500 * time.Millisecond
+)
+
+// retryOnSharingViolation runs fn until it stops failing because the file is locked by another process, backing off
+// a bit more after every attempt. Gives up after sharingViolationRetries attempts
+func retryOnSharingViolation(file string, fn func() error) error {
+	delay := sharingViolationBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isSharingViolation(err) || attempt == sharingViolationRetries {
+			return err
+		}
+		Log.Warn(file, "is locked by another process, probably an antivirus scanning it. Retrying in", delay)
+		time.Sleep(delay)
+		delay += sharingViolationBackoff
+	}
+}
+
+// antivirusError returns an explanation of how to fix err if an antivirus caused it, otherwise err itself
+func antivirusError(file string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case isBlockedByAntivirus(err):
+		return errors.New("Your antivirus blocked " + file + ". " + antivirusRemediation(file))
+	case isSharingViolation(err):
+		return withClass(ErrDiscordRunning, errors.New(file+" stayed locked by another process, like Discord or your antivirus scanning it. "+
+			"Close Discord, wait a minute and try again. If it keeps happening, "+antivirusRemediation(file)))
+	}
+	return err
+}
+
+// CheckNotQuarantined checks whether an antivirus quarantined file right after we wrote it, which otherwise only
+// shows up later as Discord failing to load Potatocord
+func CheckNotQuarantined(file string) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+
+	time.Sleep(quarantineCheckDelay)
+	err := retryOnSharingViolation(file, func() error {
+		f, err := os.Open(file)
+		if err == nil {
+			_ = f.Close()
+		}
+		return err
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, os.ErrNotExist):
+		return errors.New(file + " vanished right after it was written, so your antivirus most likely quarantined it. " +
+			antivirusRemediation(file))
+	case isSharingViolation(err):
+		// Still being scanned, which doesn't mean it will be removed
+		Log.Warn(file, "is still locked by another process after writing it")
+		return nil
+	}
+	return antivirusError(file, err)
+}
+
+func antivirusRemediation(file string) string {
+	return "Restore it from your antivirus' quarantine if it is there, add an exclusion for " + path.Dir(file) +
+		" (in Windows Security: Virus & threat protection > Manage settings > Exclusions) and try again"
+}
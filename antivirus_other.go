@@ -0,0 +1,19 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+// Files aren't locked against other processes outside of Windows
+
+func isSharingViolation(_ error) bool {
+	return false
+}
+
+func isBlockedByAntivirus(_ error) bool {
+	return false
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
@@ -22,6 +23,14 @@ type asarEntry struct {
 // Ported from https://github.com/GeopJr/asar-cr/blob/cd7695b7c913bf921d9fb6600eaeb1400e3ba225/src/asar-cr/pack.cr#L61
 
 func WriteAppAsar(outFile string, potatocordAsarPath string) error {
+	if err := os.WriteFile(outFile, BuildAppAsar(potatocordAsarPath), 0644); err != nil {
+		return fmt.Errorf("Failed to write %s: %w", outFile, err)
+	}
+	return nil
+}
+
+// BuildAppAsar returns an app.asar that loads the Potatocord file at potatocordAsarPath
+func BuildAppAsar(potatocordAsarPath string) []byte {
 	header := make(map[string]map[string]asarEntry)
 	files := make(map[string]asarEntry)
 	header["files"] = files
@@ -55,23 +64,11 @@ func WriteAppAsar(outFile string, potatocordAsarPath string) error {
 		headerString += strings.Repeat("0", int(diff))
 	}
 
-	f, err := os.Create(outFile)
-	if err != nil {
-		return fmt.Errorf("Failed to create %s: %w", outFile, err)
-	}
-	defer f.Close()
-
+	var b bytes.Buffer
 	for _, n := range []uint32{dataSize, headerSize, headerObjectSize, headerStringSize} {
-		if err = binary.Write(f, binary.LittleEndian, int32(n)); err != nil {
-			return fmt.Errorf("Failed to write asar bytes: %w", err)
-		}
-	}
-
-	for _, s := range []string{headerString, fileContents} {
-		if _, err = f.WriteString(s); err != nil {
-			return fmt.Errorf("Failed to write asar data: %w", err)
-		}
+		_ = binary.Write(&b, binary.LittleEndian, int32(n))
 	}
-
-	return nil
+	b.WriteString(headerString)
+	b.WriteString(fileContents)
+	return b.Bytes()
 }
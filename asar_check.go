@@ -0,0 +1,217 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	path "path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Discord's asar headers are a few hundred KiB. Anything this big is not an asar
+const maxAsarHeaderSize = 64 * 1024 * 1024
+
+type asarNode struct {
+	Files    map[string]*asarNode `json:"files"`
+	Size     int64                `json:"size"`
+	Offset   string               `json:"offset"`
+	Unpacked bool                 `json:"unpacked"`
+	Link     string               `json:"link"`
+}
+
+type asarArchive struct {
+	file       string
+	root       asarNode
+	dataOffset int64
+}
+
+// openAsar parses the header of an asar archive. The layout is a pickle with the size of the header pickle,
+// followed by the header pickle holding the length of the json header and the json header itself
+func openAsar(file string) (*asarArchive, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sizes [4]uint32
+	if err = binary.Read(f, binary.LittleEndian, &sizes); err != nil {
+		return nil, errors.New(file + " is too short to be an asar")
+	}
+	headerSize, jsonSize := sizes[1], sizes[3]
+	if sizes[0] != 4 || jsonSize > headerSize || headerSize > maxAsarHeaderSize {
+		return nil, errors.New(file + " is not an asar")
+	}
+
+	header := make([]byte, jsonSize)
+	if _, err = io.ReadFull(f, header); err != nil {
+		return nil, errors.New(file + " is truncated")
+	}
+
+	archive := &asarArchive{file: file, dataOffset: 8 + int64(headerSize)}
+	if err = json.Unmarshal(header, &archive.root); err != nil {
+		return nil, errors.New(file + " has a corrupted header: " + err.Error())
+	}
+	return archive, nil
+}
+
+func (a *asarArchive) find(name string) *asarNode {
+	node := &a.root
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		if node = node.Files[part]; node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// ReadFile returns the contents of a file in the archive, following links and reading unpacked files from disk
+func (a *asarArchive) ReadFile(name string) ([]byte, error) {
+	node := a.find(name)
+	for i := 0; node != nil && node.Link != "" && i < 10; i++ {
+		name = node.Link
+		node = a.find(name)
+	}
+	if node == nil || node.Files != nil {
+		return nil, errors.New(a.file + " has no file " + name)
+	}
+	if node.Unpacked {
+		return os.ReadFile(path.Join(a.file+".unpacked", name))
+	}
+
+	offset, err := strconv.ParseInt(node.Offset, 10, 64)
+	if err != nil {
+		return nil, errors.New(a.file + " has an invalid offset for " + name)
+	}
+	f, err := os.Open(a.file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b := make([]byte, node.Size)
+	if _, err = f.ReadAt(b, a.dataOffset+offset); err != nil {
+		return nil, errors.New(a.file + " is truncated, " + name + " is missing data")
+	}
+	return b, nil
+}
+
+// readAsarEntry reads the package.json of an asar and returns it along with its entry point
+func readAsarEntry(file string) (*asarArchive, string, error) {
+	archive, err := openAsar(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	b, err := archive.ReadFile("package.json")
+	if err != nil {
+		return nil, "", err
+	}
+	var pkg struct {
+		Main string `json:"main"`
+	}
+	if err = json.Unmarshal(b, &pkg); err != nil {
+		return nil, "", errors.New("The package.json in " + file + " is corrupted: " + err.Error())
+	}
+	return archive, Ternary(pkg.Main != "", pkg.Main, "index.js"), nil
+}
+
+// ValidateDiscordAsar checks that file is an intact app: a parseable asar with a package.json and the entry point
+// it names. Patching anything else would leave Discord unable to start once we hand over to it
+func ValidateDiscordAsar(file string) error {
+	archive, main, err := readAsarEntry(file)
+	if err != nil {
+		return err
+	}
+	// Like require, accept the entry point without extension or as a folder
+	for _, candidate := range []string{main, main + ".js", main + "/index.js"} {
+		if _, err = archive.ReadFile(candidate); err == nil {
+			return nil
+		}
+	}
+	return errors.New("The entry point of " + file + " is missing: " + main)
+}
+
+// verifyInjectedAsar checks that file is exactly the app.asar WriteAppAsar writes for loading potatocordFile
+func verifyInjectedAsar(file, potatocordFile string) error {
+	archive, main, err := readAsarEntry(file)
+	if err != nil {
+		return err
+	}
+	index, err := archive.ReadFile(main)
+	if err != nil {
+		return err
+	}
+	patcherPath, _ := json.Marshal(potatocordFile)
+	if !bytes.Equal(index, []byte("require("+string(patcherPath)+")")) {
+		return errors.New(file + " doesn't load " + potatocordFile)
+	}
+	return nil
+}
+
+// ValidatePotatocordAsar checks that file is a complete Potatocord build: every file's data is within the archive,
+// package.json and main.js are readable and main.js embeds the expected hash, if one is known. A corrupted download can
+// match the Content-Length and would otherwise only be noticed once Discord fails to load it
+func ValidatePotatocordAsar(file, hash string) error {
+	archive, err := openAsar(file)
+	if err != nil {
+		return err
+	}
+	stat, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if name := archive.root.findOutOfBounds("", stat.Size()-archive.dataOffset); name != "" {
+		return errors.New(file + " is truncated, " + name + " is missing data")
+	}
+
+	if _, err = archive.ReadFile("package.json"); err != nil {
+		return err
+	}
+	main, err := archive.ReadFile("main.js")
+	if err != nil {
+		return err
+	}
+	if match := asarHashRe.FindSubmatch(main); match == nil {
+		return errors.New(file + " has no build hash, it is not a Potatocord build")
+	} else if commitHashRegex.MatchString(hash) && !HashesMatch(hash, string(match[2])) {
+		return errors.New(file + " is build " + string(match[2]) + " instead of " + hash)
+	}
+	return nil
+}
+
+// findOutOfBounds returns the first packed file whose data doesn't fit into the size available for data
+func (n *asarNode) findOutOfBounds(name string, size int64) string {
+	if n.Files == nil {
+		if n.Unpacked || n.Link != "" {
+			return ""
+		}
+		offset, err := strconv.ParseInt(n.Offset, 10, 64)
+		if err != nil || offset < 0 || n.Size < 0 || offset+n.Size > size {
+			return name
+		}
+		return ""
+	}
+	for child, node := range n.Files {
+		if node == nil {
+			continue
+		}
+		if bad := node.findOutOfBounds(strings.TrimPrefix(name+"/"+child, "/"), size); bad != "" {
+			return bad
+		}
+	}
+	return ""
+}
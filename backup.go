@@ -0,0 +1,159 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	path "path/filepath"
+	"time"
+)
+
+// Backups of Discord's stock app.asar, stored by content so identical asars of different installs are only stored once
+var BackupDir string
+
+type StockBackup struct {
+	File   string    `json:"file"`
+	Sha256 string    `json:"sha256"`
+	Time   time.Time `json:"time"`
+}
+
+func getBackupIndexPath() string {
+	return path.Join(BackupDir, "index.json")
+}
+
+// readBackupIndex returns the recorded backups by resources directory
+func readBackupIndex() map[string]StockBackup {
+	index := make(map[string]StockBackup)
+	b, err := os.ReadFile(getBackupIndexPath())
+	if err == nil {
+		if err = json.Unmarshal(b, &index); err != nil {
+			Log.Warn("Failed to parse backup index:", err)
+		}
+	}
+	return index
+}
+
+func writeBackupIndex(index map[string]StockBackup) error {
+	b, err := json.MarshalIndent(index, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(getBackupIndexPath(), b, 0644)
+}
+
+func hashFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// StoreBackup stores src in BackupDir, unless an identical file is already stored.
+// To save space it is reflinked or hardlinked where possible, only falling back to a copy if neither works
+func StoreBackup(src string) (*StockBackup, error) {
+	sum, err := hashFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(BackupDir, 0755); err != nil {
+		return nil, err
+	}
+
+	dst := getStockBackupPath(sum)
+	if ExistsFile(dst) {
+		Log.Debug("Backup of", src, "already exists at", dst)
+	} else if err = linkOrCopy(src, dst); err != nil {
+		_ = os.Remove(dst)
+		return nil, err
+	}
+
+	return &StockBackup{File: dst, Sha256: sum, Time: time.Now()}, nil
+}
+
+func linkOrCopy(src, dst string) error {
+	err := Reflink(src, dst)
+	if err == nil {
+		Log.Debug("Reflinked", src, "to", dst)
+		return nil
+	}
+	Log.Debug("Failed to reflink", src+":", err)
+
+	// Discord's files are only ever renamed or replaced, never modified in place, so hardlinks are safe.
+	// RestoreStockAsar still verifies the checksum in case that ever changes
+	if err = os.Link(src, dst); err == nil {
+		Log.Debug("Hardlinked", src, "to", dst)
+		return nil
+	}
+	Log.Debug("Failed to hardlink", src+":", err)
+
+	return copyFile(src, dst)
+}
+
+func getStockBackupPath(sum string) string {
+	return path.Join(BackupDir, sum+".asar")
+}
+
+// getStockAsar returns where the unmodified app.asar of the given install is
+func getStockAsar(di *DiscordInstall) string {
+	return path.Join(di.resourcesDir(), Ternary(di.isPatched, "_app.asar", "app.asar"))
+}
+
+// BackupStockAsar backs up the unmodified app.asar of the given install
+func BackupStockAsar(di *DiscordInstall) error {
+	dir := di.resourcesDir()
+	stock := getStockAsar(di)
+	if !ExistsFile(stock) {
+		return errors.New("No stock app.asar at " + dir)
+	}
+
+	backup, err := StoreBackup(stock)
+	if err != nil {
+		return err
+	}
+
+	index := readBackupIndex()
+	index[dir] = *backup
+	if err = writeBackupIndex(index); err != nil {
+		return err
+	}
+
+	_ = FixOwnership(BackupDir)
+	recordTouchedFiles(true, backup.File)
+	recordBackup(backup.File)
+	Log.Debug("Backed up", stock, "to", backup.File)
+	return nil
+}
+
+// RestoreStockAsar restores the backed up app.asar of the given install to dst
+func RestoreStockAsar(di *DiscordInstall, dst string) error {
+	backup, ok := readBackupIndex()[di.resourcesDir()]
+	if !ok {
+		return errors.New("No backup of " + di.resourcesDir())
+	}
+
+	if sum, err := hashFile(backup.File); err != nil {
+		return err
+	} else if sum != backup.Sha256 {
+		return errors.New("Backup " + backup.File + " is corrupted")
+	}
+
+	Log.Info("Restoring", dst, "from backup", backup.File)
+	return copyFile(backup.File, dst)
+}
@@ -0,0 +1,120 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PatchResult is the outcome of patching, or another action, for one install of a batch
+type PatchResult struct {
+	Branch string `json:"branch"`
+	Path   string `json:"path"`
+	Error  string `json:"error,omitempty"`
+}
+
+// FindPatchableDiscords returns the installs that can be patched, leaving out read-only ones like snaps
+func FindPatchableDiscords(discords []any) []*DiscordInstall {
+	var installs []*DiscordInstall
+	for _, d := range discords {
+		if di := d.(*DiscordInstall); !di.isSnap && !di.isStore {
+			installs = append(installs, di)
+		}
+	}
+	return installs
+}
+
+// ReadTargets reads the installs to modify from a targets file, one Discord path or branch per line. Empty lines and
+// lines starting with # are skipped
+func ReadTargets(r io.Reader) ([]string, error) {
+	var targets []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") && !SliceContains(targets, line) {
+			targets = append(targets, line)
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// ResolveTargets finds the install of each target. Targets that can't be found get a failed result instead of
+// stopping the batch, so a fleet-wide list works on machines that lack some of the installs
+func ResolveTargets(targets []string) (installs []*DiscordInstall, unresolved []PatchResult) {
+	for _, target := range targets {
+		var di *DiscordInstall
+		var err error
+		if branch := strings.ToLower(target); SliceContains(DiscordBranches, branch) {
+			if di = FindDiscordByBranch(branch); di == nil {
+				unresolved = append(unresolved, PatchResult{Branch: branch, Error: "Discord " + branch + " not found"})
+				continue
+			}
+		} else if di, err = ParseDiscordLocation(target); err != nil {
+			unresolved = append(unresolved, PatchResult{Path: target, Error: err.Error()})
+			continue
+		}
+
+		// A branch and its path may both be listed
+		if !SliceContainsFunc(installs, func(other *DiscordInstall) bool { return other.path == di.path }) {
+			installs = append(installs, di)
+		}
+	}
+	return
+}
+
+// PatchAll patches each install in the scope it was patched in before. A failing install doesn't stop the batch,
+// every install gets its own result
+func PatchAll(installs []*DiscordInstall) []PatchResult {
+	return RunAll(installs, "patch", "", (*DiscordInstall).patch)
+}
+
+// RunAll runs an action like patch or unpatch on each install, in the given scope or the one it was patched in before
+func RunAll(installs []*DiscordInstall, action string, scope InstallScope, fn func(di *DiscordInstall) error) []PatchResult {
+	defer WithLogContext(action + "-all")()
+	Log.Info("Running", action, "on", len(installs), "installs...")
+
+	previous := CurrentScope
+	results := make([]PatchResult, len(installs))
+	for i, di := range installs {
+		results[i] = PatchResult{Branch: di.branch, Path: di.path}
+		err := UseScopeFor(di, scope)
+		if err == nil {
+			err = fn(di)
+		}
+		if err != nil {
+			Log.Error("Failed to", action, di.path+":", err)
+			results[i].Error = err.Error()
+		}
+	}
+	// Don't leave later operations in the scope of whichever install came last
+	if err := UseScope(previous); err != nil {
+		Log.Warn(err)
+	}
+	return results
+}
+
+// FailedPatches returns an error summarising the failed installs of a batch, or nil if all succeeded
+func FailedPatches(results []PatchResult) error {
+	return FailedBatch("patch", results)
+}
+
+func FailedBatch(action string, results []PatchResult) error {
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return errors.New("Failed to " + action + " " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(results)) + " installs")
+}
@@ -0,0 +1,116 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	path "path/filepath"
+	"strings"
+	"time"
+)
+
+// DiscordBranches in the order they are preferred when picking one automatically
+var DiscordBranches = []string{"stable", "canary", "ptb", "development"}
+
+// FindDiscordByBranch returns the first detected install of the given branch or nil
+func FindDiscordByBranch(branch string) *DiscordInstall {
+	for _, d := range discords {
+		if di := d.(*DiscordInstall); di.branch == branch {
+			return di
+		}
+	}
+	return nil
+}
+
+// PatchedHash returns the Potatocord version this install was last patched with, "Unknown" if it was patched
+// by an older installer, or "" if it isn't patched
+func (di *DiscordInstall) PatchedHash() string {
+	if !di.isPatched {
+		return ""
+	}
+	if hash, ok := ReadManifest().Patched[di.resourcesDir()]; ok {
+		return hash
+	}
+	return "Unknown"
+}
+
+// IsPotatocordOutdated reports whether a newer Potatocord than the installed one is available. Every install loads the
+// same Potatocord file, so they are all outdated or up to date together
+func IsPotatocordOutdated() bool {
+	return GithubError == nil && LatestHash != "Unknown" && InstalledHash != "None" && !HashesMatch(LatestHash, InstalledHash)
+}
+
+// StatusText describes the patch status of the install, for display next to it
+func (di *DiscordInstall) StatusText() string {
+	hash := di.PatchedHash()
+	switch {
+	case di.isStore:
+		return " [MICROSOFT STORE, NOT SUPPORTED]"
+	case di.isSnap:
+		return " [SNAP, NEEDS MIGRATION]"
+	case hash == "":
+		return ""
+	case hash == "Unknown":
+		return " [PATCHED]"
+	default:
+		return " [PATCHED " + hash + "]"
+	}
+}
+
+// DisplayName is the capitalised branch name
+func (di *DiscordInstall) DisplayName() string {
+	//goland:noinspection GoDeprecation
+	return strings.Title(di.branch)
+}
+
+func recordPatchedHash(di *DiscordInstall, hash string) {
+	manifest := ReadManifest()
+	if manifest.Patched == nil {
+		manifest.Patched = make(map[string]string)
+	}
+	if manifest.Scopes == nil {
+		manifest.Scopes = make(map[string]InstallScope)
+	}
+	if manifest.Injections == nil {
+		manifest.Injections = make(map[string]Injection)
+	}
+	dir := di.resourcesDir()
+	appAsar, stockAsar := path.Join(dir, "app.asar"), path.Join(dir, "_app.asar")
+	recordVersion("Discord "+di.DisplayName()+" - "+di.path, manifest.Patched[dir], hash)
+	if hash == "" {
+		delete(manifest.Patched, dir)
+		delete(manifest.Scopes, dir)
+		delete(manifest.Injections, dir)
+		// Back to stock, so they are Discord's own files again
+		delete(manifest.Files, appAsar)
+		delete(manifest.Files, stockAsar)
+		recordRestored(appAsar)
+		recordRemoved(stockAsar)
+	} else {
+		manifest.Patched[dir] = hash
+		manifest.Scopes[dir] = CurrentScope
+		manifest.Injections[dir] = Injection{
+			Discord:   di.path,
+			Branch:    di.branch,
+			AppAsar:   appAsar,
+			StockAsar: stockAsar,
+			Loads:     PotatocordDirectory,
+			Hash:      hash,
+			Scope:     CurrentScope,
+			Flatpak:   di.isFlatpak,
+			Time:      time.Now(),
+		}
+		manifest.touch(appAsar, false)
+		manifest.touch(stockAsar, true)
+		// Like Discord itself, the app.asar of an install in the user's home must belong to them, not to root
+		if !di.IsMachineWide() {
+			_ = FixOwnership(appAsar)
+		}
+	}
+	if err := manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+}
@@ -0,0 +1,37 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+)
+
+// Builds without -ldflags, like go build or go install, still know the commit they were built from
+func init() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	var revision, time string
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.time":
+			time = s.Value
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+
+	if InstallerGitHash == VersionUnknown && len(revision) >= 7 {
+		InstallerGitHash = revision[:7]
+		if modified {
+			InstallerGitHash += "-dirty"
+		}
+	}
+	// The commit time is the closest to a build date we have
+	if BuildDate == VersionUnknown && time != "" {
+		BuildDate = time
+	}
+}
@@ -4,3 +4,4 @@ var VersionUnknown = "Unknown"
 
 var InstallerGitHash = "Unknown"
 var InstallerTag = "Unknown"
+var BuildDate = "Unknown"
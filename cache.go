@@ -0,0 +1,181 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	path "path/filepath"
+	"regexp"
+	"time"
+)
+
+var CacheDir string
+
+var validCacheKey = regexp.MustCompile(`^\w+$`)
+
+func getCachePath(hash string) (string, bool) {
+	if !validCacheKey.MatchString(hash) || hash == "Unknown" || hash == "None" {
+		return "", false
+	}
+	// Abbreviated, as hashes from different sources may have different lengths
+	return path.Join(CacheDir, hash[:min(len(hash), 7)]+".asar"), true
+}
+
+func isCached(hash string) bool {
+	file, ok := getCachePath(hash)
+	return ok && HashesMatch(ReadAsarHash(file), hash)
+}
+
+// OpenCachedBuild opens the cached asar of the given build. The file's embedded hash is checked
+// so a corrupted cache entry is never installed
+func OpenCachedBuild(hash string) (io.ReadCloser, int64, error) {
+	file, ok := getCachePath(hash)
+	if !ok {
+		return nil, 0, errors.New("Not caching builds with unknown hash")
+	}
+
+	if actual := ReadAsarHash(file); !HashesMatch(actual, hash) {
+		if actual != "" {
+			Log.Warn("Cached build", file, "has hash", actual, "instead of", hash+". Deleting it")
+			_ = os.Remove(file)
+		}
+		return nil, 0, errors.New("Build " + hash + " is not cached")
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return f, stat.Size(), nil
+}
+
+// AddToCache copies the asar at src into the cache as the given build
+func AddToCache(hash, src string) {
+	file, ok := getCachePath(hash)
+	if !ok {
+		return
+	}
+
+	if actual := ReadAsarHash(src); !HashesMatch(actual, hash) {
+		Log.Warn("Not caching", src, "as its hash", actual, "doesn't match", hash)
+		return
+	}
+
+	if err := copyFile(src, file); err != nil {
+		Log.Warn("Failed to cache build", hash+":", err)
+		_ = os.Remove(file)
+		return
+	}
+	_ = FixOwnership(CacheDir)
+	Log.Debug("Cached build", hash, "at", file)
+}
+
+// The release data last fetched, so offline and notify-only mode know which build is the latest
+type cachedRelease struct {
+	Release GithubRelease `json:"release"`
+	Hash    string        `json:"hash"`
+	Time    time.Time     `json:"time"`
+}
+
+func getCachedReleasePath() string {
+	return path.Join(CacheDir, "release.json")
+}
+
+// CacheRelease stores the release data and the hash of its build for offline and notify-only mode
+func CacheRelease(data *GithubRelease, hash string) {
+	b, err := json.Marshal(cachedRelease{*data, hash, time.Now()})
+	if err == nil {
+		err = EnsureDir(CacheDir)
+	}
+	if err == nil {
+		err = os.WriteFile(getCachedReleasePath(), b, 0644)
+	}
+	if err != nil {
+		Log.Warn("Failed to cache release data:", err)
+		return
+	}
+	_ = FixOwnership(CacheDir)
+}
+
+// ReadCachedRelease returns the release data stored by CacheRelease and when it was stored
+func ReadCachedRelease() (*GithubRelease, time.Time, error) {
+	b, err := os.ReadFile(getCachedReleasePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, time.Time{}, errReleaseNotCached()
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var cached cachedRelease
+	if err = json.Unmarshal(b, &cached); err != nil {
+		return nil, time.Time{}, errors.New("Failed to parse cached release data: " + err.Error())
+	}
+	Log.Debug("Using release data cached at", cached.Time.Format(time.DateTime))
+	cached.Release.Hash = cached.Hash
+	return &cached.Release, cached.Time, nil
+}
+
+// GetCacheSize returns the total size of all cached builds in bytes
+func GetCacheSize() (int64, error) {
+	var size int64
+	err := path.WalkDir(CacheDir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return size, err
+}
+
+// PurgeCache deletes all cached builds
+func PurgeCache() error {
+	Log.Info("Purging cache at", CacheDir)
+	return os.RemoveAll(CacheDir)
+}
+
+func copyFile(src, dst string) error {
+	if err := os.MkdirAll(path.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
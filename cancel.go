@@ -0,0 +1,65 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// ErrCancelled is returned by downloads and installs that were stopped with Ctrl+C or SIGTERM
+var ErrCancelled = errors.New("Cancelled")
+
+// cancelCtx is cancelled by CancelOperations. Downloads and install steps stop once it is, and installs undo what
+// they already changed
+var cancelCtx, cancelOperations = context.WithCancel(context.Background())
+
+// How many cancellable operations are running, see enterCancellable
+var cancellable atomic.Int32
+
+// CancelOperations stops all downloads and installs. Returns false if none is running, so there is nothing to wait
+// for or clean up
+func CancelOperations() bool {
+	cancelOperations()
+	return cancellable.Load() > 0
+}
+
+// IsCancelled reports whether CancelOperations was called
+func IsCancelled() bool {
+	return cancelCtx.Err() != nil
+}
+
+// enterCancellable marks code that stops with ErrCancelled and cleans up after itself once cancelled. Call the
+// returned function when it's done
+func enterCancellable() func() {
+	cancellable.Add(1)
+	return func() {
+		cancellable.Add(-1)
+	}
+}
+
+// cancelledOr returns ErrCancelled if operations were cancelled, as err is then just a symptom like "context canceled"
+func cancelledOr(err error) error {
+	if err != nil && IsCancelled() {
+		return ErrCancelled
+	}
+	return err
+}
+
+// cancellableReader stops reading with ErrCancelled once operations are cancelled
+type cancellableReader struct {
+	r io.Reader
+}
+
+func (c cancellableReader) Read(b []byte) (int, error) {
+	if IsCancelled() {
+		return 0, ErrCancelled
+	}
+	return c.r.Read(b)
+}
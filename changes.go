@@ -0,0 +1,162 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "sync"
+
+// Changes is what the installer did to the system, so it can tell the user once it's done
+type Changes struct {
+	// Files created or overwritten, apart from backups
+	Written []string `json:"written,omitempty"`
+	// Files put back the way they were, e.g. Discord's own app.asar
+	Restored []string        `json:"restored,omitempty"`
+	Removed  []string        `json:"removed,omitempty"`
+	Backups  []string        `json:"backups,omitempty"`
+	Versions []VersionChange `json:"versions,omitempty"`
+	// Whether administrator rights were used for any of it
+	Elevated bool `json:"elevated"`
+}
+
+// VersionChange is a version that was replaced, e.g. the installed Potatocord build. Empty means nothing was installed
+type VersionChange struct {
+	What   string `json:"what"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+var (
+	changes     Changes
+	changesLock sync.Mutex
+)
+
+func without(files []string, file string) []string {
+	if i := SliceIndex(files, file); i != -1 {
+		return append(files[:i], files[i+1:]...)
+	}
+	return files
+}
+
+func recordWritten(files ...string) {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	for _, file := range files {
+		changes.Removed = without(changes.Removed, file)
+		changes.Restored = without(changes.Restored, file)
+		if !SliceContains(changes.Written, file) && !SliceContains(changes.Backups, file) {
+			changes.Written = append(changes.Written, file)
+		}
+	}
+}
+
+func recordRemoved(files ...string) {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	for _, file := range files {
+		changes.Written = without(changes.Written, file)
+		changes.Restored = without(changes.Restored, file)
+		changes.Backups = without(changes.Backups, file)
+		if !SliceContains(changes.Removed, file) {
+			changes.Removed = append(changes.Removed, file)
+		}
+	}
+}
+
+func recordRestored(files ...string) {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	for _, file := range files {
+		changes.Written = without(changes.Written, file)
+		changes.Removed = without(changes.Removed, file)
+		if !SliceContains(changes.Restored, file) {
+			changes.Restored = append(changes.Restored, file)
+		}
+	}
+}
+
+func recordBackup(file string) {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	changes.Written = without(changes.Written, file)
+	if !SliceContains(changes.Backups, file) {
+		changes.Backups = append(changes.Backups, file)
+	}
+}
+
+// recordVersion records that what went from before to after. Changing it again keeps the first before, so undoing a
+// change leaves no change at all
+func recordVersion(what, before, after string) {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	if i := SliceIndexFunc(changes.Versions, func(v VersionChange) bool { return v.What == what }); i != -1 {
+		changes.Versions[i].After = after
+		return
+	}
+	changes.Versions = append(changes.Versions, VersionChange{what, before, after})
+}
+
+func recordElevated() {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	changes.Elevated = true
+}
+
+// TakeChanges returns what was changed since the last call
+func TakeChanges() Changes {
+	changesLock.Lock()
+	defer changesLock.Unlock()
+	c := changes
+	changes = Changes{}
+	var versions []VersionChange
+	for _, v := range c.Versions {
+		if v.Before != v.After {
+			versions = append(versions, v)
+		}
+	}
+	c.Versions = versions
+	return c
+}
+
+func (c Changes) IsEmpty() bool {
+	return len(c.Written) == 0 && len(c.Restored) == 0 && len(c.Removed) == 0 && len(c.Backups) == 0 && len(c.Versions) == 0 && !c.Elevated
+}
+
+// Lines describes the changes for humans, one line each
+func (c Changes) Lines() []string {
+	var lines []string
+	for _, v := range c.Versions {
+		lines = append(lines, T("%s: %s → %s", v.What, Ternary(v.Before != "", v.Before, T("none")), Ternary(v.After != "", v.After, T("none"))))
+	}
+	for _, file := range c.Written {
+		lines = append(lines, T("Wrote %s", file))
+	}
+	for _, file := range c.Restored {
+		lines = append(lines, T("Restored %s", file))
+	}
+	for _, file := range c.Backups {
+		lines = append(lines, T("Backed up to %s", file))
+	}
+	for _, file := range c.Removed {
+		lines = append(lines, T("Removed %s", file))
+	}
+	if c.Elevated {
+		lines = append(lines, T("Used administrator rights"))
+	}
+	return lines
+}
+
+// LogChanges logs what was changed since the last call, if anything
+func LogChanges() Changes {
+	c := TakeChanges()
+	if c.IsEmpty() {
+		return c
+	}
+	Log.Info(T("Changes made:"))
+	for _, line := range c.Lines() {
+		Log.Info("  " + line)
+	}
+	return c
+}
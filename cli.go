@@ -9,13 +9,19 @@
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"potatocordinstaller/buildinfo"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/manifoldco/promptui"
@@ -24,6 +30,29 @@ import (
 var discords []any
 var interactive = false
 
+// Set by --yes, which never prompts and picks defaults instead
+var assumeDefaults = false
+
+// branchList is the value of --branch, which may be repeated or hold several comma separated branches
+type branchList []string
+
+func (b *branchList) String() string {
+	return strings.Join(*b, ",")
+}
+
+func (b *branchList) Set(value string) error {
+	for _, branch := range strings.Split(value, ",") {
+		branch = strings.ToLower(strings.TrimSpace(branch))
+		if branch == "" || !isValidBranch(branch) {
+			return errors.New("must be one of the following: [auto|stable|ptb|canary|development]")
+		}
+		if !SliceContains(*b, branch) {
+			*b = append(*b, branch)
+		}
+	}
+	return nil
+}
+
 func isValidBranch(branch string) bool {
 	switch branch {
 	case "", "stable", "ptb", "canary", "development", "auto":
@@ -33,95 +62,501 @@ func isValidBranch(branch string) bool {
 	}
 }
 
+// The code exitFailure exits with, unless it is set more specifically before
+var failureCode = ExitFailure
+
 func die(msg string) {
-	Log.Error(msg)
+	fail(msg)
+}
+
+func dieWith(code int, msg string) {
+	failureCode = code
+	fail(msg)
+}
+
+// dieFetchFailed exits as there is no release data. In offline mode that's because none is cached, which is worth
+// saying instead, as running once online fixes it
+func dieFetchFailed(msg string) {
+	dieWith(releaseFetchExitCode(), Ternary(IsOffline() && GithubError != nil, GithubError.Error(), msg))
+}
+
+// fail logs the error, which also ends up in the --json result, and exits with the exit code of the first error in a
+func fail(a ...any) {
+	Log.Error(SliceMap(a, localize)...)
+	resultErrors = append(resultErrors, strings.TrimSuffix(fmt.Sprintln(SliceMap(a, localize)...), "\n"))
+	for _, arg := range a {
+		if err, ok := arg.(error); ok && failureCode == ExitFailure {
+			failureCode = exitCodeFor(err)
+		}
+	}
 	exitFailure()
 }
 
 func main() {
-	InitGithubDownloader()
-	discords = FindDiscords()
+	RunElevatedHelper()
 
 	// Used by log.go init func
-	flag.Bool("debug", false, "Enable debug info")
+	flag.Bool("debug", false, "Log everything for troubleshooting, including all requests and file changes")
+	flag.Bool("verbose", false, "Same as --debug")
+	flag.Bool("quiet", false, "Only log errors")
+	// Used by target_user.go
+	flag.String("user", "", "Install for this user account instead of the current one, e.g. as an administrator. Its Discord installs are modified and the files are given to it")
 
 	var helpFlag = flag.Bool("help", false, "View usage instructions")
 	var versionFlag = flag.Bool("version", false, "View the program version")
-	var updateSelfFlag = flag.Bool("update-self", false, "Update me to the latest version")
+	var updateSelfFlag = flag.Bool("update-self", false, "Update me to the latest release, verifying the download")
 	var installFlag = flag.Bool("install", false, "Install Potatocord")
 	var updateFlag = flag.Bool("repair", false, "Repair Potatocord")
+	var updateAllFlag = flag.Bool("update", false, "Update Potatocord to the latest version and re-apply it to every patched install")
 	var uninstallFlag = flag.Bool("uninstall", false, "Uninstall Potatocord")
 	var installOpenAsarFlag = flag.Bool("install-openasar", false, "Install OpenAsar")
 	var uninstallOpenAsarFlag = flag.Bool("uninstall-openasar", false, "Uninstall OpenAsar")
+	var rollbackFlag = flag.Bool("rollback", false, "Restore the previously installed Potatocord version")
+	var downgradeFlag = flag.String("downgrade", "", "Restore the retained Potatocord version with this hash. Run --rollback interactively to pick one")
+	var keepVersionsFlag = flag.Int("keep-versions", 0, "How many previously installed Potatocord versions to keep for downgrading. Remembered for later runs")
+	var migrateFlag = flag.Bool("migrate-vencord", false, "Replace Vencord with Potatocord in all Discord installs and carry over its settings")
+	var doctorFlag = flag.Bool("doctor", false, "Check the network, write access, the Potatocord file and every Discord install for problems, without changing anything")
+	var troubleshootFlag = flag.Bool("troubleshoot", false, "Find and fix common reasons for Potatocord not loading")
+	var uninstallEverythingFlag = flag.Bool("uninstall-everything", false, "Remove Potatocord and OpenAsar from all Discord installs and delete Potatocord's files")
+	var removeDataFlag = flag.Bool("remove-data", false, "When uninstalling everything, also delete Potatocord's settings and data")
 	var locationFlag = flag.String("location", "", "The location of the Discord install to modify")
-	var branchFlag = flag.String("branch", "", "The branch of Discord to modify [auto|stable|ptb|canary]")
-	flag.Parse()
+	var allFlag = flag.Bool("all", false, "With --install, patch every Discord install that can be patched instead of a single one")
+	var registerFlag = flag.String("register", "", "Remember a Discord install outside the usual locations, e.g. a portable one, so it is always included")
+	var unregisterFlag = flag.String("unregister", "", "Forget a Discord install remembered with --register")
+	var targetsFlag = flag.String("targets", "", "Install, uninstall or repair every Discord install listed in this `file`, one path or branch per line, or stdin for -. Targets that aren't found fail without stopping the others")
+	var branchFlag branchList
+	flag.Var(&branchFlag, "branch", "The branch of Discord to modify [auto|stable|ptb|canary|development]. Repeat it or separate branches with commas to install, uninstall or repair several")
+	var healFlag = flag.Bool("heal", false, "Replace the installed Potatocord file with an intact copy of the same version if it is corrupted")
+	var downloadFlag = flag.String("download", "", "Download and verify the latest Potatocord build to this `file` without installing it. If it's a directory, the build is saved there as desktop.asar")
+	var buildFlag = flag.String("build", "", "With --download, the Potatocord version (`hash`) to download instead of the latest. Only the latest can be downloaded, others must be cached or retained")
+	var purgeCacheFlag = flag.Bool("purge-cache", false, "Delete all cached Potatocord downloads")
+	var notifyOnlyFlag = flag.Bool("notify-only", false, "Only check for updates and send a notification if outdated, for login scripts. Never downloads or changes anything")
+	var daemonFlag = flag.Bool("daemon", false, "Keep running in the background and notify about updates and broken installs")
+	var repatchFlag = flag.String("repatch-after-updates", "", "What to do when a Discord update removes Potatocord while the gui or --daemon is running: ask, always or never. Remembered for later runs")
+	var statusFlag = flag.Bool("status", false, "Check whether Potatocord is up to date and still injected into every patched install. Exits with 1 if not")
+	var listFlag = flag.Bool("list", false, "List all detected Discord installs and their patch status")
+	var checkFlag = flag.Bool("check", false, "Check whether a Potatocord update is available without looking at Discord. Exits with 0 if up to date and 11 if not")
+	var scheduleFlag = flag.String("schedule", "", "Register a job with the system scheduler that runs the update command at this `interval`: hourly, daily, weekly or a duration like 12h")
+	var unscheduleFlag = flag.Bool("unschedule", false, "Remove the job registered with --schedule")
+	var scheduleStatusFlag = flag.Bool("schedule-status", false, "Show the job registered with --schedule and when it last and next runs")
+	var latestFlag = flag.Bool("latest", false, "Print the latest Potatocord version and exit without changing anything")
+	var waitFlag = flag.Bool("wait", false, "With --latest, wait until the latest version was fetched")
+	var yesFlag = flag.Bool("yes", false, "Never ask anything, not even before uninstalling. Without --location or --branch, the first Discord install found is modified, preferring stable over canary, ptb and development. Discord is only closed with --kill-discord")
+	flag.BoolVar(yesFlag, "non-interactive", false, "Same as --yes")
+	var jsonFlag = flag.Bool("json", false, "Print machine readable json instead of text. Actions print their result, including all errors")
+	var jsonSchemaFlag = flag.Int("json-schema", JsonSchemaVersion, "The schema version of --json output. Schema 1 is deprecated and will be removed")
+	var reportFlag = flag.Bool("report", false, "If something goes wrong, collect a diagnostics bundle including Discord's logs")
+	var advancedFlag = flag.Bool("advanced", false, "Enable risky actions like overwriting other mods and custom update sources (POTATOCORD_UPDATE_SOURCE)")
+	var shareFlag = flag.Bool("share", false, "Share the installed Potatocord build with other machines on your local network")
+	var sharePortFlag = flag.Int("share-port", DefaultSharePort, "The port to share Potatocord on")
+	var killDiscordFlag = flag.Bool("kill-discord", false, "Close Discord without asking if it is running")
+	var relaunchFlag = flag.Bool("relaunch", false, "Start Discord again after closing it, or restart it after installing so it loads the new build")
+	var devBuildFlag = flag.String("dev-build", "", "Inject the Potatocord build in this directory, e.g. ~/potatocord/dist, via a link instead of downloading it. Rebuilding is enough to update it")
+	var logFileFlag = flag.String("log-file", "", "Also write the complete log, including debug lines, to this `file`, rotated once it reaches 1 MiB. Pass 'default' for the installer's log folder")
+	var progressFlag = flag.String("progress", string(ProgressNone), "Report progress for wrappers: none, json (one event per line on stdout with the stage, done, total, percent and message) ansi (OSC 9;4 sequences on stderr) or bar (a progress bar on stderr)")
+	var installDirFlag = flag.String("install-dir", "", "Install Potatocord to this directory instead of the default location and remember it. Pass 'default' to go back")
+	var scopeFlag = flag.String("scope", "", "Who to install for: user, or system for all users of a machine-wide Discord (needs administrator rights). Defaults to the scope the install was patched in")
+	var dryRunFlag = flag.Bool("dry-run", false, "Print what installing, repairing or uninstalling would change without changing anything")
+	var offlineFlag = flag.Bool("offline", false, "Never connect to the network and only install release data and builds that were cached before")
+	var shareFromFlag = flag.String("share-from", "", "Install the Potatocord build shared by another installer on your local network, e.g. http://192.168.1.2:8734")
+	var tuiFlag = flag.Bool("tui", false, "Manage all Discord installs from a keyboard driven menu that shows their status, e.g. over SSH")
+	var showConfigFlag = flag.Bool("show-config", false, "Print the configuration in effect and where each value comes from")
+	var completionFlag = flag.String("completion", "", "Print a completion script for this `shell`: bash, zsh, fish or powershell")
+	flag.Usage = printUsage
+	argsErr := parseArgs(os.Args[1:])
+	jsonOutput, assumeDefaults = *jsonFlag, *yesFlag
+	handleSignals()
+	if jsonOutput {
+		// Errors in the output are for scripts, which shouldn't have to handle every language
+		UseLanguage("en")
+	}
+	if argsErr != nil {
+		dieWith(ExitUsage, argsErr.Error())
+	}
+	if TargetUserError != nil {
+		fail(TargetUserError)
+	}
+	if BaseDirError != nil {
+		fail(BaseDirError)
+	}
+	if TargetUser != "" {
+		Log.Info("Modifying Discord for the user", TargetUser)
+	}
+
+	if *logFileFlag != "" {
+		if err := OpenLogFile(*logFileFlag); err != nil {
+			dieWith(ExitUsage, "Failed to open the log file: "+err.Error())
+		}
+		logFileSource = "--log-file"
+	}
+
+	if *jsonSchemaFlag != JsonSchemaVersion && *jsonSchemaFlag != legacyJsonSchemaVersion {
+		dieWith(ExitUsage, "Unsupported json schema "+strconv.Itoa(*jsonSchemaFlag)+". Supported are "+strconv.Itoa(legacyJsonSchemaVersion)+" and "+strconv.Itoa(JsonSchemaVersion))
+	}
+	jsonSchema = *jsonSchemaFlag
+
+	if *advancedFlag {
+		Settings.AdvancedMode = true
+	}
+
+	if err := SetProgressFormat(*progressFlag); err != nil {
+		fail(err)
+	}
+
+	scope, err := ParseScope(*scopeFlag)
+	if err != nil {
+		fail(err)
+	}
+	if scope == ScopeSystem && *installDirFlag != "" {
+		dieWith(ExitUsage, "The 'scope' and 'install-dir' flags are mutually exclusive.")
+	}
+
+	if *devBuildFlag != "" {
+		if err := UseDevBuild(*devBuildFlag); err != nil {
+			fail(err)
+		}
+	}
+
+	if *offlineFlag {
+		if *shareFromFlag != "" {
+			dieWith(ExitUsage, "The 'offline' and 'share-from' flags are mutually exclusive.")
+		}
+		UseOffline()
+	}
+
+	if *shareFromFlag != "" {
+		if err := UseShareSource(*shareFromFlag); err != nil {
+			fail(err)
+		}
+	}
+
+	if *completionFlag != "" {
+		resultAction = "completion"
+		// Before initialising the downloader, as shells may run this on every start
+		script, err := GenerateCompletion(*completionFlag, FindDiscords())
+		if err != nil {
+			dieWith(ExitUsage, err.Error())
+		}
+		fmt.Print(script)
+		return
+	}
+
+	if *showConfigFlag {
+		printConfig(*jsonFlag)
+		return
+	}
+
+	if *notifyOnlyFlag {
+		resultAction = "notify-only"
+		// Before initialising the downloader, which would start fetching right away
+		if _, err := RunNotifyOnly(); err != nil {
+			fail(err)
+		}
+		exitSuccess()
+	}
+
+	if *scheduleFlag != "" {
+		resultAction = "schedule"
+		// Nothing to download, the job does that when it runs
+		interval, err := ParseScheduleInterval(*scheduleFlag)
+		if err != nil {
+			dieWith(ExitUsage, err.Error())
+		}
+		if err = ScheduleUpdates(interval); err != nil {
+			fail("Failed to schedule updates:", err)
+		}
+		Log.Info("Potatocord will be updated every", formatScheduleInterval(interval)+". Remove the job with the unschedule command")
+		exitSuccess()
+	}
+
+	if *unscheduleFlag {
+		resultAction = "unschedule"
+		if err := UnscheduleUpdates(); errors.Is(err, os.ErrNotExist) {
+			Log.Info("No update job is scheduled")
+		} else if err != nil {
+			fail("Failed to unschedule updates:", err)
+		}
+		exitSuccess()
+	}
+
+	if *scheduleStatusFlag {
+		printScheduledJob(*jsonFlag)
+		return
+	}
+
+	// Not for the commands above, which return right away and must not reach out to GitHub
+	CheckForSelfUpdate()
+	// After parsing flags, as the update sources depend on advanced mode
+	InitGithubDownloader()
 
 	if *helpFlag {
 		flag.Usage()
 		return
 	}
 
+	if *versionFlag && *jsonFlag {
+		version := struct {
+			SchemaVersion int    `json:"schemaVersion"`
+			Version       string `json:"version"`
+			GitHash       string `json:"gitHash"`
+			BuildDate     string `json:"buildDate"`
+			GoVersion     string `json:"goVersion"`
+			Os            string `json:"os"`
+			Arch          string `json:"arch"`
+		}{JsonSchemaVersion, buildinfo.InstallerTag, buildinfo.InstallerGitHash, buildinfo.BuildDate, runtime.Version(), runtime.GOOS, runtime.GOARCH}
+		printJson(version, version)
+		return
+	}
 	if *versionFlag {
 		fmt.Println("Potatocord Installer Cli", buildinfo.InstallerTag, "("+buildinfo.InstallerGitHash+")")
+		fmt.Println("Built", buildinfo.BuildDate, "with", runtime.Version(), "for", runtime.GOOS+"/"+runtime.GOARCH)
 		fmt.Println("Copyright (C) 2023 Potatocord and Vencord contributors")
 		fmt.Println("License GPLv3+: GNU GPL version 3 or later <https://gnu.org/licenses/gpl.html>.")
 		return
 	}
 
 	if *updateSelfFlag {
-		if !<-SelfUpdateCheckDoneChan {
-			die("Can't update self because checking for updates failed")
+		resultAction = "update-self"
+		runSelfUpdate()
+	}
+
+	if *healFlag {
+		resultAction = "heal"
+		if err := SelfHeal(); err != nil {
+			fail(err)
 		}
-		if err := UpdateSelf(); err != nil {
-			Log.Error("Failed to update self:", err)
-			exitFailure()
+		exitSuccess()
+	}
+
+	if *buildFlag != "" && *downloadFlag == "" {
+		dieWith(ExitUsage, "The 'build' flag can only be used with 'download'.")
+	}
+	if *downloadFlag != "" {
+		resultAction = "download"
+		runDownload(*downloadFlag, *buildFlag, *jsonFlag)
+	}
+
+	if *purgeCacheFlag {
+		resultAction = "purge-cache"
+		size, err := GetCacheSize()
+		if err != nil {
+			Log.Warn("Failed to get cache size:", err)
+		}
+		if err = PurgeCache(); err != nil {
+			fail("Failed to purge cache:", err)
+		}
+		Log.Info("Freed", FormatBytes(uint64(size)))
+		exitSuccess()
+	}
+
+	if *repatchFlag != "" {
+		resultAction = "repatch-after-updates"
+		mode, err := ParseRepatchMode(*repatchFlag)
+		if err != nil {
+			fail(err)
+		}
+		Settings.RepatchAfterUpdate = mode
+		if err = Settings.Save(); err != nil {
+			fail("Failed to save settings:", err)
 		}
+		Log.Info("Potatocord will", map[RepatchMode]string{
+			RepatchAsk:    "ask before being re-applied",
+			RepatchAlways: "be re-applied automatically",
+			RepatchNever:  "not be re-applied",
+		}[mode], "after Discord updates")
+		if !*daemonFlag {
+			exitSuccess()
+		}
+	}
+
+	if *keepVersionsFlag != 0 {
+		resultAction = "keep-versions"
+		if *keepVersionsFlag < 0 {
+			dieWith(ExitUsage, "The 'keep-versions' flag must be positive.")
+		}
+		Settings.KeptVersions = *keepVersionsFlag
+		if err = Settings.Save(); err != nil {
+			fail("Failed to save settings:", err)
+		}
+		Log.Info("Keeping", *keepVersionsFlag, "previous versions from now on")
+		if *downgradeFlag == "" {
+			exitSuccess()
+		}
+	}
+
+	if *downgradeFlag != "" {
+		resultAction = "downgrade"
+		backup := ReadManifest().FindBackup(*downgradeFlag)
+		if backup == nil {
+			die("Version " + *downgradeFlag + " is not retained. Retained are: " + strings.Join(SliceMap(ReadManifest().Backups, func(b PotatocordBackup) string {
+				return Ternary(b.Hash != "", b.Hash, "unknown")
+			}), ", "))
+		}
+		if err = RestoreVersion(*backup); err != nil {
+			fail(err)
+		}
+		Log.Info("Restart Discord to use Potatocord", InstalledHash)
 		exitSuccess()
 	}
 
-	if *locationFlag != "" && *branchFlag != "" {
-		die("The 'location' and 'branch' flags are mutually exclusive.")
+	if *registerFlag != "" || *unregisterFlag != "" {
+		resultAction = Ternary(*registerFlag != "", "register", "unregister")
+		if *registerFlag != "" {
+			if di, err := RegisterDiscord(*registerFlag); err != nil {
+				fail(err)
+			} else {
+				Log.Info("Registered Discord", di.branch, "at", di.path)
+			}
+		}
+		if *unregisterFlag != "" {
+			if err := UnregisterDiscord(*unregisterFlag); err != nil {
+				fail(err)
+			}
+			Log.Info("Unregistered", *unregisterFlag)
+		}
+		exitSuccess()
+	}
+
+	if *daemonFlag {
+		RunBackgroundVerifier(BackgroundVerifyInterval)
+	}
+
+	if *shareFlag {
+		if err := ServeShare(*sharePortFlag); err != nil {
+			fail("Failed to share Potatocord:", err)
+		}
 	}
 
-	if !isValidBranch(*branchFlag) {
-		die("The 'branch' flag must be one of the following: [auto|stable|ptb|canary]")
+	if *latestFlag {
+		printLatest(*waitFlag, *jsonFlag)
+		return
+	}
+
+	if *checkFlag {
+		resultAction = "check"
+		exit(printCheck(*jsonFlag))
 	}
 
-	if *installFlag || *updateFlag {
-		if !<-GithubDoneChan {
-			die("Not " + Ternary(*installFlag, "installing", "updating") + " as fetching release data failed")
+	if *statusFlag {
+		exit(Ternary(printStatus(*jsonFlag), ExitSuccess, ExitFailure))
+	}
+
+	if *locationFlag != "" {
+		// Only the given install is looked at, nothing else is detected
+		di, err := ParseDiscordLocation(*locationFlag)
+		if err != nil {
+			dieWith(ExitNoDiscord, err.Error())
 		}
+		discords = []any{di}
+	} else {
+		discords = FindDiscords()
 	}
 
-	install, uninstall, update, installOpenAsar, uninstallOpenAsar := *installFlag, *uninstallFlag, *updateFlag, *installOpenAsarFlag, *uninstallOpenAsarFlag
-	switches := []*bool{&install, &update, &uninstall, &installOpenAsar, &uninstallOpenAsar}
+	if *listFlag {
+		printInstalls(*jsonFlag)
+		return
+	}
+
+	if *doctorFlag {
+		resultAction = "doctor"
+		exit(Ternary(printDoctorResults(*jsonFlag), ExitSuccess, ExitFailure))
+	}
+
+	if *tuiFlag {
+		resultAction = "tui"
+		if !isTerminal(os.Stdin) || jsonOutput {
+			dieWith(ExitUsage, "The tui needs a terminal")
+		}
+		runTui()
+		return
+	}
+
+	if *locationFlag != "" && len(branchFlag) != 0 {
+		dieWith(ExitUsage, "The 'location' and 'branch' flags are mutually exclusive.")
+	}
+	if *allFlag && (*locationFlag != "" || len(branchFlag) != 0) {
+		dieWith(ExitUsage, "The 'all' flag can't be combined with 'location' or 'branch'.")
+	}
+	if *targetsFlag != "" && (*locationFlag != "" || len(branchFlag) != 0 || *allFlag) {
+		dieWith(ExitUsage, "The 'targets' flag can't be combined with 'location', 'branch' or 'all'.")
+	}
+	if len(branchFlag) > 1 && SliceContains(branchFlag, "auto") {
+		dieWith(ExitUsage, "The 'auto' branch can't be combined with other branches.")
+	}
+
+	if len(branchFlag) == 0 && *locationFlag == "" && *targetsFlag == "" && !*allFlag && GetDefaultBranch() != "" {
+		if err := branchFlag.Set(GetDefaultBranch()); err != nil {
+			dieWith(ExitUsage, "The default branch "+GetDefaultBranch()+" "+err.Error())
+		}
+	}
+	branch := Ternary(len(branchFlag) == 1, branchFlag.String(), "")
+
+	if *installFlag || *updateFlag || *updateAllFlag || *migrateFlag {
+		if !WaitForGithub() {
+			dieFetchFailed(Ternary(*installFlag, "Not installing as fetching release data failed",
+				Ternary(*migrateFlag, "Not migrating as fetching release data failed", "Not updating as fetching release data failed")))
+		}
+	}
+
+	install, uninstall, update, installOpenAsar, uninstallOpenAsar, uninstallEverything, troubleshoot, rollback, migrate := *installFlag, *uninstallFlag, *updateFlag, *installOpenAsarFlag, *uninstallOpenAsarFlag, *uninstallEverythingFlag, *troubleshootFlag, *rollbackFlag, *migrateFlag
+	installAll := install && *allFlag
+	install = install && !installAll
+	updateAll := *updateAllFlag
+	switches := []*bool{&install, &update, &uninstall, &installOpenAsar, &uninstallOpenAsar, &uninstallEverything, &troubleshoot, &rollback, &migrate, &installAll, &updateAll}
+	if *installDirFlag != "" {
+		resultAction = "install-dir"
+		if err := SetInstallDir(Ternary(*installDirFlag == "default", "", *installDirFlag)); err != nil {
+			fail("Failed to change the install location:", err)
+		}
+		Log.Info("Potatocord is now installed to", GetInstallDir())
+		if !SliceContainsFunc(switches, func(b *bool) bool { return *b }) {
+			exitSuccess()
+		}
+	}
 	if !SliceContainsFunc(switches, func(b *bool) bool { return *b }) {
+		// Prompting without anyone to answer would wait forever
+		if assumeDefaults || !isTerminal(os.Stdin) {
+			dieWith(ExitUsage, "Nothing to do. Pass a command like install or status, see --help")
+		}
 		interactive = true
 
 		go func() {
 			<-SelfUpdateCheckDoneChan
 			if IsSelfOutdated {
-				Log.Warn("Your installer is outdated.")
-				Log.Warn("To update, select the 'Update Potatocord Installer' option to update, or run with --update-self")
+				Log.Warn(T("Your installer is outdated."))
+				Log.Warn(T("To update, select the 'Update Potatocord Installer' option to update, or run the self-update command"))
 			}
 		}()
 
+		if !CanUseArrowMenus() {
+			if !RunSimpleMenu() {
+				exitFailure()
+			}
+			exitSuccess()
+		}
+
 		choices := []string{
 			"Install Potatocord",
 			"Repair Potatocord",
 			"Uninstall Potatocord",
 			"Install OpenAsar",
 			"Uninstall OpenAsar",
+			"Uninstall Everything",
+			"Troubleshoot Potatocord",
+			"Roll Back Potatocord",
+			"Migrate from Vencord",
+			"Install Potatocord to Several Installs",
+			"Update Potatocord",
 			"View Help Menu",
 			"Update Potatocord Installer",
 			"Quit",
 		}
-		_, choice, err := (&promptui.Select{
-			Label: "What would you like to do? (Press Enter to confirm)",
-			Items: choices,
+		i, _, err := (&promptui.Select{
+			Label: T("What would you like to do? (Press Enter to confirm)"),
+			Items: SliceMap(choices, func(c string) string { return T(c) }),
 		}).Run()
 		handlePromptError(err)
+		choice := choices[i]
 
 		switch choice {
 		case "View Help Menu":
@@ -130,53 +565,200 @@ func main() {
 		case "Quit":
 			return
 		case "Update Potatocord Installer":
-			if err := UpdateSelf(); err != nil {
-				Log.Error("Failed to update self:", err)
-				exitFailure()
-			}
-			exitSuccess()
+			resultAction = "update-self"
+			runSelfUpdate()
 		}
 
-		*switches[SliceIndex(choices, choice)] = true
+		*switches[i] = true
 	}
+	actions := []string{"install", "repair", "uninstall", "install-openasar", "uninstall-openasar", "uninstall-everything", "troubleshoot", "rollback", "migrate-vencord", "install-all", "update"}
+	resultAction = actions[SliceIndexFunc(switches, func(b *bool) bool { return *b })]
 
-	var err error
 	var errSilent error
-	if install {
-		errSilent = PromptDiscord("patch", *locationFlag, *branchFlag).patch()
+	var target *DiscordInstall
+	var relaunchExe string
+	batch := len(branchFlag) > 1 || *targetsFlag != ""
+	if batch && (!(install || update || uninstall) || *dryRunFlag) {
+		dieWith(ExitUsage, "Only install, uninstall and repair can modify several installs at once")
+	}
+	if *dryRunFlag && (install || update || uninstall) {
+		target = PromptDiscord(Ternary(uninstall, "unpatch", Ternary(install, "patch", "repair")), *locationFlag, branch)
+		useScope(target, scope)
+		// What patching does depends on the latest release, so don't plan with the placeholder hash
+		if !uninstall {
+			WaitForGithub()
+		}
+		var changes []PlannedChange
+		switch {
+		case install:
+			changes, err = target.PlanPatch()
+		case update:
+			changes, err = target.PlanRepair()
+		default:
+			changes = target.PlanUnpatch()
+		}
+		if err != nil {
+			fail(err)
+		}
+		printPlan(changes, *jsonFlag)
+		exitSuccess()
+	} else if *dryRunFlag {
+		dieWith(ExitUsage, "--dry-run only supports --install, --repair and --uninstall")
+	}
+
+	if batch {
+		var installs []*DiscordInstall
+		var unresolved []PatchResult
+		if *targetsFlag != "" {
+			installs, unresolved = readTargets(*targetsFlag)
+		} else {
+			installs = findBranchInstalls(branchFlag)
+		}
+		switch {
+		case install:
+			offerMirrorHints()
+			errSilent = runOnInstalls(installs, unresolved, "patch", scope, (*DiscordInstall).patch, *killDiscordFlag, *relaunchFlag, *jsonFlag)
+		case uninstall:
+			if len(installs) != 0 {
+				confirmOrExit(T("Uninstall Potatocord from %s", strings.Join(SliceMap(installs, func(di *DiscordInstall) string { return di.DisplayName() }), ", ")))
+			}
+			errSilent = runOnInstalls(installs, unresolved, "unpatch", scope, (*DiscordInstall).unpatch, *killDiscordFlag, *relaunchFlag, *jsonFlag)
+		default:
+			offerMirrorHints()
+			errSilent = runOnInstalls(installs, unresolved, "repair", scope, (*DiscordInstall).Repair, *killDiscordFlag, *relaunchFlag, *jsonFlag)
+		}
+	} else if install {
+		offerMirrorHints()
+		target = offerSnapMigration(PromptDiscord("patch", *locationFlag, branch))
+		useScope(target, scope)
+		relaunchExe = closeRunningDiscord(target, *killDiscordFlag)
+		offerDisablingConflicts(target)
+		errSilent = target.patch()
 	} else if uninstall {
-		errSilent = PromptDiscord("unpatch", *locationFlag, *branchFlag).unpatch()
+		target = PromptDiscord("unpatch", *locationFlag, branch)
+		confirmOrExit(T("Uninstall Potatocord from %s", target.DisplayName()+" - "+target.path))
+		useScope(target, scope)
+		relaunchExe = closeRunningDiscord(target, *killDiscordFlag)
+		errSilent = target.unpatch()
 	} else if update {
-		Log.Info("Downloading latest Potatocord files...")
-		err := installLatestBuilds()
-		Log.Info("Done!")
-		if err == nil {
-			errSilent = PromptDiscord("repair", *locationFlag, *branchFlag).patch()
-		}
+		offerMirrorHints()
+		target = offerSnapMigration(PromptDiscord("repair", *locationFlag, branch))
+		useScope(target, scope)
+		relaunchExe = closeRunningDiscord(target, *killDiscordFlag)
+		offerDisablingConflicts(target)
+		err = target.Repair()
 	} else if installOpenAsar {
-		discord := PromptDiscord("patch", *locationFlag, *branchFlag)
+		discord := PromptDiscord("patch", *locationFlag, branch)
+		resultInstall = discord
 		if !discord.IsOpenAsar() {
 			err = discord.InstallOpenAsar()
 		} else {
 			die("OpenAsar already installed")
 		}
 	} else if uninstallOpenAsar {
-		discord := PromptDiscord("patch", *locationFlag, *branchFlag)
+		discord := PromptDiscord("patch", *locationFlag, branch)
+		resultInstall = discord
 		if discord.IsOpenAsar() {
 			err = discord.UninstallOpenAsar()
 		} else {
 			die("OpenAsar not installed")
 		}
+	} else if troubleshoot {
+		if !WaitForGithub() {
+			Log.Warn(T("Fetching release data failed. Potatocord files can't be repaired"))
+		}
+		target = PromptDiscord("troubleshoot", *locationFlag, branch)
+		errSilent = runTroubleshooter(target, *reportFlag)
+	} else if rollback {
+		if backups := ReadManifest().Backups; interactive && len(backups) > 1 {
+			err = RestoreVersion(pickVersion(backups))
+		} else {
+			err = Rollback()
+		}
+	} else if migrate {
+		if interactive && !WaitForGithub() {
+			dieFetchFailed("Not migrating as fetching release data failed")
+		}
+		errSilent = migrateFromVencord(*killDiscordFlag, *relaunchFlag)
+	} else if installAll {
+		if interactive && !WaitForGithub() {
+			dieFetchFailed("Not installing as fetching release data failed")
+		}
+		offerMirrorHints()
+		errSilent = patchSeveral(*killDiscordFlag, *relaunchFlag, *jsonFlag)
+	} else if updateAll {
+		if interactive && !WaitForGithub() {
+			dieFetchFailed("Not updating as fetching release data failed")
+		}
+		offerMirrorHints()
+		errSilent = updatePatched(*killDiscordFlag, *relaunchFlag, *jsonFlag)
+	} else if uninstallEverything {
+		removeData := *removeDataFlag
+		confirmOrExit(T("This will remove Potatocord and OpenAsar from all Discord installs. Continue"))
+		if !removeData && canPrompt() {
+			removeData = ask(T("Also delete Potatocord's settings and data at %s", BaseDir), false)
+		}
+
+		for _, e := range UninstallEverything(removeData) {
+			Log.Error(localize(e))
+			resultErrors = append(resultErrors, e.Error())
+			if errSilent == nil {
+				errSilent = e
+			}
+		}
+	}
+
+	if errors.Is(errSilent, errUpToDate) {
+		exitUpToDate()
 	}
 
+	if target != nil {
+		resultInstall = target
+	}
 	if err != nil {
-		Log.Error(err)
-		exitFailure()
+		Log.Error(localize(err))
+		resultErrors = append(resultErrors, err.Error())
+	} else if errSilent != nil && !uninstallEverything {
+		resultErrors = append(resultErrors, errSilent.Error())
 	}
-	if errSilent != nil {
+	if err != nil || errSilent != nil {
+		failureCode = exitCodeFor(Ternary(err != nil, err, errSilent))
+		if target != nil {
+			offerDiagnostics(target, *reportFlag)
+		}
 		exitFailure()
 	}
 
+	if relaunchExe != "" && (*relaunchFlag || interactive) {
+		if err = target.RelaunchDiscord(relaunchExe); err != nil {
+			Log.Warn(T("Failed to start Discord:"), err)
+		}
+	} else if install || update {
+		offerRestart(target, *relaunchFlag)
+	}
+
+	exitSuccess()
+}
+
+// runSelfUpdate replaces the installer with the latest release and exits
+func runSelfUpdate() {
+	//goland:noinspection GoBoolExpressions
+	if buildinfo.InstallerTag == buildinfo.VersionUnknown {
+		dieWith(ExitFailure, "Can't update self because this is not a release build")
+	}
+	if IsOffline() {
+		dieWith(ExitNotCached, "Can't update self in offline mode")
+	}
+	if !<-SelfUpdateCheckDoneChan {
+		dieWith(releaseFetchExitCode(), "Can't update self because checking for updates failed")
+	}
+	if !IsSelfOutdated {
+		Log.Info("The installer is already at the latest version", buildinfo.InstallerTag)
+		exitUpToDate()
+	}
+	if err := UpdateSelf(); err != nil {
+		fail("Failed to update self:", err)
+	}
 	exitSuccess()
 }
 
@@ -190,13 +772,57 @@ func exit(status int) {
 }
 
 func exitSuccess() {
-	color.HiGreen("✔ Success!")
-	exit(0)
+	ReportProgress(StageDone, 1, 1)
+	changes := LogChanges()
+	if jsonOutput {
+		printActionResult(ExitSuccess, changes)
+	} else if LogLevel <= LevelInfo && progressFormat != ProgressJson {
+		color.HiGreen("✔ " + T("Success!"))
+	}
+	exit(ExitSuccess)
+}
+
+func exitUpToDate() {
+	ReportProgress(StageDone, 1, 1)
+	changes := LogChanges()
+	if jsonOutput {
+		printActionResult(ExitUpToDate, changes)
+	} else if LogLevel <= LevelInfo && progressFormat != ProgressJson {
+		color.HiGreen("✔ " + T("Already up to date!"))
+	}
+	exit(ExitUpToDate)
 }
 
 func exitFailure() {
-	color.HiRed("❌ Failed!")
-	exit(1)
+	ReportProgressMessage(StageFailed, 0, -1, strings.Join(resultErrors, "\n"))
+	// Failed operations may still have changed something, e.g. when undoing failed too
+	changes := LogChanges()
+	if jsonOutput {
+		printActionResult(failureCode, changes)
+	} else if progressFormat != ProgressJson {
+		// The failed event says so already, and wrappers expect nothing but events on stdout
+		color.HiRed("❌ " + T("Failed!"))
+	}
+	exit(failureCode)
+}
+
+// handleSignals cancels downloads and installs on Ctrl+C or SIGTERM, so they clean up after themselves and undo what
+// they changed instead of leaving a half written install behind. Without any running, or on a second signal, it exits
+// right away
+func handleSignals() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if !CancelOperations() {
+			failureCode = ExitCancelled
+			resultErrors = append(resultErrors, ErrCancelled.Error())
+			exitFailure()
+		}
+		Log.Warn(T("Cancelling, undoing what was changed so far. Press Ctrl+C again to exit right away"))
+		<-signals
+		exit(ExitCancelled)
+	}()
 }
 
 func handlePromptError(err error) {
@@ -209,55 +835,64 @@ func handlePromptError(err error) {
 
 func PromptDiscord(action, dir, branch string) *DiscordInstall {
 	if branch == "auto" {
-		for _, b := range []string{"stable", "canary", "ptb"} {
-			for _, discord := range discords {
-				install := discord.(*DiscordInstall)
-				if install.branch == b {
-					return install
-				}
+		for _, b := range DiscordBranches {
+			if install := FindDiscordByBranch(b); install != nil {
+				return install
 			}
 		}
-		die("No Discord install found. Try manually specifying it with the --dir flag. Hint: snap is not supported")
+		dieWith(ExitNoDiscord, "No Discord install found. Try manually specifying it with the --location flag")
 	}
 
 	if branch != "" {
-		for _, discord := range discords {
-			install := discord.(*DiscordInstall)
-			if install.branch == branch {
-				return install
-			}
+		if install := FindDiscordByBranch(branch); install != nil {
+			return install
 		}
-		die("Discord " + branch + " not found")
+		dieWith(ExitNoDiscord, T("Discord %s not found", branch))
 	}
 
 	if dir != "" {
-		if discord := ParseDiscord(dir, branch); discord != nil {
-			return discord
-		} else {
-			die(dir + " is not a valid Discord install. Hint: snap is not supported")
+		discord, err := ParseDiscordLocation(dir)
+		if err != nil {
+			dieWith(ExitNoDiscord, err.Error())
+		}
+		return discord
+	}
+
+	if !interactive {
+		installs := FindPatchableDiscords(discords)
+		switch {
+		case len(installs) == 0:
+			dieWith(ExitNoDiscord, "No Discord install found. Try manually specifying it with the --location flag")
+		case len(installs) == 1:
+			// Nothing to choose from, so there is nothing to ask either
+			return installs[0]
+		case assumeDefaults:
+			di := findDefaultInstall(installs)
+			Log.Info(T("Picked Discord %s at %s. Pass --branch or --location to pick another", di.branch, di.path))
+			return di
 		}
+		dieWith(ExitUsage, "Found "+strconv.Itoa(len(installs))+" Discord installs. Pick one to "+action+" with the --location or --branch flag, or pass --yes to use the default")
 	}
 
 	items := SliceMap(discords, func(d any) string {
 		install := d.(*DiscordInstall)
-		//goland:noinspection GoDeprecation
-		return fmt.Sprintf("%s - %s%s", strings.Title(install.branch), install.path, Ternary(install.isPatched, " [PATCHED]", ""))
+		return install.DisplayName() + " - " + install.path + install.StatusText()
 	})
-	items = append(items, "Custom Location")
+	items = append(items, T("Custom Location"))
 
-	_, choice, err := (&promptui.Select{
-		Label: "Select Discord install to " + action + " (Press Enter to confirm)",
+	i, _, err := (&promptui.Select{
+		Label: T("Select Discord install to " + action + " (Press Enter to confirm)"),
 		Items: items,
 	}).Run()
 	handlePromptError(err)
 
-	if choice != "Custom Location" {
-		return discords[SliceIndex(items, choice)].(*DiscordInstall)
+	if i < len(discords) {
+		return discords[i].(*DiscordInstall)
 	}
 
 	for {
 		custom, err := (&promptui.Prompt{
-			Label: "Custom Discord Location",
+			Label: T("Custom Discord Location"),
 		}).Run()
 		handlePromptError(err)
 
@@ -265,8 +900,737 @@ func PromptDiscord(action, dir, branch string) *DiscordInstall {
 			return di
 		}
 
-		Log.Error("Invalid Discord install!")
+		Log.Error(T("Invalid Discord install!"))
+	}
+}
+
+// readTargets reads the targets file, or stdin for -, and finds the installs it lists
+func readTargets(file string) ([]*DiscordInstall, []PatchResult) {
+	r := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			dieWith(ExitUsage, "Failed to read the targets file: "+err.Error())
+		}
+		defer f.Close()
+		r = f
+	}
+	targets, err := ReadTargets(r)
+	if err != nil {
+		dieWith(ExitUsage, "Failed to read the targets file: "+err.Error())
+	}
+	if len(targets) == 0 {
+		dieWith(ExitUsage, "No targets in "+Ternary(file == "-", "stdin", file))
+	}
+	return ResolveTargets(targets)
+}
+
+// findBranchInstalls returns the installs of the given branches, dying if any of them isn't installed
+func findBranchInstalls(branches []string) []*DiscordInstall {
+	installs := make([]*DiscordInstall, len(branches))
+	for i, branch := range branches {
+		if installs[i] = FindDiscordByBranch(branch); installs[i] == nil {
+			dieWith(ExitNoDiscord, T("Discord %s not found", branch))
+		}
+	}
+	return installs
+}
+
+// findDefaultInstall returns the install of the first branch in DiscordBranches, i.e. stable if there is one
+func findDefaultInstall(installs []*DiscordInstall) *DiscordInstall {
+	for _, branch := range DiscordBranches {
+		if i := SliceIndexFunc(installs, func(di *DiscordInstall) bool { return di.branch == branch }); i != -1 {
+			return installs[i]
+		}
+	}
+	return installs[0]
+}
+
+// offerSnapMigration offers to migrate snap installs, which can't be patched, to the Flatpak and returns the
+// install to patch
+func offerSnapMigration(di *DiscordInstall) *DiscordInstall {
+	if !di.isSnap {
+		return di
+	}
+
+	Log.Warn(T(ErrSnapReadOnly.Error()))
+	if !interactive {
+		dieWith(ExitUsage, "Run the installer interactively to migrate to the Flatpak")
+	}
+
+	if !ask(T("Install the Flatpak version of Discord and copy your data over"), false) {
+		exitFailure()
+	}
+
+	flatpak, err := MigrateSnapToFlatpak(di)
+	if err != nil {
+		fail("Failed to migrate to the Flatpak:", err)
+	}
+	return flatpak
+}
+
+// closeRunningDiscord closes the install if it is running, asking first unless kill is set. Returns the executable
+// to relaunch once done, or an empty string if Discord wasn't closed
+func closeRunningDiscord(di *DiscordInstall, kill bool) string {
+	if !di.IsRunning() {
+		return ""
+	}
+
+	Log.Warn(T("Discord %s is running", di.branch))
+	if !kill {
+		if !canPrompt() {
+			// Windows doesn't let us replace files that are in use
+			if runtime.GOOS == "windows" {
+				dieWith(ExitDiscordRunning, "Close Discord or pass --kill-discord")
+			}
+			Log.Warn(T("Restart Discord afterwards for the changes to take effect"))
+			return ""
+		}
+
+		if !ask(T("Close Discord %s now and restart it afterwards", di.branch), false) {
+			if runtime.GOOS == "windows" {
+				failureCode = ExitDiscordRunning
+				exitFailure()
+			}
+			Log.Warn(T("Restart Discord afterwards for the changes to take effect"))
+			return ""
+		}
+	}
+
+	exe, err := di.CloseDiscord()
+	if err != nil {
+		fail(err)
+	}
+	return exe
+}
+
+func useScope(di *DiscordInstall, scope InstallScope) {
+	if err := UseScopeFor(di, scope); err != nil {
+		fail(err)
+	}
+}
+
+// migrateFromVencord replaces Vencord with Potatocord after confirming, closing Discord for the time being
+func migrateFromVencord(kill, relaunch bool) error {
+	migration := FindVencordMigration()
+	if migration == nil {
+		dieWith(ExitNoDiscord, "No Discord install with Vencord found")
+	}
+
+	Log.Info("Vencord", migration.Hash, "is installed in:")
+	for _, di := range migration.Installs {
+		Log.Info("  " + di.DisplayName() + " - " + di.path)
+	}
+	if migration.DataDir != "" {
+		Log.Info("Its settings, QuickCSS and themes in", migration.DataDir, "will be carried over")
+	}
+
+	confirmOrExit(T("Replace Vencord with Potatocord"))
+
+	exes := make([]string, len(migration.Installs))
+	for i, di := range migration.Installs {
+		exes[i] = closeRunningDiscord(di, kill)
+	}
+
+	err := migration.Migrate()
+	if err != nil {
+		Log.Error(err)
+	}
+
+	for i, di := range migration.Installs {
+		if exes[i] != "" && (relaunch || interactive) {
+			if err := di.RelaunchDiscord(exes[i]); err != nil {
+				Log.Warn(T("Failed to start Discord:"), err)
+			}
+		}
+	}
+	return err
+}
+
+// pickVersion lets the user choose one of the retained versions, newest first
+func pickVersion(backups []PotatocordBackup) PotatocordBackup {
+	var items []string
+	for i := len(backups) - 1; i >= 0; i-- {
+		items = append(items, Ternary(backups[i].Hash != "", backups[i].Hash, "Unknown version")+" - installed until "+backups[i].Time.Format(time.DateTime))
+	}
+	i, _, err := (&promptui.Select{
+		Label: T("Select the version to restore (Press Enter to confirm)"),
+		Items: items,
+	}).Run()
+	handlePromptError(err)
+	return backups[len(backups)-1-i]
+}
+
+// patchSeveral patches all installs, or the ones the user picks when interactive, and reports how each went
+func patchSeveral(kill, relaunch, asJson bool) error {
+	var installs []*DiscordInstall
+	for _, di := range FindPatchableDiscords(discords) {
+		if interactive && !ask(T("Patch %s", di.DisplayName()+" - "+di.path+di.StatusText()), true) {
+			continue
+		}
+		installs = append(installs, di)
+	}
+	if len(installs) == 0 {
+		dieWith(ExitNoDiscord, "No Discord install to patch")
+	}
+	return patchInstalls(installs, kill, relaunch, asJson)
+}
+
+var errUpToDate = errors.New("Already up to date")
+
+// updatePatched updates Potatocord and re-applies it to every patched install that doesn't load the latest version.
+// Returns errUpToDate if there was nothing to do
+func updatePatched(kill, relaunch, asJson bool) error {
+	var installs []*DiscordInstall
+	for _, di := range FindPatchableDiscords(discords) {
+		if di.isPatched && (!HashesMatch(LatestHash, di.PatchedHash()) || di.VerifyInjection() != nil) {
+			installs = append(installs, di)
+		}
+	}
+	if len(installs) == 0 {
+		if HashesMatch(LatestHash, InstalledHash) {
+			Log.Info(T("Potatocord %s is up to date", InstalledHash))
+			return errUpToDate
+		}
+		Log.Info("All patched installs are up to date, only updating Potatocord's files")
+		return InstallLatestBuilds()
+	}
+	return patchInstalls(installs, kill, relaunch, asJson)
+}
+
+func patchInstalls(installs []*DiscordInstall, kill, relaunch, asJson bool) error {
+	return runOnInstalls(installs, nil, "patch", "", (*DiscordInstall).patch, kill, relaunch, asJson)
+}
+
+// runOnInstalls closes the installs, runs the action on each and prints the result of each, after the results of
+// targets that weren't found
+func runOnInstalls(installs []*DiscordInstall, unresolved []PatchResult, action string, scope InstallScope, fn func(di *DiscordInstall) error, kill, relaunch, asJson bool) error {
+	exes := make([]string, len(installs))
+	for i, di := range installs {
+		exes[i] = closeRunningDiscord(di, kill)
+	}
+
+	results := append(unresolved, RunAll(installs, action, scope, fn)...)
+
+	for i, di := range installs {
+		if exes[i] != "" && (relaunch || interactive) {
+			if err := di.RelaunchDiscord(exes[i]); err != nil {
+				Log.Warn(T("Failed to start Discord:"), err)
+			}
+		}
+	}
+
+	if asJson {
+		failed := 0
+		for _, r := range results {
+			if r.Error != "" {
+				failed++
+			}
+		}
+		printJson(struct {
+			SchemaVersion int           `json:"schemaVersion"`
+			Succeeded     int           `json:"succeeded"`
+			Failed        int           `json:"failed"`
+			Results       []PatchResult `json:"results"`
+			Changes       Changes       `json:"changes"`
+		}{JsonSchemaVersion, len(results) - failed, failed, results, LogChanges()}, results)
+	} else {
+		for _, r := range results {
+			// Targets that weren't found lack the branch or the path
+			name := Ternary(r.Branch == "", r.Path, Ternary(r.Path == "", "Discord "+r.Branch, "Discord "+r.Branch+" - "+r.Path))
+			if r.Error == "" {
+				color.HiGreen("✔ " + name)
+			} else {
+				color.HiRed("❌ " + name + ": " + r.Error)
+			}
+		}
+	}
+	return FailedBatch(action, results)
+}
+
+// offerDisablingConflicts warns about other client mods in the install and offers to disable them
+func offerDisablingConflicts(di *DiscordInstall) {
+	mods := FindConflictingMods(di)
+	if len(mods) == 0 {
+		return
+	}
+
+	for _, mod := range mods {
+		Log.Warn(T("%s was modified by %s. Using it together with Potatocord will likely break Discord", mod.File, mod.DisplayName()))
+	}
+	if !interactive {
+		Log.Warn(T("Run the installer interactively or use --troubleshoot to disable them"))
+		return
+	}
+
+	if !ask(T("Disable them? The modified files are kept with the suffix %s", disabledModSuffix), false) {
+		return
+	}
+
+	if err := DisableConflictingMods(mods); err != nil {
+		Log.Error(err)
+	}
+}
+
+// offerRestart offers to restart Discord if it is still running the previous build
+func offerRestart(di *DiscordInstall, restart bool) {
+	if !di.IsRunning() {
+		return
+	}
+
+	if !restart {
+		if !interactive {
+			Log.Info(T("Restart Discord for the changes to take effect"))
+			return
+		}
+
+		if !ask(T("Restart Discord now to load Potatocord"), false) {
+			return
+		}
+	}
+
+	if err := di.RestartDiscord(); err != nil {
+		Log.Warn(T("Failed to restart Discord:"), err)
+	}
+}
+
+// offerMirrorHints asks the user whether to use the mirrors suggested for their region, if any
+func offerMirrorHints() {
+	if !WaitForGithub() || len(PendingMirrorHints) == 0 {
+		return
+	}
+
+	for _, hint := range PendingMirrorHints {
+		Log.Info(T("Suggested mirror for your region:"), hint.Name, "("+hint.Url+")")
+	}
+	if !interactive {
+		Log.Info(T("Run the installer interactively to use them"))
+		return
+	}
+
+	if ask(T("Use these mirrors in case downloading from GitHub is slow or fails"), false) {
+		AcceptMirrorHints()
+	} else {
+		DeclineMirrorHints()
+	}
+}
+
+// JsonSchemaVersion is the version of the --json output. Bump it on breaking changes, i.e. when removing, renaming
+// or changing the meaning of fields, and keep the previous schema available via --json-schema for one release
+const JsonSchemaVersion = 2
+
+// Schema 1 had no schemaVersion and printed lists as bare arrays
+const legacyJsonSchemaVersion = 1
+
+var jsonSchema = JsonSchemaVersion
+var jsonOutput, printedJson bool
+
+// What was done, for the --json result
+var (
+	resultAction  string
+	resultInstall *DiscordInstall
+	resultErrors  []string
+)
+
+// printJson prints payload, which must contain the schemaVersion, or legacyPayload if the legacy schema was requested
+func printJson(payload, legacyPayload any) {
+	if jsonSchema == legacyJsonSchemaVersion {
+		Log.Warn("json schema", legacyJsonSchemaVersion, "is deprecated and will be removed in a future release")
+		payload = legacyPayload
+	}
+	b, _ := json.Marshal(payload)
+	fmt.Println(string(b))
+	printedJson = true
+}
+
+// actionResult is what actions print with --json when they're done, unless they already printed a result of their own
+type actionResult struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Action        string       `json:"action,omitempty"`
+	Success       bool         `json:"success"`
+	ExitCode      int          `json:"exitCode"`
+	Errors        []string     `json:"errors,omitempty"`
+	Install       *installInfo `json:"install,omitempty"`
+	InstalledHash string       `json:"installedHash,omitempty"`
+	LatestHash    string       `json:"latestHash,omitempty"`
+	Changes       *Changes     `json:"changes,omitempty"`
+}
+
+func printActionResult(code int, changes Changes) {
+	if printedJson {
+		return
+	}
+	result := actionResult{
+		SchemaVersion: JsonSchemaVersion,
+		Action:        resultAction,
+		Success:       code == ExitSuccess || code == ExitUpToDate,
+		ExitCode:      code,
+		Errors:        resultErrors,
+		InstalledHash: ReadInstalledHash(),
+		LatestHash:    Ternary(LatestHash != "Unknown", LatestHash, ""),
+	}
+	if !changes.IsEmpty() {
+		result.Changes = &changes
+	}
+	// Re-parse, as the install changed
+	if resultInstall != nil {
+		if di := ParseDiscord(resultInstall.path, resultInstall.branch); di != nil {
+			info := newInstallInfo(di)
+			result.Install = &info
+		}
+	}
+	printJson(result, result)
+}
+
+type installInfo struct {
+	Branch      string `json:"branch"`
+	Path        string `json:"path"`
+	Patched     bool   `json:"patched"`
+	Version     string `json:"version,omitempty"`
+	PatchedHash string `json:"patchedHash,omitempty"`
+	OpenAsar    bool   `json:"openAsar"`
+}
+
+// printStatus prints the status of Potatocord and all installs and returns whether everything is healthy
+func printStatus(asJson bool) bool {
+	status := GetStatus()
+	if asJson {
+		printJson(struct {
+			SchemaVersion int  `json:"schemaVersion"`
+			Healthy       bool `json:"healthy"`
+			PotatocordStatus
+		}{JsonSchemaVersion, status.Healthy(), status}, status)
+		return status.Healthy()
+	}
+
+	switch {
+	case !status.Present:
+		fmt.Println("Potatocord: not installed (" + status.File + ")")
+	case status.CorruptionError != "":
+		fmt.Println("Potatocord: corrupted,", status.CorruptionError+". Run with --heal to fix it")
+	default:
+		fmt.Println("Potatocord:", status.InstalledHash, "("+status.File+")")
+	}
+	switch {
+	case status.LatestError != "":
+		fmt.Println("Latest: unknown,", status.LatestError)
+	case status.UpToDate:
+		fmt.Println("Latest:", status.LatestHash, "- up to date")
+	default:
+		fmt.Println("Latest:", status.LatestHash, "- outdated")
+	}
+
+	for _, i := range status.Installs {
+		line := "Discord " + i.Branch + " - " + i.Path + ": "
+		switch {
+		case !i.Patched:
+			line += "not patched"
+		case !i.InjectionIntact:
+			line += "injection broken, " + i.InjectionError
+		case !i.UpToDate:
+			line += "patched, outdated"
+		default:
+			line += "patched, up to date"
+		}
+		fmt.Println(line)
+	}
+	return status.Healthy()
+}
+
+func printPlan(changes []PlannedChange, asJson bool) {
+	if asJson {
+		printJson(struct {
+			SchemaVersion int             `json:"schemaVersion"`
+			Changes       []PlannedChange `json:"changes"`
+		}{JsonSchemaVersion, changes}, changes)
+		return
+	}
+	fmt.Println(FormatPlan(changes))
+}
+
+func newInstallInfo(di *DiscordInstall) installInfo {
+	return installInfo{di.branch, di.path, di.isPatched, GetDiscordVersion(di), di.PatchedHash(), di.IsOpenAsar()}
+}
+
+func printInstalls(asJson bool) {
+	// Whether they're outdated depends on the latest release
+	WaitForGithub()
+	infos := SliceMap(discords, func(d any) installInfo {
+		return newInstallInfo(d.(*DiscordInstall))
+	})
+
+	if asJson {
+		printJson(struct {
+			SchemaVersion int `json:"schemaVersion"`
+			// All installs load the same Potatocord file, so they are outdated together
+			Outdated bool          `json:"outdated"`
+			Installs []installInfo `json:"installs"`
+		}{JsonSchemaVersion, IsPotatocordOutdated(), infos}, infos)
+		return
+	}
+
+	if IsPotatocordOutdated() {
+		fmt.Println(T("Potatocord %s is installed, %s is available", InstalledHash, LatestHash))
+	}
+	if len(infos) == 0 {
+		fmt.Println("No Discord installs found")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "BRANCH\tVERSION\tPATCHED\tHASH\tPATH")
+	for i, d := range discords {
+		di, info := d.(*DiscordInstall), infos[i]
+		patched := Ternary(info.Patched, "yes", "no")
+		switch {
+		case di.isStore:
+			patched = "unsupported (Microsoft Store)"
+		case di.isSnap:
+			patched = "unsupported (Snap)"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Branch, Ternary(info.Version != "", info.Version, "-"), patched,
+			Ternary(info.PatchedHash != "", info.PatchedHash, "-"), info.Path)
+	}
+	_ = w.Flush()
+}
+
+func printConfig(asJson bool) {
+	values := EffectiveConfig()
+	if asJson {
+		printJson(struct {
+			SchemaVersion int           `json:"schemaVersion"`
+			Config        []ConfigValue `json:"config"`
+		}{JsonSchemaVersion, values}, values)
+		return
+	}
+	for _, v := range values {
+		fmt.Printf("%-20s %s (%s)\n", v.Key, v.Value, v.Source)
+	}
+}
+
+type latestInfo struct {
+	Done          bool   `json:"done"`
+	Error         string `json:"error,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Tag           string `json:"tag,omitempty"`
+	Hash          string `json:"hash"`
+	InstalledHash string `json:"installedHash"`
+	UpToDate      bool   `json:"upToDate"`
+}
+
+// runDownload saves the build to file without installing it and exits
+func runDownload(file, hash string, asJson bool) {
+	// Also for other versions, as whether they're the latest one decides if they can be downloaded
+	if !WaitForGithub() && hash == "" {
+		dieFetchFailed("Not downloading as fetching release data failed")
+	}
+	target, err := GetDownloadTarget(file)
+	if err != nil {
+		fail(err)
+	}
+	replace := assumeDefaults
+	if !replace && ExistsFile(target) {
+		if !canPrompt() {
+			fail(errors.New(target + " already exists. Pass --yes to replace it"))
+		}
+		confirmOrExit(T("%s already exists. Replace it", target))
+		replace = true
+	}
+
+	build, err := DownloadBuild(Ternary(hash != "", hash, LatestHash), file, replace)
+	if err != nil {
+		fail(err)
+	}
+
+	if asJson {
+		printJson(struct {
+			SchemaVersion int `json:"schemaVersion"`
+			DownloadedBuild
+		}{JsonSchemaVersion, *build}, build)
+	} else {
+		Log.Info(T("Saved Potatocord %s to %s", build.Hash, build.File))
+		Log.Info("SHA-256:", build.Sha256)
+	}
+	exitSuccess()
+}
+
+func printLatest(wait, asJson bool) {
+	info := latestInfo{}
+	if wait {
+		WaitForGithub()
+		info.Done = true
+	} else {
+		info.Done = GithubFinished()
+	}
+
+	if GithubError != nil {
+		info.Error = GithubError.Error()
+	}
+	info.Name, info.Tag = ReleaseData.Name, ReleaseData.TagName
+	info.Hash, info.InstalledHash = LatestHash, InstalledHash
+	info.UpToDate = info.Done && info.Error == "" && HashesMatch(LatestHash, InstalledHash)
+
+	if asJson {
+		printJson(struct {
+			SchemaVersion int `json:"schemaVersion"`
+			latestInfo
+		}{JsonSchemaVersion, info}, info)
+		return
+	}
+
+	switch {
+	case !info.Done:
+		fmt.Println("Latest version is not known yet. Use --wait to wait for it")
+	case info.Error != "":
+		fmt.Println("Failed to fetch latest version:", info.Error)
+	default:
+		fmt.Println("Latest:", info.Hash, "("+info.Name+")")
+		fmt.Println("Installed:", info.InstalledHash)
+	}
+}
+
+// printCheck compares the installed Potatocord with the latest release and returns the code to exit with. Made for
+// cron jobs and status bars, so it prints a single line
+func printCheck(asJson bool) int {
+	WaitForGithub()
+	info := latestInfo{Done: true, Name: ReleaseData.Name, Tag: ReleaseData.TagName, Hash: LatestHash, InstalledHash: InstalledHash}
+	code := ExitUpdateAvailable
+	if GithubError != nil {
+		info.Error = GithubError.Error()
+		code = releaseFetchExitCode()
+	} else if info.UpToDate = HashesMatch(LatestHash, InstalledHash); info.UpToDate {
+		code = ExitSuccess
+	}
+
+	if asJson {
+		printJson(struct {
+			SchemaVersion int `json:"schemaVersion"`
+			ExitCode      int `json:"exitCode"`
+			latestInfo
+		}{JsonSchemaVersion, code, info}, info)
+		return code
+	}
+
+	switch {
+	case info.Error != "":
+		fmt.Println("Failed to check for updates:", info.Error)
+	case info.UpToDate:
+		fmt.Println("Potatocord", InstalledHash, "is up to date")
+	case InstalledHash == "None":
+		fmt.Println("Potatocord is not installed. Latest version:", LatestHash)
+	default:
+		fmt.Println("Potatocord update available:", InstalledHash, "->", LatestHash)
+	}
+	return code
+}
+
+func printScheduledJob(asJson bool) {
+	job, err := GetScheduledJob()
+	if err != nil {
+		fail("Failed to look up the scheduled job:", err)
+	}
+	if asJson {
+		printJson(struct {
+			SchemaVersion int `json:"schemaVersion"`
+			ScheduledJob
+		}{JsonSchemaVersion, job}, job)
+		return
+	}
+
+	if !job.Registered {
+		fmt.Println("No update job is scheduled. Add one with the schedule command")
+		return
+	}
+	fmt.Println("Updates are scheduled every", Ternary(job.Interval != "", job.Interval, "unknown interval"), "("+job.Location+")")
+	if job.Command != "" {
+		fmt.Println("Command:", job.Command)
+	}
+	fmt.Println("Next run:", Ternary(job.NextRun != "", job.NextRun, "unknown"))
+	if job.LastRun != "" {
+		fmt.Println("Last run:", job.LastRun)
+	}
+	if job.LastResult != "" {
+		fmt.Println("Last result:", job.LastResult)
+	}
+}
+
+// printDoctorResults runs the doctor and prints its findings. Returns whether there were no problems
+func printDoctorResults(asJson bool) bool {
+	results := RunDoctor(SliceMap(discords, func(d any) *DiscordInstall { return d.(*DiscordInstall) }))
+	if asJson {
+		problems := SliceContainsFunc(results, func(r TroubleshootResult) bool { return r.Problem != "" })
+		printJson(struct {
+			SchemaVersion int                  `json:"schemaVersion"`
+			Healthy       bool                 `json:"healthy"`
+			Checks        []TroubleshootResult `json:"checks"`
+		}{JsonSchemaVersion, !problems, results}, results)
+		return !problems
+	}
+
+	problems := printTroubleshootResults(results)
+	if problems == 0 {
+		color.HiGreen("✔ " + T("No problems found"))
+	} else {
+		color.HiYellow(T("%d problem(s) found", problems))
+	}
+	return problems == 0
+}
+
+func printTroubleshootResults(results []TroubleshootResult) (problems int) {
+	for _, r := range results {
+		if r.Problem == "" {
+			color.Green("✔ %s", r.Check)
+		} else {
+			problems++
+			color.Yellow("✘ %s: %s", r.Check, r.Problem)
+		}
+	}
+	return
+}
+
+func runTroubleshooter(di *DiscordInstall, withDiscordLogs bool) error {
+	results := Troubleshoot(di)
+	if printTroubleshootResults(results) == 0 {
+		Log.Info(T("Couldn't find any problems."))
+		offerDiagnostics(di, withDiscordLogs)
+		return nil
+	}
+
+	// Fixes may go as far as reinstalling Discord, so they are only applied without asking with --yes
+	if canPrompt() {
+		if !ask(T("Apply fixes where possible"), false) {
+			return errors.New("Not applying fixes")
+		}
+	} else if !assumeDefaults {
+		return errors.New("Not applying fixes without confirmation. Pass --yes to apply them")
+	}
+
+	ApplyTroubleshootFixes(results)
+
+	Log.Info(T("Checking again..."))
+	if problems := printTroubleshootResults(Troubleshoot(di)); problems != 0 {
+		return errors.New(T("%d problems remain", problems))
+	}
+
+	Log.Info(T("All problems were fixed. Restart Discord and check if Potatocord loads now."))
+	return nil
+}
+
+func offerDiagnostics(di *DiscordInstall, withDiscordLogs bool) {
+	if !withDiscordLogs && interactive {
+		withDiscordLogs = ask(T("Include Discord's logs in a diagnostics bundle for the Potatocord developers"), false)
+	}
+
+	if !withDiscordLogs {
+		Log.Info(T("To collect Discord's logs into a diagnostics bundle, rerun with --report"))
+		return
+	}
+
+	out, err := CollectDiagnostics(di, true)
+	if err != nil {
+		Log.Error(T("Failed to collect diagnostics:"), err)
+		return
 	}
+	Log.Info(T("Diagnostics bundle written to %s - please attach it when reporting this issue", out))
 }
 
 func InstallLatestBuilds() error {
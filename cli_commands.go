@@ -0,0 +1,109 @@
+//go:build cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+type cliCommand struct {
+	name string
+	// The flag doing the same, which keeps working for scripts written before the commands existed
+	flag  string
+	usage string
+}
+
+var cliCommands = []cliCommand{
+	{"install", "install", "Install Potatocord into a Discord install, or every one with --all"},
+	{"uninstall", "uninstall", "Uninstall Potatocord from a Discord install"},
+	{"update", "update", "Update Potatocord to the latest version and re-apply it to every patched install"},
+	{"repair", "repair", "Repair Potatocord and re-apply it to a Discord install"},
+	{"status", "status", "Check whether Potatocord is up to date and still injected. Exits with 1 if not"},
+	{"check", "check", "Check whether a Potatocord update is available. Exits with 11 if so, for cron jobs and status bars"},
+	{"schedule", "schedule", "Update Potatocord automatically at an interval using the system scheduler"},
+	{"unschedule", "unschedule", "Stop updating Potatocord automatically"},
+	{"schedule-status", "schedule-status", "Show the scheduled update job and when it runs"},
+	{"list", "list", "List all detected Discord installs and their patch status"},
+	{"rollback", "rollback", "Restore the previously installed Potatocord version"},
+	{"heal", "heal", "Replace a corrupted Potatocord file with an intact copy"},
+	{"download", "download", "Download and verify the latest or another version to a file without installing it"},
+	{"doctor", "doctor", "Check the network, write access and all Discord installs for problems without changing anything"},
+	{"troubleshoot", "troubleshoot", "Find and fix common reasons for Potatocord not loading"},
+	{"uninstall-everything", "uninstall-everything", "Remove Potatocord and OpenAsar from all Discord installs"},
+	{"tui", "tui", "Manage all Discord installs from a keyboard driven menu, e.g. over SSH"},
+	{"config", "show-config", "Print the configuration in effect and where each value comes from"},
+	{"self-update", "update-self", "Update the installer itself to the latest release"},
+	{"version", "version", "Print the installer version and how it was built, e.g. for bug reports"},
+	{"completion", "completion", "Print a completion script for bash, zsh, fish or powershell"},
+}
+
+// parseArgs parses the flags and the command, which may come before, after or between them. The command sets the
+// flag doing the same, so e.g. `install --branch stable` is the same as `--install --branch stable`. Commands whose
+// flag takes a value take it as their argument, e.g. `completion bash`
+func parseArgs(args []string) error {
+	_ = flag.CommandLine.Parse(args)
+	if flag.NArg() == 0 {
+		return nil
+	}
+
+	name := flag.Arg(0)
+	i := SliceIndexFunc(cliCommands, func(c cliCommand) bool { return c.name == name })
+	if i == -1 {
+		return errors.New("Unknown command " + name + ". Run with --help to see all commands")
+	}
+	rest := flag.Args()[1:]
+	if f := flag.Lookup(cliCommands[i].flag); isBoolFlag(f) {
+		_ = flag.Set(f.Name, "true")
+	} else {
+		arg, _ := flag.UnquoteUsage(f)
+		if len(rest) == 0 || strings.HasPrefix(rest[0], "-") {
+			return errors.New("The " + name + " command needs a " + arg + " as argument")
+		}
+		_ = flag.Set(f.Name, rest[0])
+		rest = rest[1:]
+	}
+
+	_ = flag.CommandLine.Parse(rest)
+	if flag.NArg() != 0 {
+		return errors.New("Unexpected argument " + flag.Arg(0) + ". Only one command can be run at a time")
+	}
+	return nil
+}
+
+func printUsage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintln(out, "Usage:", path.Base(os.Args[0]), "[command] [flags]")
+	_, _ = fmt.Fprintln(out, "\nCommands:")
+	for _, c := range cliCommands {
+		name := c.name
+		if arg, _ := flag.UnquoteUsage(flag.Lookup(c.flag)); !isBoolFlag(flag.Lookup(c.flag)) {
+			name += " <" + arg + ">"
+		}
+		_, _ = fmt.Fprintf(out, "  %-22s %s\n", name, c.usage)
+	}
+	_, _ = fmt.Fprintln(out, "\nWithout a command, the installer asks what to do. Pass --location or --branch to pick the")
+	_, _ = fmt.Fprintln(out, "Discord install to modify without being asked")
+	_, _ = fmt.Fprintln(out, "\nDefaults like the branch or a proxy can be set in", GetConfigPath()+".")
+	_, _ = fmt.Fprintln(out, "Flags and environment variables take precedence over it")
+	_, _ = fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+	_, _ = fmt.Fprintln(out, "\nEnvironment variables:")
+	for _, env := range EnvVars {
+		_, _ = fmt.Fprintf(out, "  %-26s %s\n", env.Name, env.Description)
+	}
+	_, _ = fmt.Fprintln(out, "\nExit codes:")
+	for _, c := range exitCodeDescriptions {
+		_, _ = fmt.Fprintf(out, "  %-3d %s\n", c.code, c.description)
+	}
+}
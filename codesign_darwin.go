@@ -0,0 +1,43 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+func verifySignature(bundle string) error {
+	if out, err := exec.Command("codesign", "--verify", "--strict", bundle).CombinedOutput(); err != nil {
+		return errors.New(strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// ResignDiscord signs Discord.app ad-hoc if modifying its resources broke its signature, as Gatekeeper refuses such
+// bundles and Apple Silicon kills them on launch. Intact signatures are left alone
+func ResignDiscord(di *DiscordInstall) error {
+	err := verifySignature(di.path)
+	if err == nil {
+		Log.Debug("Signature of", di.path, "is still valid")
+		return nil
+	}
+	Log.Debug("Signature of", di.path, "is broken:", err)
+
+	// Only the bundle itself is re-signed, the frameworks inside are untouched and keep Discord's signature.
+	// Discord needs its entitlements for JIT, the microphone and the camera
+	Log.Info("Re-signing", di.path)
+	out, err := exec.Command("codesign", "--force", "--sign", "-", "--preserve-metadata=entitlements", di.path).CombinedOutput()
+	if err != nil {
+		return errors.New("Failed to re-sign " + di.path + ": " + strings.TrimSpace(string(out)))
+	}
+	if err = verifySignature(di.path); err != nil {
+		return errors.New(di.path + " is still not validly signed: " + err.Error())
+	}
+	return nil
+}
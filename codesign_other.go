@@ -0,0 +1,14 @@
+//go:build !darwin
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+// ResignDiscord is only needed on macOS, where Discord.app is code signed as a whole
+func ResignDiscord(_ *DiscordInstall) error {
+	return nil
+}
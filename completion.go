@@ -0,0 +1,256 @@
+//go:build cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	path "path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var CompletionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Flags whose value is a path, completed by the shell's own file completion
+var pathFlags = []string{"location", "install-dir", "dev-build", "register", "unregister", "targets", "download"}
+
+type completionSpec struct {
+	// The name the installer was invoked as, which the completion is registered for
+	name     string
+	commands []cliCommand
+	flags    []*flag.Flag
+	// The possible values of flags and commands taking an argument, by their name
+	values map[string][]string
+}
+
+func newCompletionSpec(discords []any) completionSpec {
+	var branches []string
+	for _, b := range DiscordBranches {
+		if SliceContainsFunc(discords, func(d any) bool { return d.(*DiscordInstall).branch == b }) {
+			branches = append(branches, b)
+		}
+	}
+	if len(branches) == 0 {
+		branches = DiscordBranches
+	}
+
+	spec := completionSpec{
+		name:     strings.TrimSuffix(path.Base(os.Args[0]), ".exe"),
+		commands: cliCommands,
+		values: map[string][]string{
+			"branch":                append([]string{"auto"}, branches...),
+			"completion":            CompletionShells,
+			"scope":                 {string(ScopeUser), string(ScopeSystem)},
+			"progress":              {string(ProgressNone), string(ProgressJson), string(ProgressAnsi), string(ProgressBar)},
+			"repatch-after-updates": {string(RepatchAsk), string(RepatchAlways), string(RepatchNever)},
+			"schedule":              {"hourly", "daily", "weekly"},
+		},
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		spec.flags = append(spec.flags, f)
+	})
+	return spec
+}
+
+// GenerateCompletion returns a completion script for the given shell covering all commands, flags and the branches
+// of the detected Discord installs
+func GenerateCompletion(shell string, discords []any) (string, error) {
+	spec := newCompletionSpec(discords)
+	var sb strings.Builder
+	switch shell {
+	case "bash":
+		spec.writeBash(&sb)
+	case "zsh":
+		spec.writeZsh(&sb)
+	case "fish":
+		spec.writeFish(&sb)
+	case "powershell":
+		spec.writePowershell(&sb)
+	default:
+		return "", errors.New("Unsupported shell " + shell + ". Supported are " + strings.Join(CompletionShells, ", "))
+	}
+	return sb.String(), nil
+}
+
+// funcName is the name of the completion function, which has to be a valid identifier
+func (s completionSpec) funcName() string {
+	return "_" + regexp.MustCompile(`\W`).ReplaceAllString(s.name, "_")
+}
+
+func (s completionSpec) sortedValueKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for k := range s.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s completionSpec) isCommand(name string) bool {
+	return SliceContainsFunc(s.commands, func(c cliCommand) bool { return c.name == name })
+}
+
+// patterns returns the words after which the values of name are completed. Go's flag package accepts - and --
+func (s completionSpec) patterns(name string) []string {
+	p := []string{"--" + name, "-" + name}
+	if s.isCommand(name) {
+		p = append(p, name)
+	}
+	return p
+}
+
+func (s completionSpec) commandNames() []string {
+	return SliceMap(s.commands, func(c cliCommand) string { return c.name })
+}
+
+func (s completionSpec) flagNames() []string {
+	return SliceMap(s.flags, func(f *flag.Flag) string { return "--" + f.Name })
+}
+
+func (s completionSpec) allPathPatterns() []string {
+	var p []string
+	for _, f := range pathFlags {
+		p = append(p, s.patterns(f)...)
+	}
+	return p
+}
+
+func (s completionSpec) writeBash(sb *strings.Builder) {
+	fn := s.funcName()
+	_, _ = fmt.Fprintf(sb, "# bash completion for %s\n", s.name)
+	_, _ = fmt.Fprintf(sb, "# Add to ~/.bashrc: source <(%s completion bash)\n", s.name)
+	_, _ = fmt.Fprintf(sb, "%s() {\n", fn)
+	sb.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	sb.WriteString("\tcase \"$prev\" in\n")
+	for _, k := range s.sortedValueKeys() {
+		_, _ = fmt.Fprintf(sb, "\t%s)\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\t\t;;\n",
+			strings.Join(s.patterns(k), "|"), strings.Join(s.values[k], " "))
+	}
+	_, _ = fmt.Fprintf(sb, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn\n\t\t;;\n", strings.Join(s.allPathPatterns(), "|"))
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tif [[ \"$cur\" == -* ]]; then\n")
+	_, _ = fmt.Fprintf(sb, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(s.flagNames(), " "))
+	sb.WriteString("\telse\n")
+	_, _ = fmt.Fprintf(sb, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(s.commandNames(), " "))
+	sb.WriteString("\tfi\n}\n")
+	_, _ = fmt.Fprintf(sb, "complete -F %s %s\n", fn, s.name)
+}
+
+// zshDescribeItem formats a name and its description for _describe, which splits them at the first unescaped colon
+func zshDescribeItem(name, description string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(name, ":", "\\:")+":"+description, "'", `'\''`) + "'"
+}
+
+func (s completionSpec) writeZsh(sb *strings.Builder) {
+	fn := s.funcName()
+	_, _ = fmt.Fprintf(sb, "#compdef %s\n", s.name)
+	_, _ = fmt.Fprintf(sb, "# Add to ~/.zshrc: source <(%s completion zsh)\n", s.name)
+	_, _ = fmt.Fprintf(sb, "%s() {\n", fn)
+	sb.WriteString("\tlocal -a commands flags\n")
+	sb.WriteString("\tcase \"${words[CURRENT-1]}\" in\n")
+	for _, k := range s.sortedValueKeys() {
+		_, _ = fmt.Fprintf(sb, "\t%s)\n\t\tcompadd -- %s\n\t\treturn\n\t\t;;\n", strings.Join(s.patterns(k), "|"), strings.Join(s.values[k], " "))
+	}
+	_, _ = fmt.Fprintf(sb, "\t%s)\n\t\t_files\n\t\treturn\n\t\t;;\n", strings.Join(s.allPathPatterns(), "|"))
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tcommands=(\n")
+	for _, c := range s.commands {
+		_, _ = fmt.Fprintf(sb, "\t\t%s\n", zshDescribeItem(c.name, c.usage))
+	}
+	sb.WriteString("\t)\n\tflags=(\n")
+	for _, f := range s.flags {
+		usage, _ := flag.UnquoteUsage(f)
+		_, _ = fmt.Fprintf(sb, "\t\t%s\n", zshDescribeItem("--"+f.Name, usage))
+	}
+	sb.WriteString("\t)\n")
+	sb.WriteString("\tif [[ \"$PREFIX\" == -* ]]; then\n\t\t_describe flag flags\n\telse\n\t\t_describe command commands\n\tfi\n}\n")
+	_, _ = fmt.Fprintf(sb, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n\t%s \"$@\"\nelse\n\tcompdef %s %s\nfi\n", fn, fn, fn, s.name)
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func (s completionSpec) writeFish(sb *strings.Builder) {
+	_, _ = fmt.Fprintf(sb, "# fish completion for %s\n", s.name)
+	_, _ = fmt.Fprintf(sb, "# Save to ~/.config/fish/completions/%s.fish\n", s.name)
+	_, _ = fmt.Fprintf(sb, "complete -c %s -f\n", s.name)
+	subcommands := strings.Join(s.commandNames(), " ")
+	for _, c := range s.commands {
+		_, _ = fmt.Fprintf(sb, "complete -c %s -n 'not __fish_seen_subcommand_from %s' -a %s -d %s\n", s.name, subcommands, c.name, fishQuote(c.usage))
+		if values, ok := s.values[c.name]; ok {
+			_, _ = fmt.Fprintf(sb, "complete -c %s -n '__fish_seen_subcommand_from %s' -a %s\n", s.name, c.name, fishQuote(strings.Join(values, " ")))
+		}
+	}
+	for _, f := range s.flags {
+		usage, _ := flag.UnquoteUsage(f)
+		line := fmt.Sprintf("complete -c %s -l %s -d %s", s.name, f.Name, fishQuote(usage))
+		if values, ok := s.values[f.Name]; ok {
+			line += " -x -a " + fishQuote(strings.Join(values, " "))
+		} else if SliceContains(pathFlags, f.Name) {
+			line += " -r -F"
+		} else if !isBoolFlag(f) {
+			line += " -x"
+		}
+		sb.WriteString(line + "\n")
+	}
+}
+
+func powershellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func (s completionSpec) writePowershell(sb *strings.Builder) {
+	writeList := func(items []string) {
+		sb.WriteString(strings.Join(SliceMap(items, powershellQuote), ", "))
+	}
+	_, _ = fmt.Fprintf(sb, "# PowerShell completion for %s\n", s.name)
+	_, _ = fmt.Fprintf(sb, "# Add to your $PROFILE: %s completion powershell | Out-String | Invoke-Expression\n", s.name)
+	_, _ = fmt.Fprintf(sb, "Register-ArgumentCompleter -Native -CommandName %s, %s -ScriptBlock {\n", powershellQuote(s.name), powershellQuote(s.name+".exe"))
+	sb.WriteString("\tparam($wordToComplete, $commandAst, $cursorPosition)\n")
+	sb.WriteString("\t$before = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n")
+	sb.WriteString("\t$prev = $before[-1]\n")
+	sb.WriteString("\tif ($prev -in @(")
+	writeList(s.allPathPatterns())
+	sb.WriteString(")) {\n\t\t# Falls back to path completion\n\t\treturn\n\t}\n")
+	sb.WriteString("\t$values = switch ($prev) {\n")
+	for _, k := range s.sortedValueKeys() {
+		sb.WriteString("\t\t{ $_ -in @(")
+		writeList(s.patterns(k))
+		sb.WriteString(") } { @(")
+		writeList(s.values[k])
+		sb.WriteString(") }\n")
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif ($values) {\n")
+	sb.WriteString("\t\t$values | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	sb.WriteString("\t\t\t[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)\n\t\t}\n\t\treturn\n\t}\n")
+	sb.WriteString("\t$completions = if ($wordToComplete -like '-*') {\n\t\t[ordered]@{\n")
+	for _, f := range s.flags {
+		usage, _ := flag.UnquoteUsage(f)
+		_, _ = fmt.Fprintf(sb, "\t\t\t%s = %s\n", powershellQuote("--"+f.Name), powershellQuote(usage))
+	}
+	sb.WriteString("\t\t}\n\t} else {\n\t\t[ordered]@{\n")
+	for _, c := range s.commands {
+		_, _ = fmt.Fprintf(sb, "\t\t\t%s = %s\n", powershellQuote(c.name), powershellQuote(c.usage))
+	}
+	sb.WriteString("\t\t}\n\t}\n")
+	sb.WriteString("\t$completions.GetEnumerator() | Where-Object { $_.Key -like \"$wordToComplete*\" } | ForEach-Object {\n")
+	sb.WriteString("\t\t$type = if ($_.Key -like '-*') { 'ParameterName' } else { 'Command' }\n")
+	sb.WriteString("\t\t[System.Management.Automation.CompletionResult]::new($_.Key, $_.Key, $type, $_.Value)\n\t}\n}\n")
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,207 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"strconv"
+	"strings"
+)
+
+// InstallerConfig are defaults the user writes by hand, unlike InstallerSettings which the installer writes itself.
+// For each of them, flags win over environment variables, which win over what the installer remembered in its
+// settings, which wins over this file
+type InstallerConfig struct {
+	// The Discord branch to modify when none is passed, and the install the gui selects at first
+	Branch string `json:"branch,omitempty"`
+	// Where to fetch Potatocord from, in the same format as POTATOCORD_UPDATE_SOURCE. Requires advanced mode
+	UpdateSource string `json:"updateSource,omitempty"`
+	// The proxy to use, e.g. http://proxy.example.com:8080, instead of the one from the system settings
+	Proxy string `json:"proxy,omitempty"`
+	// The default directory to install Potatocord to
+	InstallDir string `json:"installDir,omitempty"`
+	// Discord installs outside the known locations to always include, like ones registered with --register
+	Discords []string `json:"discords,omitempty"`
+	// What to do when a Discord update removed Potatocord: ask, always or never
+	RepatchAfterUpdates RepatchMode `json:"repatchAfterUpdates,omitempty"`
+	// How many previously installed Potatocord versions to keep for downgrading
+	KeepVersions int `json:"keepVersions,omitempty"`
+	// How much to log: debug, info, warn or error
+	LogLevel string `json:"logLevel,omitempty"`
+	// A file to also write the complete log to, or default for the installer's log folder
+	LogFile string `json:"logFile,omitempty"`
+	// Commands to run before and after installing or updating
+	Hooks InstallHooks `json:"hooks,omitempty"`
+	// The language, e.g. de or pt_BR, instead of the system's. Used for the cli's messages and to suggest mirrors
+	// meant for the user's region
+	Language string `json:"language,omitempty"`
+}
+
+var Config InstallerConfig
+
+// GetConfigPath returns the config file, which can be changed with POTATOCORD_CONFIG
+func GetConfigPath() string {
+	if p := EnvConfig.Get(); p != "" {
+		return p
+	}
+	return path.Join(BaseDir, "installer-config.json")
+}
+
+// ReadConfig reads the config file. Invalid values are logged and ignored, so a typo doesn't break the installer
+func ReadConfig() InstallerConfig {
+	var config InstallerConfig
+	// The other user can write to their config file, and its hooks run as administrator
+	if TargetUser != "" && !EnvConfig.IsSet() {
+		Log.Info("Not reading the config file of", TargetUser+". Set", EnvConfig.Name, "to use one")
+		return config
+	}
+	b, err := os.ReadFile(GetConfigPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return config
+	}
+	if err != nil {
+		Log.Warn("Failed to read config file:", err)
+		return config
+	}
+	if err = json.Unmarshal(b, &config); err != nil {
+		Log.Warn("Failed to parse config file", GetConfigPath()+":", err)
+		return InstallerConfig{}
+	}
+	Log.Debug("Using config file", GetConfigPath())
+
+	if config.Branch != "" && config.Branch != "auto" && !SliceContains(DiscordBranches, config.Branch) {
+		Log.Warn("Ignoring unknown branch", config.Branch, "in the config file")
+		config.Branch = ""
+	}
+	if config.RepatchAfterUpdates != "" {
+		if _, err = ParseRepatchMode(string(config.RepatchAfterUpdates)); err != nil {
+			Log.Warn("Ignoring repatchAfterUpdates in the config file:", err)
+			config.RepatchAfterUpdates = ""
+		}
+	}
+	if _, ok := ParseLogLevel(config.LogLevel); config.LogLevel != "" && !ok {
+		Log.Warn("Ignoring unknown logLevel", config.LogLevel, "in the config file")
+		config.LogLevel = ""
+	}
+	if config.KeepVersions < 0 {
+		Log.Warn("Ignoring negative keepVersions in the config file")
+		config.KeepVersions = 0
+	}
+	for i, p := range config.Discords {
+		if abs, err := path.Abs(p); err == nil {
+			config.Discords[i] = abs
+		}
+	}
+	return config
+}
+
+// GetDefaultBranch returns the Discord branch to modify when none is passed, or "" to ask
+func GetDefaultBranch() string {
+	return Ternary(EnvBranch.IsSet(), EnvBranch.Get(), Config.Branch)
+}
+
+// ConfigValue is a setting in effect and where it comes from: an environment variable, the settings the installer
+// remembered, the config file or the default
+type ConfigValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func configSource(env EnvVar, inSettings, inConfig bool) string {
+	if _, name := env.Lookup(); name != "" {
+		return name
+	}
+	switch {
+	case inSettings:
+		return "settings"
+	case inConfig:
+		return "config file"
+	default:
+		return "default"
+	}
+}
+
+// effectiveProxy returns the proxy SystemProxy uses and where it comes from, in the same order
+func effectiveProxy() (proxy, source string) {
+	if proxy = EnvProxy.Get(); proxy != "" {
+		return proxy, EnvProxy.Name
+	}
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if proxy = os.Getenv(env); proxy != "" {
+			return proxy, env
+		}
+	}
+	if Config.Proxy != "" {
+		return Config.Proxy, "config file"
+	}
+	if cfg, err := GetSystemProxyConfig(); err == nil && cfg != nil {
+		return Ternary(cfg.PacUrl != "", cfg.PacUrl, Ternary(cfg.Https != "", cfg.Https, cfg.Http)), "system settings"
+	}
+	return "none", "default"
+}
+
+// EffectiveConfig returns the settings in effect after applying environment variables, the remembered settings
+// and the config file
+func EffectiveConfig() []ConfigValue {
+	installDirSource := configSource(EnvInstallDir, Settings.InstallDir != "", Config.InstallDir != "")
+	if _, name := EnvDirectory.Lookup(); name != "" {
+		installDirSource = name
+	} else if EnvDevBuild.IsSet() {
+		installDirSource = EnvDevBuild.Name
+	}
+
+	updateSource, updateSourceOrigin := getCustomUpdateSource()
+	switch {
+	case updateSource == "":
+		updateSource, updateSourceOrigin = "GitHub", "default"
+	case updateSourceOrigin == "the updateSource from the config file":
+		updateSourceOrigin = "config file"
+	}
+	if updateSourceOrigin != "default" && !IsAdvancedMode() {
+		updateSourceOrigin += ", ignored without advanced mode"
+	}
+
+	proxy, proxySource := effectiveProxy()
+
+	mirrors := append(append(append([]string(nil), AssetMirrors...), EnvMirror.GetList()...), Settings.AcceptedMirrors...)
+	mirrorsSource := configSource(EnvMirror, len(Settings.AcceptedMirrors) != 0, false)
+
+	discords := append(append([]string(nil), Settings.CustomDiscords...), Config.Discords...)
+	branch := GetDefaultBranch()
+	language := GetLanguage()
+	logLevelSource := configSource(EnvLogLevel, false, Config.LogLevel != "")
+	for _, f := range []string{"debug", "verbose", "quiet"} {
+		if SliceContains(os.Args, "--"+f) || SliceContains(os.Args, "-"+f) {
+			logLevelSource = "--" + f
+			break
+		}
+	}
+
+	return []ConfigValue{
+		{"configFile", GetConfigPath(), configSource(EnvConfig, false, false)},
+		{"dataDir", BaseDir, configSource(Ternary(EnvUserDataDir.IsSet(), EnvUserDataDir, EnvDiscordUserDataDir), false, false)},
+		{"installDir", GetInstallDir(), installDirSource},
+		{"branch", Ternary(branch != "", branch, "ask"), configSource(EnvBranch, false, Config.Branch != "")},
+		{"updateSource", updateSource, updateSourceOrigin},
+		{"mirrors", strings.Join(mirrors, ", "), mirrorsSource},
+		{"proxy", proxy, proxySource},
+		{"discords", Ternary(len(discords) != 0, strings.Join(discords, ", "), "none"),
+			configSource(EnvVar{}, len(Settings.CustomDiscords) != 0, len(Config.Discords) != 0)},
+		{"repatchAfterUpdates", string(GetRepatchMode()), configSource(EnvVar{}, Settings.RepatchAfterUpdate != "", Config.RepatchAfterUpdates != "")},
+		{"keepVersions", strconv.Itoa(GetKeptVersions()), configSource(EnvVar{}, Settings.KeptVersions > 0, Config.KeepVersions > 0)},
+		{"language", Ternary(language != "", language, "unknown"), Ternary(Settings.Language != "" || Config.Language != "", configSource(EnvVar{}, Settings.Language != "", Config.Language != ""), "system settings")},
+		{"logLevel", strings.ToLower(levelNames[LogLevel]), logLevelSource},
+		{"logFile", Ternary(GetLogFile() != "", GetLogFile(), "none"), Ternary(logFileSource != "", logFileSource, "default")},
+		{"hooks", strconv.Itoa(len(Config.Hooks.PreInstall)) + " pre-install, " + strconv.Itoa(len(Config.Hooks.PostInstall)) + " post-install",
+			configSource(EnvVar{}, false, len(Config.Hooks.PreInstall)+len(Config.Hooks.PostInstall) != 0)},
+		{"advancedMode", strconv.FormatBool(IsAdvancedMode()), configSource(EnvAdvanced, Settings.AdvancedMode, false)},
+	}
+}
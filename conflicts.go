@@ -0,0 +1,129 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+// Suffix of the files and folders conflicting mods are moved to, so they can be restored by hand
+const disabledModSuffix = ".potatocord-disabled"
+
+// Injected app.asars are tiny, anything bigger is Discord's own
+const maxInjectedAsarSize = 64 * 1024
+
+// Markers of known client mods in the files they inject into, lowercase
+var knownModMarkers = []struct {
+	Name    string
+	Markers []string
+}{
+	{"BetterDiscord", []string{"betterdiscord"}},
+	{"Vencord", []string{"vencord"}},
+	{"Powercord", []string{"powercord"}},
+	{"Replugged", []string{"replugged"}},
+}
+
+type ConflictingMod struct {
+	// Empty if the injection couldn't be attributed to a known mod
+	Name string
+	// The file or folder the mod injected itself with
+	File string
+	// Only known mods are disabled automatically, unknown modifications could be anything
+	disable func() error
+}
+
+func (m ConflictingMod) DisplayName() string {
+	return Ternary(m.Name != "", m.Name, "an unknown mod")
+}
+
+// Disable removes the mod's injection. The injected files are kept with disabledModSuffix or, for app.asar
+// injections, replaced by the original app.asar the other installer kept
+func (m ConflictingMod) Disable() error {
+	if m.disable == nil {
+		return errors.New(m.File + " was modified by an unknown mod. Uninstall it or reinstall Discord")
+	}
+	Log.Info("Disabling", m.DisplayName(), "at", m.File)
+	return m.disable()
+}
+
+func identifyMod(b []byte) string {
+	content := strings.ToLower(string(b))
+	for _, mod := range knownModMarkers {
+		for _, marker := range mod.Markers {
+			if strings.Contains(content, marker) {
+				return mod.Name
+			}
+		}
+	}
+	return ""
+}
+
+// FindConflictingMods looks for injections of other client mods, which break Discord when combined with Potatocord
+func FindConflictingMods(di *DiscordInstall) []ConflictingMod {
+	var mods []ConflictingMod
+	resources := di.resourcesDir()
+
+	// Electron prefers resources/app over app.asar, so Discord won't load our app.asar at all.
+	// Powercord, Replugged and old Vencord versions inject this way
+	if appDir := path.Join(resources, "app"); !di.isSystemElectron && ExistsFile(appDir) {
+		index, _ := os.ReadFile(path.Join(appDir, "index.js"))
+		mods = append(mods, ConflictingMod{identifyMod(index), appDir, func() error {
+			return os.Rename(appDir, appDir+disabledModSuffix)
+		}})
+	}
+
+	// Other installers patch app.asar the same way we do
+	appAsar := path.Join(resources, "app.asar")
+	if stat, err := os.Stat(appAsar); err == nil && di.isPatched && stat.Size() < maxInjectedAsarSize {
+		b, _ := os.ReadFile(appAsar)
+		patcherPath, _ := json.Marshal(PotatocordDirectory)
+		if !bytes.Contains(b, patcherPath) {
+			mods = append(mods, ConflictingMod{identifyMod(b), appAsar, di.unpatch})
+		}
+	}
+
+	// BetterDiscord and others modify discord_desktop_core in the user data
+	matches, _ := path.Glob(path.Join(GetDiscordDataDir(di), "*", "modules", "discord_desktop_core", "index.js"))
+	for _, index := range matches {
+		b, err := os.ReadFile(index)
+		if err != nil || strings.TrimSpace(string(b)) == stockDesktopCoreIndex {
+			continue
+		}
+
+		mod := ConflictingMod{Name: identifyMod(b), File: index}
+		if mod.Name != "" || IsAdvancedMode() {
+			mod.disable = func() error {
+				if err := copyFile(index, index+disabledModSuffix); err != nil {
+					return err
+				}
+				return os.WriteFile(index, []byte(stockDesktopCoreIndex), 0644)
+			}
+		}
+		mods = append(mods, mod)
+	}
+
+	for _, mod := range mods {
+		Log.Debug("Found", mod.DisplayName(), "at", mod.File)
+	}
+	return mods
+}
+
+// DisableConflictingMods disables all mods that can be disabled automatically and returns the errors of all others
+func DisableConflictingMods(mods []ConflictingMod) error {
+	var errs []error
+	for _, mod := range mods {
+		if err := mod.Disable(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -17,6 +17,13 @@ const InstallerReleaseUrl = "https://api.github.com/repos/potatocord/Installer/r
 const InstallerReleaseUrlFallback = InstallerReleaseUrl
 const BuildsApiUrl = "https://api.github.com/repos/potatocord/builds/commits/main"
 const BuildsRawUrl = "https://raw.githubusercontent.com/potatocord/builds/main"
+const DiscordDownloadUrl = "https://discord.com/download"
+
+// Mirrors of the release assets, tried in order if downloading from GitHub fails.
+// Each mirror must serve assets as <mirror>/<asset name>
+var AssetMirrors = []string{
+	BuildsRawUrl,
+}
 
 var UserAgent = "PotatocordInstaller/" + buildinfo.InstallerGitHash + " (https://github.com/potatocord/Installer)"
 
@@ -0,0 +1,87 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+)
+
+// The file of a dev build that Discord loads
+const devBuildEntry = "patcher.js"
+
+// DevBuildDir is the build directory of a local Potatocord checkout, e.g. ~/potatocord/dist. If set, Discord is
+// patched to load it via a link in BaseDir instead of a downloaded build, so rebuilding is enough to update it
+var DevBuildDir string
+
+func getDevLinkPath() string {
+	return path.Join(BaseDir, "dev")
+}
+
+// UseDevBuild makes the installer inject the dev build in dir instead of downloading Potatocord
+func UseDevBuild(dir string) error {
+	abs, err := path.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if !ExistsFile(path.Join(abs, devBuildEntry)) {
+		return errors.New(abs + " doesn't contain " + devBuildEntry + ". Build Potatocord first")
+	}
+
+	Log.Debug("Using dev build at", abs)
+	DevBuildDir = abs
+	PotatocordDirectory = path.Join(getDevLinkPath(), devBuildEntry)
+	IsDevInstall = true
+	// Read again by InitGithubDownloader
+	_ = os.Setenv(EnvDevInstall.Name, "1")
+	return nil
+}
+
+// LinkDevBuild points the dev link at DevBuildDir. Installs are patched against the link rather than the build
+// directory itself, so switching checkouts only needs relinking instead of repatching every install
+func LinkDevBuild() error {
+	link := getDevLinkPath()
+	if target, err := os.Readlink(link); err == nil && path.Clean(target) == DevBuildDir {
+		return nil
+	}
+
+	if stat, err := os.Lstat(link); err == nil {
+		// Never delete actual files, only a previous link. Junctions aren't reported as symlinks
+		if stat.Mode()&(os.ModeSymlink|os.ModeIrregular) == 0 {
+			return errors.New(link + " already exists and is not a link. Delete it first")
+		}
+		if err = os.Remove(link); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(BaseDir, 0755); err != nil {
+		return err
+	}
+
+	Log.Info("Linking", link, "to", DevBuildDir)
+	if err := linkDir(DevBuildDir, link); err != nil {
+		return errors.New("Failed to link " + link + " to " + DevBuildDir + ": " + err.Error())
+	}
+	recordWritten(link)
+	return nil
+}
+
+// UnlinkDevBuild removes the dev link, leaving the build directory alone
+func UnlinkDevBuild() error {
+	link := getDevLinkPath()
+	if stat, err := os.Lstat(link); err != nil || stat.Mode()&(os.ModeSymlink|os.ModeIrregular) == 0 {
+		return nil
+	}
+	Log.Debug("Removing dev link", link)
+	if err := os.Remove(link); err != nil {
+		return err
+	}
+	recordRemoved(link)
+	return nil
+}
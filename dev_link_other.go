@@ -0,0 +1,15 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "os"
+
+func linkDir(target, link string) error {
+	return os.Symlink(target, link)
+}
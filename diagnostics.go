@@ -0,0 +1,163 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	path "path/filepath"
+	"potatocordinstaller/buildinfo"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Only the tail of each log is collected, Discord's logs can grow huge
+const maxBundledLogBytes = 512 * 1024
+
+var discordDataDirNames = map[string]string{
+	"stable":      "discord",
+	"ptb":         "discordptb",
+	"canary":      "discordcanary",
+	"development": "discorddevelopment",
+}
+
+// CollectDiagnostics writes a zip bundle containing information about the installer and the given install.
+// Discord's own logs are only included if withDiscordLogs is set, as they may contain personal information,
+// so callers must ask the user for consent first
+func CollectDiagnostics(di *DiscordInstall, withDiscordLogs bool) (string, error) {
+	if err := os.MkdirAll(BaseDir, 0755); err != nil {
+		return "", fmt.Errorf("Failed to create %s: %w", BaseDir, err)
+	}
+
+	out := path.Join(BaseDir, "diagnostics-"+time.Now().Format("20060102-150405")+".zip")
+	Log.Info("Writing diagnostics bundle to", out)
+
+	f, err := os.Create(out)
+	if err != nil {
+		return "", fmt.Errorf("Failed to create %s: %w", out, err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+
+	w, err := zw.Create("info.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.WriteString(w, diagnosticsInfo(di)); err != nil {
+		return "", err
+	}
+
+	// Everything the installer did, which is the first thing to look at when an install is broken
+	if b, err := os.ReadFile(getManifestPath()); err == nil {
+		if w, err = zw.Create("manifest.json"); err != nil {
+			return "", err
+		}
+		if _, err = w.Write(b); err != nil {
+			return "", err
+		}
+	}
+
+	// Our own log contains no personal information besides paths
+	if file := GetLogFile(); file != "" {
+		if err = addLogToZip(zw, file, "installer-logs"); err != nil {
+			Log.Warn("Failed to add", file, "to diagnostics bundle:", err)
+		}
+	}
+
+	if withDiscordLogs && di != nil {
+		for _, logFile := range FindDiscordLogs(di) {
+			if err = addLogToZip(zw, logFile, "discord-logs"); err != nil {
+				Log.Warn("Failed to add", logFile, "to diagnostics bundle:", err)
+			}
+		}
+	}
+
+	if err = zw.Close(); err != nil {
+		return "", fmt.Errorf("Failed to write diagnostics bundle: %w", err)
+	}
+
+	_ = FixOwnership(out)
+	return out, nil
+}
+
+func diagnosticsInfo(di *DiscordInstall) string {
+	var sb strings.Builder
+	line := func(key string, value any) {
+		_, _ = fmt.Fprintf(&sb, "%s: %v\n", key, value)
+	}
+
+	line("Installer Version", buildinfo.InstallerTag+" ("+buildinfo.InstallerGitHash+")")
+	line("Installer Type", buildinfo.UiType)
+	line("Installer Build", buildinfo.BuildDate+", "+runtime.Version())
+	line("OS", runtime.GOOS+"/"+runtime.GOARCH)
+	line("Potatocord File", PotatocordDirectory)
+	line("Installed Hash", InstalledHash)
+	line("Latest Hash", LatestHash)
+	line("Dev Install", IsDevInstall)
+
+	if di != nil {
+		sb.WriteString("\n")
+		line("Discord Path", di.path)
+		line("Discord Branch", di.branch)
+		line("Discord App Path", di.appPath)
+		line("Patched", di.isPatched)
+		line("Flatpak", di.isFlatpak)
+		line("System Electron", di.isSystemElectron)
+		line("OpenAsar", di.IsOpenAsar())
+		if injection, ok := ReadManifest().Injections[di.resourcesDir()]; ok {
+			line("Injected", injection.Time.Format(time.DateTime)+", loading "+injection.Loads+" ("+injection.Hash+", "+string(injection.Scope)+" scope)")
+		} else {
+			line("Injected", "not recorded in the install manifest")
+		}
+	}
+
+	return sb.String()
+}
+
+// FindDiscordLogs returns the log files Discord wrote for the given install
+func FindDiscordLogs(di *DiscordInstall) []string {
+	var logs []string
+
+	logDir := path.Join(GetDiscordDataDir(di), "logs")
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		Log.Debug("Failed to read Discord log directory", logDir+":", err)
+		return logs
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, path.Join(logDir, entry.Name()))
+		}
+	}
+	return logs
+}
+
+func addLogToZip(zw *zip.Writer, file, dir string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if stat, err := f.Stat(); err == nil && stat.Size() > maxBundledLogBytes {
+		if _, err = f.Seek(-maxBundledLogBytes, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	w, err := zw.Create(dir + "/" + path.Base(file))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,55 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	path "path/filepath"
+)
+
+// FindDiscords returns every Discord install on this machine: all installs in the known locations of this platform,
+// followed by the ones the user registered and installs that are only known because Discord is currently running
+// from them
+func FindDiscords() []any {
+	var discords []any
+	seen := make(map[string]bool)
+	// Some known locations are symlinks to each other, e.g. /usr/lib64 on some distros
+	isNew := func(p string) bool {
+		if resolved, err := path.EvalSymlinks(p); err == nil {
+			p = resolved
+		}
+		p = path.Clean(p)
+		if seen[p] {
+			return false
+		}
+		seen[p] = true
+		return true
+	}
+
+	for _, d := range findKnownDiscords() {
+		if isNew(d.(*DiscordInstall).path) {
+			discords = append(discords, d)
+		}
+	}
+
+	for _, d := range findRegisteredDiscords() {
+		if isNew(d.(*DiscordInstall).path) {
+			discords = append(discords, d)
+		}
+	}
+
+	for _, p := range findRunningDiscordPaths() {
+		if !ExistsFile(p) || !isNew(p) {
+			continue
+		}
+		if discord := ParseDiscord(p, ""); discord != nil {
+			Log.Debug("Found running Discord install at", p)
+			discords = append(discords, discord)
+		}
+	}
+
+	return discords
+}
@@ -0,0 +1,16 @@
+package main
+
+import "golang.org/x/sys/windows"
+
+func GetFreeDiskSpace(dir string) (uint64, error) {
+	dirPtr, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var free uint64
+	if err = windows.GetDiskFreeSpaceEx(dirPtr, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
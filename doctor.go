@@ -0,0 +1,150 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	path "path/filepath"
+	"time"
+)
+
+const doctorNetworkTimeout = 10 * time.Second
+
+// RunDoctor checks everything installing depends on, for the whole machine instead of one install like Troubleshoot:
+// the network, write access, the Potatocord file and every Discord install. Nothing is changed, each problem says
+// what to do about it
+func RunDoctor(installs []*DiscordInstall) []TroubleshootResult {
+	defer WithLogContext("doctor")()
+
+	results := checkNetwork()
+	results = append(results, checkPotatocordIntegrity(), checkWriteAccess("Potatocord folder", GetInstallDir()))
+
+	if len(installs) == 0 {
+		results = append(results, TroubleshootResult{
+			Check:   "Discord installs",
+			Problem: "No Discord install was found. Install Discord from " + DiscordDownloadUrl + " or pass --location",
+		})
+	}
+	for _, di := range installs {
+		results = append(results, checkDiscordInstall(di)...)
+	}
+	return results
+}
+
+func checkNetwork() []TroubleshootResult {
+	targets := []struct{ name, url string }{
+		{"GitHub API", ReleaseUrl},
+		{"GitHub downloads", githubProbeUrl},
+		{"Builds repo (fallback)", BuildsApiUrl},
+	}
+	for _, mirror := range getAssetMirrors() {
+		if mirror != BuildsRawUrl {
+			targets = append(targets, struct{ name, url string }{"Mirror " + mirror, mirror + "/"})
+		}
+	}
+
+	client := http.Client{Timeout: doctorNetworkTimeout}
+	results := make([]TroubleshootResult, len(targets))
+	done := make(chan bool)
+	for i, target := range targets {
+		go func() {
+			results[i] = checkReachable(&client, "Reach "+target.name, target.url)
+			done <- true
+		}()
+	}
+	for range targets {
+		<-done
+	}
+	return results
+}
+
+func checkReachable(client *http.Client, check, url string) TroubleshootResult {
+	r := TroubleshootResult{Check: check}
+	req, err := http.NewRequest("HEAD", url, nil)
+	if err != nil {
+		r.Problem = err.Error()
+		return r
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := client.Do(req)
+	if err == nil {
+		_ = res.Body.Close()
+		if res.StatusCode >= 400 {
+			err = &HttpStatusError{res.StatusCode, res.Status}
+		}
+	}
+	var statusErr *HttpStatusError
+	switch {
+	case err == nil:
+	case errors.As(err, &statusErr) && (statusErr.Code == 403 || statusErr.Code == 429):
+		r.Problem = url + " rate limited us. Wait an hour or set POTATOCORD_MIRROR to a mirror"
+	case errors.As(err, &statusErr):
+		r.Problem = url + " answered with " + statusErr.Status
+	default:
+		r.Problem = "Can't reach " + url + ": " + err.Error() + ". Check your internet connection, firewall and proxy (see the config command)"
+	}
+	return r
+}
+
+func checkPotatocordIntegrity() TroubleshootResult {
+	r := TroubleshootResult{Check: "Potatocord file"}
+	switch _, err := CheckPotatocordIntegrity(); {
+	case IsDevInstall:
+	case !ExistsFile(PotatocordDirectory):
+		r.Problem = PotatocordDirectory + " doesn't exist. Install Potatocord, or run the repair command if it was installed before"
+	case err != nil:
+		r.Problem = err.Error() + ". Run the heal command to fix it"
+	}
+	return r
+}
+
+// checkWriteAccess checks that dir can be written, either directly or after asking for administrator rights
+func checkWriteAccess(check, dir string) TroubleshootResult {
+	r := TroubleshootResult{Check: check + " is writable"}
+	if !IsWritable(dir) && !CanElevate() {
+		r.Problem = "Can't write to " + dir + ". " + elevationHint
+	}
+	return r
+}
+
+func checkDiscordInstall(di *DiscordInstall) []TroubleshootResult {
+	name := "Discord " + di.branch
+	if di.isSnap || di.isStore {
+		return []TroubleshootResult{{
+			Check: name,
+			Problem: Ternary(di.isSnap, ErrSnapReadOnly.Error()+". Run the install command to migrate to the Flatpak",
+				"Microsoft Store installs can't be modified. Install Discord from "+DiscordDownloadUrl+" instead"),
+		}}
+	}
+
+	results := []TroubleshootResult{checkWriteAccess(name, di.resourcesDir())}
+
+	asar := TroubleshootResult{Check: name + " app.asar"}
+	if err := ValidateDiscordAsar(path.Join(di.resourcesDir(), Ternary(di.isPatched, "_app.asar", "app.asar"))); err != nil {
+		asar.Problem = err.Error() + ". Reinstall Discord or run the troubleshoot command"
+	} else if di.isPatched {
+		if err = di.VerifyInjection(); err != nil {
+			asar.Problem = err.Error() + ". Run the repair command"
+		}
+	}
+
+	mods := TroubleshootResult{Check: name + " has no other mods"}
+	for _, mod := range FindConflictingMods(di) {
+		mods.Problem += Ternary(mods.Problem != "", ", ", "") + mod.File + " was modified by " + mod.DisplayName()
+	}
+	if mods.Problem != "" {
+		mods.Problem += ". Run the troubleshoot command to disable them"
+	}
+
+	running := TroubleshootResult{Check: name + " is closed"}
+	if di.IsRunning() {
+		running.Problem = name + " is running. Close it before installing or pass --kill-discord"
+	}
+	return append(results, asar, mods, running)
+}
@@ -0,0 +1,72 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+)
+
+// DownloadedBuild is a build saved by DownloadBuild
+type DownloadedBuild struct {
+	Hash   string `json:"hash"`
+	File   string `json:"file"`
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// GetDownloadTarget returns the file DownloadBuild saves to for the given destination
+func GetDownloadTarget(dst string) (string, error) {
+	dst, err := path.Abs(dst)
+	if err != nil {
+		return "", err
+	}
+	if IsDirectory(dst) {
+		dst = path.Join(dst, "desktop.asar")
+	}
+	return dst, nil
+}
+
+// DownloadBuild saves the given build to dst without installing it, e.g. to distribute it internally. If dst is a
+// directory, it is saved there as desktop.asar. An existing file is only replaced if replace is set. Like installing,
+// builds other than the latest one must be cached or retained. The file is only put in place once it was verified
+func DownloadBuild(hash, dst string, replace bool) (*DownloadedBuild, error) {
+	defer enterCancellable()()
+	dst, err := GetDownloadTarget(dst)
+	if err != nil {
+		return nil, err
+	}
+	errExists := errors.New(dst + " already exists")
+	if !replace && ExistsFile(dst) {
+		return nil, errExists
+	}
+	if err = EnsureDir(path.Dir(dst)); err != nil {
+		return nil, err
+	}
+
+	saved, err := saveBuild(hash, path.Dir(dst), "Copying")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(saved.File)
+	// Temp files are private, but this one is meant to be shared
+	if err = os.Chmod(saved.File, 0o644); err != nil {
+		return nil, err
+	}
+
+	// It may have been created while downloading
+	if !replace && ExistsFile(dst) {
+		return nil, errExists
+	}
+	if err = os.Rename(saved.File, dst); err != nil {
+		return nil, err
+	}
+	_ = FixOwnership(dst)
+	recordWritten(dst)
+	return &DownloadedBuild{hash, dst, saved.Size, saved.Sha256}, nil
+}
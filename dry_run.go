@@ -0,0 +1,204 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	path "path/filepath"
+	"runtime"
+	"strings"
+)
+
+// PlannedChange is a single change to the system that patching, unpatching or repairing would make
+type PlannedChange struct {
+	// download, write, copy, rename, delete, link, chown, run or close
+	Action string `json:"action"`
+	Target string `json:"target"`
+	// Where the data comes from or what is run, depending on the action
+	Source string `json:"source,omitempty"`
+}
+
+func (c PlannedChange) String() string {
+	if c.Source == "" {
+		return c.Action + " " + c.Target
+	}
+	return c.Action + " " + c.Target + " <- " + c.Source
+}
+
+// planInstallBuild plans installBuild, getting the build from where openBuild would. The installed build is backed up
+// if there is one
+func planInstallBuild(hash string, installed bool) ([]PlannedChange, error) {
+	file, downloadUrl, err := findBuild(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []PlannedChange
+	if installed {
+		changes = append(changes, PlannedChange{"copy", path.Join(BackupDir, "potatocord-<time>-"+ReadInstalledHash()+".asar"), PotatocordDirectory})
+	}
+	cached, cacheable := getCachePath(hash)
+	switch {
+	case file == "":
+		changes = append(changes, PlannedChange{"download", PotatocordDirectory, downloadUrl})
+		if cacheable {
+			changes = append(changes, PlannedChange{"copy", cached, PotatocordDirectory})
+		}
+	case file != cached:
+		// A retained version, which is cached before installing it
+		changes = append(changes, PlannedChange{"copy", cached, file}, PlannedChange{"copy", PotatocordDirectory, cached})
+	default:
+		changes = append(changes, PlannedChange{"copy", PotatocordDirectory, cached})
+	}
+	return append(changes, planFixOwnership(PotatocordDirectory)...), nil
+}
+
+func planFixOwnership(p string) []PlannedChange {
+	if runtime.GOOS != "linux" && TargetUser == "" || runtime.GOOS == "windows" || os.Geteuid() != 0 || isSystemPath(p) {
+		return nil
+	}
+	return []PlannedChange{{"chown", p, os.Getenv("SUDO_USER")}}
+}
+
+// planResign plans ResignDiscord, which only re-signs if the signature broke
+func (di *DiscordInstall) planResign() []PlannedChange {
+	if runtime.GOOS != "darwin" {
+		return nil
+	}
+	return []PlannedChange{{"run", "codesign --force --sign - " + di.path, ""}}
+}
+
+func (di *DiscordInstall) planFlatpakOverride(arg string) []PlannedChange {
+	if !di.isFlatpak {
+		return nil
+	}
+	return []PlannedChange{{"run", "flatpak " + Ternary(CurrentScope == ScopeSystem, "--system", "--user") + " override " + di.flatpakId() + " " + arg, ""}}
+}
+
+// PlanPatch returns the changes patch would make, without making any
+func (di *DiscordInstall) PlanPatch() ([]PlannedChange, error) {
+	return di.planPatch(InstalledHash)
+}
+
+func (di *DiscordInstall) planPatch(installedHash string) ([]PlannedChange, error) {
+	if di.isSnap {
+		return nil, ErrSnapReadOnly
+	}
+	if di.isStore {
+		return nil, ErrStoreReadOnly
+	}
+
+	var changes []PlannedChange
+	if needsLatestBuilds(installedHash) {
+		install, err := planInstallBuild(LatestHash, ExistsFile(PotatocordDirectory))
+		if err != nil {
+			return nil, err
+		}
+		changes = install
+	}
+
+	if DevBuildDir != "" {
+		changes = append(changes, PlannedChange{"link", getDevLinkPath(), DevBuildDir})
+	}
+
+	if patchClosesDiscord && di.IsRunning() {
+		changes = append(changes, PlannedChange{"close", "Discord " + di.branch, ""})
+	}
+
+	dir := di.resourcesDir()
+	if stock := getStockAsar(di); ExistsFile(stock) {
+		if sum, err := hashFile(stock); err == nil && !ExistsFile(getStockBackupPath(sum)) {
+			changes = append(changes, PlannedChange{"copy", getStockBackupPath(sum), stock})
+		}
+		changes = append(changes, PlannedChange{"write", getBackupIndexPath(), ""})
+	}
+
+	if di.isPatched {
+		changes = append(changes, di.PlanUnpatch()...)
+	}
+
+	appAsar, _appAsar := path.Join(dir, "app.asar"), path.Join(dir, "_app.asar")
+	changes = append(changes, PlannedChange{"rename", _appAsar, appAsar})
+	if di.isSystemElectron {
+		changes = append(changes, PlannedChange{"rename", _appAsar + ".unpacked", appAsar + ".unpacked"})
+	}
+	patcherPath, _ := json.Marshal(PotatocordDirectory)
+	changes = append(changes, PlannedChange{"write", appAsar, "require(" + string(patcherPath) + ")"})
+	changes = append(changes, di.planResign()...)
+	if !IsDevInstall {
+		changes = append(changes, PlannedChange{"write", getManifestPath(), ""})
+	}
+
+	changes = append(changes, di.planFlatpakOverride("--filesystem="+PotatocordDirectory)...)
+	if DevBuildDir != "" {
+		changes = append(changes, di.planFlatpakOverride("--filesystem="+DevBuildDir)...)
+	}
+	return changes, nil
+}
+
+// PlanUnpatch returns the changes unpatch would make, without making any
+func (di *DiscordInstall) PlanUnpatch() (changes []PlannedChange) {
+	dir := di.resourcesDir()
+	appAsar, _appAsar, appAsarTmp := path.Join(dir, "app.asar"), path.Join(dir, "_app.asar"), path.Join(dir, "app.asar.tmp")
+
+	if di.isPatched && !ExistsFile(_appAsar) {
+		if backup, ok := readBackupIndex()[dir]; ok {
+			changes = append(changes, PlannedChange{"copy", _appAsar, backup.File})
+		}
+	}
+
+	changes = append(changes,
+		PlannedChange{"rename", appAsarTmp, appAsar},
+		PlannedChange{"rename", appAsar, _appAsar},
+	)
+	if di.isSystemElectron {
+		changes = append(changes, PlannedChange{"rename", appAsar + ".unpacked", _appAsar + ".unpacked"})
+	}
+	changes = append(changes, PlannedChange{"delete", appAsarTmp, ""})
+	changes = append(changes, di.planResign()...)
+	return append(changes, PlannedChange{"write", getManifestPath(), ""})
+}
+
+// PlanRepair returns the changes Repair would make, without making any
+func (di *DiscordInstall) PlanRepair() ([]PlannedChange, error) {
+	var changes []PlannedChange
+	installedHash := InstalledHash
+	if !IsDevInstall {
+		if err := VerifyPotatocordFile(); err != nil {
+			Log.Info("Would reinstall Potatocord, as", err)
+			// Don't keep a broken file around as rollback target
+			broken := ReadInstalledHash() == ""
+			if broken {
+				changes = append(changes, PlannedChange{"delete", PotatocordDirectory, ""})
+			}
+			install, err := planInstallBuild(LatestHash, !broken && ExistsFile(PotatocordDirectory))
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, install...)
+			// Reinstalled regardless of the installed hash, so patching won't install it again
+			installedHash = LatestHash
+		}
+		changes = append(changes, planFixOwnership(PotatocordDirectory)...)
+	}
+
+	patch, err := di.planPatch(installedHash)
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, patch...), nil
+}
+
+// FormatPlan formats planned changes as one change per line
+func FormatPlan(changes []PlannedChange) string {
+	if len(changes) == 0 {
+		return "Nothing to do"
+	}
+	lines := SliceMap(changes, PlannedChange.String)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const elevationHint = "Rerun me with sudo"
+
+func IsElevated() bool {
+	return os.Geteuid() == 0
+}
+
+// CanElevate reports whether single operations can be run with administrator rights without restarting as root
+func CanElevate() bool {
+	if runtime.GOOS == "darwin" {
+		return true
+	}
+	_, err := exec.LookPath("pkexec")
+	return err == nil
+}
+
+// runElevated runs exe as root after the user authenticated, via the macOS authorization dialog or polkit
+func runElevated(exe string, args ...string) error {
+	if runtime.GOOS == "darwin" {
+		cmd := strings.Join(SliceMap(Prepend(args, exe), shellQuote), " ")
+		return exec.Command("osascript", "-e", "do shell script "+appleScriptQuote(cmd)+" with administrator privileges").Run()
+	}
+	return exec.Command("pkexec", Prepend(args, exe)...).Run()
+}
+
+func getUserHome() string {
+	return os.Getenv("HOME")
+}
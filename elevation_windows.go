@@ -0,0 +1,47 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+const elevationHint = "Right click me and select Run as administrator"
+
+func IsElevated() bool {
+	return windows.GetCurrentProcessToken().IsElevated()
+}
+
+// CanElevate reports whether single operations can be run with administrator rights, which UAC always allows
+func CanElevate() bool {
+	return true
+}
+
+// runElevated runs exe as administrator after the user confirmed the UAC prompt and waits for it to exit
+func runElevated(exe string, args ...string) error {
+	quote := func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	// Arguments are passed on as a single command line, so each needs its own double quotes
+	argList := strings.Join(SliceMap(args, func(arg string) string {
+		return quote(`"` + arg + `"`)
+	}), ",")
+
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"Start-Process -Verb RunAs -Wait -WindowStyle Hidden -FilePath "+quote(exe)+" -ArgumentList "+argList)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd.Run()
+}
+
+func getUserHome() string {
+	return os.Getenv("USERPROFILE")
+}
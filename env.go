@@ -0,0 +1,105 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// EnvVar is an environment variable that configures the installer. Its legacy names from the Vencord Installer
+// are still read if it isn't set
+type EnvVar struct {
+	Name        string
+	Legacy      []string
+	Description string
+}
+
+var (
+	EnvUserDataDir = EnvVar{"POTATOCORD_USER_DATA_DIR", []string{"VENCORD_USER_DATA_DIR"},
+		"Where Potatocord's settings and the installer's data are stored"}
+	EnvDiscordUserDataDir = EnvVar{"DISCORD_USER_DATA_DIR", nil,
+		"Discord's data directory. Potatocord's data is stored next to it"}
+	EnvDirectory = EnvVar{"POTATOCORD_DIRECTORY", []string{"VENCORD_DIRECTORY"},
+		"The Potatocord file Discord loads"}
+	EnvInstallDir = EnvVar{"POTATOCORD_INSTALL_DIR", nil,
+		"The directory to install Potatocord to"}
+	EnvDevBuild = EnvVar{"POTATOCORD_DEV_BUILD", nil,
+		"Inject the Potatocord build in this directory instead of downloading it, like --dev-build"}
+	EnvDevInstall = EnvVar{"POTATOCORD_DEV_INSTALL", []string{"VENCORD_DEV_INSTALL"},
+		"Set to 1 to never download Potatocord, for developing it"}
+	EnvUpdateSource = EnvVar{"POTATOCORD_UPDATE_SOURCE", nil,
+		"Where to fetch Potatocord from: github:<url>, gitlab:<url> or manifest:<url>. Requires advanced mode"}
+	EnvReleaseRepo = EnvVar{"POTATOCORD_RELEASE_REPO", nil,
+		"The GitHub repository to fetch Potatocord releases from, e.g. someone/potatocord. Requires advanced mode"}
+	EnvMirror = EnvVar{"POTATOCORD_MIRROR", nil,
+		"Comma separated mirrors of the release assets to use besides the default ones"}
+	EnvProxy = EnvVar{"POTATOCORD_PROXY", nil,
+		"The proxy to use, taking precedence over HTTPS_PROXY, HTTP_PROXY and the system settings"}
+	EnvBranch = EnvVar{"POTATOCORD_BRANCH", nil,
+		"The Discord branch (release channel) to modify when none is passed: stable, ptb, canary or development"}
+	EnvLogLevel = EnvVar{"POTATOCORD_LOG_LEVEL", nil,
+		"How much to log: debug, info, warn or error"}
+	EnvLogFile = EnvVar{"POTATOCORD_LOG_FILE", nil,
+		"Also write the complete log to this file, rotated once it reaches 1 MiB. 'default' logs to the installer's log folder"}
+	EnvAdvanced = EnvVar{"POTATOCORD_ADVANCED", nil,
+		"Set to 1 to enable advanced mode, like --advanced"}
+	EnvConfig = EnvVar{"POTATOCORD_CONFIG", nil,
+		"The config file to read instead of installer-config.json in the data directory"}
+	EnvNotify = EnvVar{"POTATOCORD_NOTIFY", nil,
+		"Comma separated notifiers: desktop, stdout, webhook or none. Per event as POTATOCORD_NOTIFY_<EVENT>"}
+	EnvWebhookUrl = EnvVar{"POTATOCORD_WEBHOOK_URL", nil,
+		"The url the webhook notifier posts to"}
+	EnvOffline = EnvVar{"POTATOCORD_OFFLINE", nil,
+		"Set to 1 to never connect to the network and only install what is cached, like --offline"}
+	EnvUser = EnvVar{"POTATOCORD_USER", nil,
+		"The user account to install for, like --user. Requires administrator rights"}
+	EnvGuiScale = EnvVar{"POTATOCORD_GUI_SCALE", nil,
+		"The factor to scale the GUI by instead of the display's scale, e.g. 1.5"}
+)
+
+// EnvVars are all variables that configure the installer, in the order they are documented
+var EnvVars = []EnvVar{
+	EnvUserDataDir, EnvDiscordUserDataDir, EnvDirectory, EnvInstallDir, EnvDevBuild, EnvDevInstall, EnvUpdateSource,
+	EnvReleaseRepo, EnvMirror, EnvProxy, EnvBranch, EnvLogLevel, EnvLogFile, EnvAdvanced, EnvConfig, EnvNotify, EnvWebhookUrl,
+	EnvOffline, EnvUser, EnvGuiScale,
+}
+
+// Lookup returns the value of the variable and the name it was set as, which is a legacy one if only that is set
+func (e EnvVar) Lookup() (value, name string) {
+	for _, name = range Prepend(e.Legacy, e.Name) {
+		if value = os.Getenv(name); value != "" {
+			return value, name
+		}
+	}
+	return "", ""
+}
+
+func (e EnvVar) Get() string {
+	value, _ := e.Lookup()
+	return value
+}
+
+func (e EnvVar) IsSet() bool {
+	return e.Get() != ""
+}
+
+// IsEnabled reports whether the variable is set to 1
+func (e EnvVar) IsEnabled() bool {
+	return e.Get() == "1"
+}
+
+// GetList splits a comma separated value, skipping empty items
+func (e EnvVar) GetList() []string {
+	var items []string
+	for _, item := range strings.Split(e.Get(), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "errors"
+
+// Classes of errors callers may want to handle differently, e.g. the cli exits with a distinct code for each.
+// Errors are tagged with them using withClass, so their message stays as specific as it was
+var (
+	ErrInstallInProgress  = errors.New("Another install is in progress")
+	ErrElevationDenied    = errors.New("Administrator rights were denied")
+	ErrVerificationFailed = errors.New("Verification failed")
+	ErrDiscordRunning     = errors.New("Discord is running")
+	// Offline mode and what's needed isn't cached
+	ErrNotCached = errors.New("Not available offline")
+	// The gui already showed the error in a dialog, so callers shouldn't show it again
+	ErrAlreadyShown = errors.New("Already shown")
+)
+
+type classifiedError struct {
+	error
+	class error
+}
+
+func (e classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+func (e classifiedError) Unwrap() error {
+	return e.error
+}
+
+// withClass makes errors.Is(err, class) hold without changing the message of err
+func withClass(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return classifiedError{err, class}
+}
+
+// HttpStatusError is returned for requests that were answered with a status other than 2xx
+type HttpStatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *HttpStatusError) Error() string {
+	return e.Status
+}
+
+// RateLimited reports whether we were rate limited or blocked, which GitHub does with 403 rather than 429
+func (e *HttpStatusError) RateLimited() bool {
+	return e.Code == 401 || e.Code == 403 || e.Code == 429
+}
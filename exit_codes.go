@@ -0,0 +1,102 @@
+//go:build cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// The cli exits with these, so scripts can tell why something failed without parsing the log. They are part of the
+// cli's interface, so never change the meaning of an existing code
+const (
+	ExitSuccess = 0
+	// Anything not covered by a more specific code
+	ExitFailure = 1
+	// Invalid flags or commands. Also used by the flag package
+	ExitUsage = 2
+	// Potatocord or release data couldn't be downloaded
+	ExitNetwork = 3
+	// GitHub rate limited or blocked us, try again later or use a mirror
+	ExitRateLimited = 4
+	// No Discord install was found, or the given one isn't valid
+	ExitNoDiscord = 5
+	// Missing file permissions, or administrator rights were denied
+	ExitPermissionDenied = 6
+	// Something was written, but checking it afterwards failed
+	ExitVerificationFailed = 7
+	// Nothing was done as everything already is up to date. Only used by update and self-update
+	ExitUpToDate = 8
+	// Another installer is modifying Potatocord or Discord right now
+	ExitInstallInProgress = 9
+	// Discord has to be closed first, see --kill-discord
+	ExitDiscordRunning = 10
+	// A newer Potatocord is available or it isn't installed. Only used by check
+	ExitUpdateAvailable = 11
+	// Offline mode and the cache lacks the release data or build needed
+	ExitNotCached = 12
+	// Cancelled with Ctrl+C or SIGTERM. What was changed so far was undone where possible
+	ExitCancelled = 130
+)
+
+var exitCodeDescriptions = []struct {
+	code        int
+	description string
+}{
+	{ExitFailure, "failed for another reason"},
+	{ExitUsage, "invalid flags or command"},
+	{ExitNetwork, "network error"},
+	{ExitRateLimited, "rate limited by GitHub"},
+	{ExitNoDiscord, "no Discord install found"},
+	{ExitPermissionDenied, "permission denied"},
+	{ExitVerificationFailed, "verification failed"},
+	{ExitUpToDate, "already up to date (update and self-update only)"},
+	{ExitInstallInProgress, "another install is in progress"},
+	{ExitDiscordRunning, "Discord has to be closed first"},
+	{ExitUpdateAvailable, "an update is available (check only)"},
+	{ExitNotCached, "not cached, so not available offline"},
+	{ExitCancelled, "cancelled with Ctrl+C or SIGTERM"},
+}
+
+// releaseFetchExitCode returns the code to exit with because fetching the latest release failed
+func releaseFetchExitCode() int {
+	if code := exitCodeFor(GithubError); code != ExitSuccess && code != ExitFailure {
+		return code
+	}
+	return ExitNetwork
+}
+
+// exitCodeFor returns the code to exit with because of err
+func exitCodeFor(err error) int {
+	var statusErr *HttpStatusError
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ExitSuccess
+	// Before the network errors, as requests refused in offline mode are those too
+	case errors.Is(err, ErrNotCached):
+		return ExitNotCached
+	case errors.As(err, &statusErr) && statusErr.RateLimited():
+		return ExitRateLimited
+	case errors.As(err, &statusErr), errors.As(err, &netErr):
+		return ExitNetwork
+	case errors.Is(err, os.ErrPermission), errors.Is(err, ErrElevationDenied):
+		return ExitPermissionDenied
+	case errors.Is(err, ErrVerificationFailed):
+		return ExitVerificationFailed
+	case errors.Is(err, ErrInstallInProgress):
+		return ExitInstallInProgress
+	case errors.Is(err, ErrDiscordRunning):
+		return ExitDiscordRunning
+	case errors.Is(err, ErrCancelled):
+		return ExitCancelled
+	}
+	return ExitFailure
+}
@@ -0,0 +1,48 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	path "path/filepath"
+)
+
+// FileAttrs are the mode and owner to give a file, so replacing it doesn't change who can access it.
+// Uid and Gid are -1 to leave them as they are
+type FileAttrs struct {
+	Mode os.FileMode `json:"mode"`
+	Uid  int         `json:"uid"`
+	Gid  int         `json:"gid"`
+}
+
+var defaultFileAttrs = FileAttrs{0644, -1, -1}
+
+// getFileAttrs returns the mode and owner of file or, if it doesn't exist yet, the ones a new file there should get.
+// Also returns whether it exists
+func getFileAttrs(file string) (FileAttrs, bool) {
+	if stat, err := os.Stat(file); err == nil && !stat.IsDir() {
+		uid, gid := getFileOwner(stat)
+		return FileAttrs{stat.Mode().Perm(), uid, gid}, true
+	}
+
+	attrs := defaultFileAttrs
+	// Files in setgid directories belong to the directory's group, which admins use to share system-wide installs
+	if stat, err := os.Stat(path.Dir(file)); err == nil && stat.Mode()&os.ModeSetgid != 0 {
+		_, attrs.Gid = getFileOwner(stat)
+	}
+	return attrs, false
+}
+
+func (a FileAttrs) apply(file string) error {
+	if err := os.Chmod(file, a.Mode); err != nil {
+		return err
+	}
+	if a.Uid == -1 && a.Gid == -1 {
+		return nil
+	}
+	return os.Chown(file, a.Uid, a.Gid)
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+func getFileOwner(stat os.FileInfo) (uid, gid int) {
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		return int(sys.Uid), int(sys.Gid)
+	}
+	return -1, -1
+}
@@ -0,0 +1,14 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "os"
+
+// Files inherit their ACLs from the directory, so there is no owner to keep
+func getFileOwner(_ os.FileInfo) (uid, gid int) {
+	return -1, -1
+}
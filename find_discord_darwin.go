@@ -8,15 +8,24 @@ package main
 
 import (
 	"os"
+	"os/exec"
 	path "path/filepath"
+	"strconv"
 	"strings"
 )
 
 var macosNames = map[string]string{
-	"stable": "Discord.app",
-	"ptb":    "Discord PTB.app",
-	"canary": "Discord Canary.app",
-	"dev":    "Discord Development.app",
+	"stable":      "Discord.app",
+	"ptb":         "Discord PTB.app",
+	"canary":      "Discord Canary.app",
+	"development": "Discord Development.app",
+}
+
+func init() {
+	if u := lookupTargetUser(); u != nil {
+		_ = os.Setenv("HOME", u.HomeDir)
+		_ = os.Setenv("SUDO_USER", u.Username)
+	}
 }
 
 func ParseDiscord(p, branch string) *DiscordInstall {
@@ -44,14 +53,10 @@ func ParseDiscord(p, branch string) *DiscordInstall {
 	}
 }
 
-func FindDiscords() []any {
+func findKnownDiscords() []any {
 	var discords []any
-	bases := []string{
-		"/Applications",
-		path.Join(os.Getenv("HOME"), "Applications"),
-	}
 	for branch, dirname := range macosNames {
-		for _, base := range bases {
+		for _, base := range getDiscordParentDirs() {
 			p := path.Join(base, dirname)
 			if discord := ParseDiscord(p, branch); discord != nil {
 				Log.Debug("Found Discord Install at", p)
@@ -62,10 +67,80 @@ func FindDiscords() []any {
 	return discords
 }
 
+// getDiscordParentDirs returns the directories Discord installs itself into
+func getDiscordParentDirs() []string {
+	return []string{
+		"/Applications",
+		path.Join(os.Getenv("HOME"), "Applications"),
+	}
+}
+
+// findRunningDiscordPaths returns the app bundles of all running Discord processes
+func findRunningDiscordPaths() []string {
+	var paths []string
+	out, err := exec.Command("ps", "-axo", "comm=").Output()
+	if err != nil {
+		return paths
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		// /Applications/Discord.app/Contents/MacOS/Discord
+		bundle, _, found := strings.Cut(strings.TrimSpace(line), ".app/Contents/MacOS/")
+		if !found {
+			continue
+		}
+		bundle += ".app"
+		for _, name := range macosNames {
+			if path.Base(bundle) == name && !SliceContains(paths, bundle) {
+				paths = append(paths, bundle)
+			}
+		}
+	}
+	return paths
+}
+
+// GetDiscordDataDir returns Discord's user data directory, which contains its logs and settings
+func GetDiscordDataDir(di *DiscordInstall) string {
+	return path.Join(os.Getenv("HOME"), "Library/Application Support", discordDataDirNames[di.branch])
+}
+
+// Whether PreparePatch closes Discord
+const patchClosesDiscord = false
+
 func PreparePatch(di *DiscordInstall) {}
 
-func FixOwnership(_ string) error {
-	return nil
+func discordLaunchCommand(di *DiscordInstall, _ string) *exec.Cmd {
+	return exec.Command("open", "-a", di.path)
+}
+
+// findDiscordProcesses returns all processes running from the given install, including its helpers
+func findDiscordProcesses(di *DiscordInstall) []DiscordProcess {
+	var procs []DiscordProcess
+	out, err := exec.Command("ps", "-axo", "pid=,comm=").Output()
+	if err != nil {
+		return procs
+	}
+
+	prefix := path.Clean(di.path) + "/Contents/"
+	for _, line := range strings.Split(string(out), "\n") {
+		pidStr, exe, found := strings.Cut(strings.TrimSpace(line), " ")
+		if !found {
+			continue
+		}
+		exe = strings.TrimSpace(exe)
+		if pid, err := strconv.Atoi(pidStr); err == nil && strings.HasPrefix(exe, prefix) {
+			procs = append(procs, DiscordProcess{pid, exe})
+		}
+	}
+	return procs
+}
+
+// FixOwnership gives the files written for --user to that user
+func FixOwnership(p string) error {
+	if TargetUser == "" || os.Geteuid() != 0 || isSystemPath(p) {
+		return nil
+	}
+	return chownTo(p, TargetUser)
 }
 
 func CheckScuffedInstall() bool {
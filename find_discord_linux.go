@@ -8,8 +8,8 @@ package main
 
 import (
 	"errors"
-	"io/fs"
 	"os"
+	"os/exec"
 	"os/user"
 	path "path/filepath"
 	"strconv"
@@ -22,6 +22,14 @@ var (
 )
 
 func init() {
+	// --user takes the place of the user who ran sudo, whose settings in the environment don't apply then
+	if u := lookupTargetUser(); u != nil {
+		_ = os.Setenv("SUDO_USER", u.Username)
+		for _, env := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+			_ = os.Unsetenv(env)
+		}
+	}
+
 	// If ran as root, the HOME environment variable will be that of root.
 	// SUDO_USER and DOAS_USER tell us the actual user
 	var sudoUser = os.Getenv("SUDO_USER")
@@ -31,6 +39,13 @@ func init() {
 			_ = os.Setenv("SUDO_USER", sudoUser)
 		}
 	}
+	// pkexec, which runs the elevated helper, only tells us the uid
+	if uid := os.Getenv("PKEXEC_UID"); sudoUser == "" && uid != "" {
+		if u, err := user.LookupId(uid); err == nil {
+			sudoUser = u.Username
+			_ = os.Setenv("SUDO_USER", sudoUser)
+		}
+	}
 	if sudoUser != "" {
 		if sudoUser == "root" {
 			panic("PotatocordInstaller must not be run as the root user. Please rerun as normal user. Use sudo or doas to run as root.")
@@ -46,27 +61,31 @@ func init() {
 			Log.Debug("Actual HOME is", u.HomeDir)
 			_ = os.Setenv("HOME", u.HomeDir)
 		}
-	} else if os.Getuid() == 0 {
+	} else if os.Getuid() == 0 && TargetUserError == nil {
 		panic("PotatocordInstaller was run as root but neither SUDO_USER nor DOAS_USER are set. Please rerun me as a normal user, with sudo/doas, or manually set SUDO_USER to your username")
 	}
 	Home = os.Getenv("HOME")
 
 	DiscordDirs = []string{
 		"/usr/share",
+		"/usr/lib",
 		"/usr/lib64",
+		"/usr/local/share",
 		"/opt",
 		path.Join(Home, ".local/share"),
 		path.Join(Home, ".dvm"),
-		"/var/lib/flatpak/app",
-		path.Join(Home, "/.local/share/flatpak/app"),
+		"/snap/discord/current/usr/share",
+		"/snap/discord-canary/current/usr/share",
 	}
+	DiscordDirs = append(DiscordDirs, getFlatpakAppDirs()...)
 }
 
 func ParseDiscord(p, _ string) *DiscordInstall {
 	name := path.Base(p)
 
-	needsFlatpakResolve := strings.Contains(p, "/flatpak/") && !strings.Contains(p, "/current/active/files/")
-	if needsFlatpakResolve {
+	// Flatpak installations may live anywhere, see /etc/flatpak/installations.d
+	isFlatpak := strings.HasPrefix(name, "com.discordapp.") || strings.Contains(p, "/current/active/files/")
+	if strings.HasPrefix(name, "com.discordapp.") {
 		discordName := strings.ToLower(name[len("com.discordapp."):])
 		if discordName != "discord" { //
 			// DiscordCanary -> discord-canary
@@ -95,12 +114,13 @@ func ParseDiscord(p, _ string) *DiscordInstall {
 		branch:           GetBranch(name),
 		appPath:          app,
 		isPatched:        isPatched,
-		isFlatpak:        needsFlatpakResolve,
+		isFlatpak:        isFlatpak,
+		isSnap:           strings.HasPrefix(p, "/snap/"),
 		isSystemElectron: isSystemElectron,
 	}
 }
 
-func FindDiscords() []any {
+func findKnownDiscords() []any {
 	var discords []any
 	for _, dir := range DiscordDirs {
 		children, err := os.ReadDir(dir)
@@ -125,47 +145,241 @@ func FindDiscords() []any {
 		}
 	}
 
-	return discords
+	return append(discords, findFlatpaksByData(discords)...)
+}
+
+// getDiscordParentDirs returns the directories Discord installs itself into
+func getDiscordParentDirs() []string {
+	return DiscordDirs
+}
+
+// findRunningDiscordPaths returns the install directories of all running Discord processes
+func findRunningDiscordPaths() []string {
+	var paths []string
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return paths
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		exe, err := os.Readlink(path.Join("/proc", entry.Name(), "exe"))
+		if err != nil {
+			continue
+		}
+		if SliceContains(LinuxDiscordNames, path.Base(exe)) {
+			paths = append(paths, path.Dir(exe))
+			continue
+		}
+
+		// System electron, the app.asar is passed as argument
+		if !strings.Contains(path.Base(exe), "electron") {
+			continue
+		}
+		cmdline, err := os.ReadFile(path.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		for _, arg := range strings.Split(string(cmdline), "\x00") {
+			if strings.HasSuffix(arg, "app.asar") && strings.Contains(strings.ToLower(arg), "discord") {
+				paths = append(paths, path.Dir(arg))
+			}
+		}
+	}
+	return paths
+}
+
+// GetDiscordDataDir returns Discord's user data directory, which contains its logs and settings
+func GetDiscordDataDir(di *DiscordInstall) string {
+	name := discordDataDirNames[di.branch]
+	if di.isFlatpak {
+		return path.Join(Home, ".var/app", di.flatpakId(), "config", name)
+	}
+	if di.isSnap {
+		return path.Join(Home, "snap", di.snapName(), "current/.config", name)
+	}
+
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" && os.Getenv("SUDO_USER") == "" {
+		return path.Join(configHome, name)
+	}
+	return path.Join(Home, ".config", name)
 }
 
+// Whether PreparePatch closes Discord
+const patchClosesDiscord = false
+
 func PreparePatch(di *DiscordInstall) {}
 
-// FixOwnership fixes file ownership on Linux
-func FixOwnership(p string) error {
-	if os.Geteuid() != 0 {
-		return nil
+var linuxDesktopEntries = map[string]string{
+	"stable":      "discord",
+	"ptb":         "discord-ptb",
+	"canary":      "discord-canary",
+	"development": "discord-development",
+}
+
+func discordLaunchCommand(di *DiscordInstall, exe string) *exec.Cmd {
+	if di.isFlatpak {
+		return exec.Command("flatpak", "run", di.flatpakId())
 	}
 
-	Log.Debug("Fixing Ownership of", p)
+	// The desktop entry may add flags or wrappers the distro needs, so prefer it
+	name := linuxDesktopEntries[di.branch]
+	if entry := findDesktopEntry(name); entry != "" && desktopEntryLaunches(entry, di.path) {
+		if _, err := exec.LookPath("gtk-launch"); err == nil {
+			return exec.Command("gtk-launch", name)
+		}
+		if _, err := exec.LookPath("gio"); err == nil {
+			return exec.Command("gio", "launch", entry)
+		}
+	}
 
-	sudoUser := os.Getenv("SUDO_USER")
-	if sudoUser == "" {
-		panic("SUDO_USER was empty. This point should never be reached")
+	if exe != "" {
+		return exec.Command(exe)
 	}
+	return nil
+}
+
+func findDesktopEntry(name string) string {
+	dataDirs := []string{path.Join(Home, ".local/share")}
+	if xdgDataDirs := os.Getenv("XDG_DATA_DIRS"); xdgDataDirs != "" {
+		dataDirs = append(dataDirs, strings.Split(xdgDataDirs, ":")...)
+	} else {
+		dataDirs = append(dataDirs, "/usr/local/share", "/usr/share")
+	}
+
+	for _, dir := range dataDirs {
+		if entry := path.Join(dir, "applications", name+".desktop"); ExistsFile(entry) {
+			return entry
+		}
+	}
+	return ""
+}
 
-	Log.Debug("Looking up User", sudoUser)
-	u, err := user.Lookup(sudoUser)
+// desktopEntryLaunches reports whether the desktop entry starts the Discord at dir and not some other install
+// of the same branch
+func desktopEntryLaunches(entry, dir string) bool {
+	b, err := os.ReadFile(entry)
 	if err != nil {
-		Log.Error("Lookup failed:", err)
-		return err
+		return false
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		cmd, found := strings.CutPrefix(line, "Exec=")
+		if !found {
+			continue
+		}
+		args := splitDesktopExec(cmd)
+		// Exec=env FOO=bar /opt/Discord/Discord
+		for len(args) > 0 && (args[0] == "env" || strings.Contains(args[0], "=")) {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return false
+		}
+		bin, err := exec.LookPath(args[0])
+		if err != nil {
+			return false
+		}
+		if bin, err = path.EvalSymlinks(bin); err != nil {
+			return false
+		}
+		if dir, err = path.EvalSymlinks(dir); err != nil {
+			return false
+		}
+		return strings.HasPrefix(bin, dir+"/")
 	}
-	Log.Debug("Lookup successful, Uid", u.Uid, "Gid", u.Gid)
-	// This conversion is safe because of the GOOS guard above
-	uid, _ := strconv.Atoi(u.Uid)
-	gid, _ := strconv.Atoi(u.Gid)
+	return false
+}
 
-	err = path.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
-		if err == nil {
-			err = os.Chown(path, uid, gid)
-			Log.Debug("chown", u.Uid+":"+u.Gid, path+":", Ternary(err == nil, "Success!", "Failed"))
+// splitDesktopExec splits the Exec key of a desktop entry into arguments. Arguments containing spaces or other
+// reserved characters are double quoted, with backslash escapes inside the quotes
+func splitDesktopExec(exec string) []string {
+	var args []string
+	var arg strings.Builder
+	inQuotes, hasArg := false, false
+	for i := 0; i < len(exec); i++ {
+		c := exec[i]
+		switch {
+		case c == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case c == '\\' && inQuotes && i+1 < len(exec):
+			i++
+			arg.WriteByte(exec[i])
+		case c == ' ' && !inQuotes:
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			hasArg = true
 		}
-		return err
-	})
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
 
+// findDiscordProcesses returns all processes running from the given install
+func findDiscordProcesses(di *DiscordInstall) []DiscordProcess {
+	var procs []DiscordProcess
+	entries, err := os.ReadDir("/proc")
 	if err != nil {
-		Log.Error("Failed to fix ownership:", err)
+		return procs
+	}
+
+	dir := di.path
+	// /proc/<pid>/exe is always resolved
+	if resolved, err := path.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	prefix := path.Clean(dir) + "/"
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+
+		exe, _ := os.Readlink(path.Join("/proc", entry.Name(), "exe"))
+		if exe != "" && strings.HasPrefix(exe, prefix) {
+			procs = append(procs, DiscordProcess{pid, exe})
+			continue
+		}
+
+		if di.isFlatpak {
+			// Flatpak processes live in their own mount namespace, so match the sandbox's cgroup instead
+			cgroup, _ := os.ReadFile(path.Join("/proc", entry.Name(), "cgroup"))
+			if strings.Contains(string(cgroup), "app-flatpak-"+di.flatpakId()+"-") {
+				procs = append(procs, DiscordProcess{pid, exe})
+			}
+		} else if di.isSystemElectron && strings.Contains(path.Base(exe), "electron") {
+			cmdline, _ := os.ReadFile(path.Join("/proc", entry.Name(), "cmdline"))
+			if strings.Contains(string(cmdline), prefix) || strings.Contains(string(cmdline), path.Clean(di.path)+"/") {
+				procs = append(procs, DiscordProcess{pid, exe})
+			}
+		}
+	}
+	return procs
+}
+
+// FixOwnership fixes file ownership on Linux
+func FixOwnership(p string) error {
+	// Machine-wide files must stay owned by root, otherwise one user could change what everyone loads
+	if os.Geteuid() != 0 || isSystemPath(p) {
+		return nil
+	}
+
+	sudoUser := os.Getenv("SUDO_USER")
+	if sudoUser == "" {
+		panic("SUDO_USER was empty. This point should never be reached")
 	}
-	return err
+	return chownTo(p, sudoUser)
 }
 
 func CheckScuffedInstall() bool {
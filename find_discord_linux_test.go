@@ -0,0 +1,32 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDesktopExec(t *testing.T) {
+	tests := []struct {
+		exec string
+		want []string
+	}{
+		{"/usr/bin/discord %U", []string{"/usr/bin/discord", "%U"}},
+		{`"/opt/My Apps/Discord/Discord" --flag`, []string{"/opt/My Apps/Discord/Discord", "--flag"}},
+		{`/home/jürgen/Discord/Discord  --start-minimized`, []string{"/home/jürgen/Discord/Discord", "--start-minimized"}},
+		{`"/home/ユーザー/Discord Canary/DiscordCanary"`, []string{"/home/ユーザー/Discord Canary/DiscordCanary"}},
+		{`"/opt/discord \"beta\"/Discord" ""`, []string{`/opt/discord "beta"/Discord`, ""}},
+		{`"/opt/back\\slash/Discord"`, []string{`/opt/back\slash/Discord`}},
+		{"  ", nil},
+	}
+	for _, test := range tests {
+		if got := splitDesktopExec(test.exec); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitDesktopExec(%q) = %q, want %q", test.exec, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,7 @@ package main
 import (
 	"errors"
 	"os"
+	"os/exec"
 	path "path/filepath"
 	"strings"
 	"sync"
@@ -26,6 +27,15 @@ var windowsNames = map[string]string{
 
 var killLock sync.Mutex
 
+func init() {
+	// Discord and Potatocord live in the user's profile, which other accounts may write to with administrator rights
+	if u := lookupTargetUser(); u != nil {
+		_ = os.Setenv("USERPROFILE", u.HomeDir)
+		_ = os.Setenv("APPDATA", path.Join(u.HomeDir, "AppData", "Roaming"))
+		_ = os.Setenv("LOCALAPPDATA", path.Join(u.HomeDir, "AppData", "Local"))
+	}
+}
+
 func ParseDiscord(p, branch string) *DiscordInstall {
 	entries, err := os.ReadDir(p)
 	if err != nil {
@@ -54,6 +64,12 @@ func ParseDiscord(p, branch string) *DiscordInstall {
 		}
 	}
 
+	// Portable installs, e.g. an app-<version> folder copied somewhere, have the resources right inside
+	if resources := path.Join(p, "resources"); appPath == "" && ExistsFile(path.Join(resources, "app.asar")) {
+		appPath = path.Join(resources, "app")
+		isPatched = ExistsFile(path.Join(resources, "_app.asar"))
+	}
+
 	if appPath == "" {
 		return nil
 	}
@@ -72,54 +88,95 @@ func ParseDiscord(p, branch string) *DiscordInstall {
 	}
 }
 
-func FindDiscords() []any {
+func findKnownDiscords() []any {
 	var discords []any
 
 	appData := os.Getenv("LOCALAPPDATA")
 	if appData == "" {
 		Log.Error("%LOCALAPPDATA% is empty???????")
-		return discords
 	}
 
 	for branch, dirname := range windowsNames {
-		p := path.Join(appData, dirname)
-		if discord := ParseDiscord(p, branch); discord != nil {
-			Log.Debug("Found Discord install at ", p)
-			discords = append(discords, discord)
+		for _, base := range getDiscordParentDirs() {
+			p := path.Join(base, dirname)
+			if discord := ParseDiscord(p, branch); discord != nil {
+				Log.Debug("Found Discord install at ", p)
+				discords = append(discords, discord)
+			}
 		}
 	}
-	return discords
+	return append(discords, findStoreDiscords()...)
 }
 
-func PreparePatch(di *DiscordInstall) {
-	killLock.Lock()
-	defer killLock.Unlock()
-
-	name := windowsNames[di.branch]
-	Log.Debug("Trying to kill", name)
-	pid := findProcessIdByName(name + ".exe")
-	if pid == 0 {
-		Log.Debug("Didn't find process matching name")
-		return
+// getDiscordParentDirs returns the directories Discord installs itself into
+func getDiscordParentDirs() []string {
+	var dirs []string
+	for _, env := range []string{"LOCALAPPDATA", "ProgramFiles", "ProgramFiles(x86)"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
+		}
 	}
+	return dirs
+}
 
-	proc, err := os.FindProcess(int(pid))
+// findRunningDiscordPaths returns the install directories of all running Discord processes
+func findRunningDiscordPaths() []string {
+	var paths []string
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
 	if err != nil {
-		Log.Warn("Failed to find process with pid", pid)
-		return
+		return paths
 	}
+	defer windows.CloseHandle(snapshot)
 
-	err = proc.Kill()
-	if err != nil {
-		Log.Warn("Failed to kill", name+":", err)
-	} else {
-		Log.Debug("Waiting for", name, "to exit")
-		_, _ = proc.Wait()
+	procEntry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
+	for err = windows.Process32First(snapshot, &procEntry); err == nil; err = windows.Process32Next(snapshot, &procEntry) {
+		name := strings.TrimSuffix(windows.UTF16ToString(procEntry.ExeFile[:]), ".exe")
+		isDiscord := false
+		for _, discordName := range windowsNames {
+			isDiscord = isDiscord || strings.EqualFold(name, discordName)
+		}
+		if !isDiscord {
+			continue
+		}
+
+		exe := getProcessExe(procEntry.ProcessID)
+		if exe == "" {
+			continue
+		}
+
+		// <install>/app-<version>/Discord.exe
+		appDir := path.Dir(exe)
+		if strings.HasPrefix(path.Base(appDir), "app-") {
+			paths = append(paths, path.Dir(appDir))
+		}
 	}
+	return paths
 }
 
-func FixOwnership(_ string) error {
-	return nil
+// GetDiscordDataDir returns Discord's user data directory, which contains its logs and settings
+func GetDiscordDataDir(di *DiscordInstall) string {
+	return path.Join(os.Getenv("APPDATA"), discordDataDirNames[di.branch])
+}
+
+// Whether PreparePatch closes Discord
+const patchClosesDiscord = true
+
+func PreparePatch(di *DiscordInstall) {
+	killLock.Lock()
+	defer killLock.Unlock()
+
+	// Discord's files can't be replaced while it is running
+	if _, err := di.CloseDiscord(); err != nil {
+		Log.Warn(err.Error())
+	}
+}
+
+// FixOwnership gives the files written for --user to that user
+func FixOwnership(p string) error {
+	if TargetUser == "" || isSystemPath(p) {
+		return nil
+	}
+	return chownTo(p, TargetUser)
 }
 
 // https://github.com/Vencord/Installer/issues/9
@@ -136,20 +193,48 @@ func CheckScuffedInstall() bool {
 	return false
 }
 
-func findProcessIdByName(name string) uint32 {
+func discordLaunchCommand(di *DiscordInstall, exe string) *exec.Cmd {
+	// Starting via Squirrel's Update.exe is what Discord's shortcuts do, it picks the current app-<version>
+	updateExe := path.Join(di.path, "Update.exe")
+	if ExistsFile(updateExe) {
+		return exec.Command(updateExe, "--processStart", windowsNames[di.branch]+".exe")
+	}
+	if exe != "" {
+		return exec.Command(exe)
+	}
+	return nil
+}
+
+// findDiscordProcesses returns all processes running from the given install
+func findDiscordProcesses(di *DiscordInstall) []DiscordProcess {
+	var procs []DiscordProcess
 	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
 	if err != nil {
-		return 0
+		return procs
 	}
+	defer windows.CloseHandle(snapshot)
 
+	prefix := strings.ToLower(path.Clean(di.path)) + `\`
 	procEntry := windows.ProcessEntry32{Size: uint32(unsafe.Sizeof(windows.ProcessEntry32{}))}
-	for {
-		err = windows.Process32Next(snapshot, &procEntry)
-		if err != nil {
-			return 0
-		}
-		if windows.UTF16ToString(procEntry.ExeFile[:]) == name {
-			return procEntry.ProcessID
+	for err = windows.Process32First(snapshot, &procEntry); err == nil; err = windows.Process32Next(snapshot, &procEntry) {
+		if exe := getProcessExe(procEntry.ProcessID); strings.HasPrefix(strings.ToLower(exe), prefix) {
+			procs = append(procs, DiscordProcess{int(procEntry.ProcessID), exe})
 		}
 	}
+	return procs
+}
+
+func getProcessExe(pid uint32) string {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(handle)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err = windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:size])
 }
@@ -0,0 +1,77 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"strings"
+)
+
+// getFlatpakAppDirs returns the app directories of the user installation, the system installation and
+// any additional installations configured in /etc/flatpak/installations.d
+func getFlatpakAppDirs() []string {
+	dataHome := path.Join(Home, ".local/share")
+	if xdgDataHome := os.Getenv("XDG_DATA_HOME"); xdgDataHome != "" && os.Getenv("SUDO_USER") == "" {
+		dataHome = xdgDataHome
+	}
+
+	dirs := []string{
+		path.Join(dataHome, "flatpak/app"),
+		"/var/lib/flatpak/app",
+	}
+
+	confs, _ := path.Glob("/etc/flatpak/installations.d/*.conf")
+	for _, conf := range confs {
+		f, err := os.Open(conf)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if key, value, ok := strings.Cut(scanner.Text(), "="); ok && strings.TrimSpace(key) == "Path" {
+				dirs = append(dirs, path.Join(strings.TrimSpace(value), "app"))
+			}
+		}
+		_ = f.Close()
+	}
+	return dirs
+}
+
+// findFlatpaksByData returns the installs of Discord Flatpaks that have user data in ~/.var/app but aren't in
+// any of the known installations, by asking flatpak where they are
+func findFlatpaksByData(known []any) []any {
+	var discords []any
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		return discords
+	}
+
+	for _, name := range LinuxDiscordNames {
+		if !strings.HasPrefix(name, "com.discordapp.") || !ExistsFile(path.Join(Home, ".var/app", name)) {
+			continue
+		}
+		if SliceContainsFunc(known, func(d any) bool { return d.(*DiscordInstall).flatpakId() == name }) {
+			continue
+		}
+
+		out, err := exec.Command("flatpak", "info", "--show-location", name).Output()
+		if err != nil {
+			Log.Debug("Flatpak", name, "has data but isn't installed")
+			continue
+		}
+		// <installation>/app/<id>/<arch>/<branch>/<commit>
+		location := strings.TrimSpace(string(out))
+		appDir := path.Join(location, "..", "..", "..")
+		if discord := ParseDiscord(appDir, ""); discord != nil {
+			Log.Debug("Found Discord Flatpak at", appDir)
+			discords = append(discords, discord)
+		}
+	}
+	return discords
+}
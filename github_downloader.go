@@ -7,9 +7,12 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
@@ -17,17 +20,47 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type GithubRelease struct {
-	Name    string `json:"name"`
-	TagName string `json:"tag_name"`
-	Assets  []struct {
-		Name        string `json:"name"`
-		DownloadURL string `json:"browser_download_url"`
-	} `json:"assets"`
+	Name    string         `json:"name"`
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+	// Release notes, in markdown
+	Body string `json:"body"`
+	// Build hash if known from a structured source, see GetReleaseHash
+	Hash string `json:"-"`
+	// Parsed metadata.json asset, if any
+	Metadata *ReleaseMetadata `json:"-"`
+	// Made up because the builds repo's API couldn't be asked for its latest commit, e.g. when rate limited, so
+	// its hash isn't known
+	Fallback bool `json:"-"`
 }
 
+type ReleaseAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+	// Checksum GitHub computed for the asset, e.g. sha256:<hex>
+	Digest string `json:"digest,omitempty"`
+	// In bytes, 0 if unknown
+	Size int64 `json:"size,omitempty"`
+}
+
+type ReleaseMetadata struct {
+	Hash    string       `json:"hash"`
+	Mirrors []MirrorHint `json:"mirrors,omitempty"`
+}
+
+// Optional release asset containing ReleaseMetadata
+const ReleaseMetadataAsset = "metadata.json"
+
+var commitHashRegex = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// The comment builds start with, e.g. // Potatocord 1a2b3c4
+var asarHashRe = regexp.MustCompile(`// (Vencord|Potatocord) (\w+)`)
+
 type GithubCommit struct {
 	Sha string `json:"sha"`
 }
@@ -36,6 +69,35 @@ var ReleaseData GithubRelease
 var GithubError error
 var GithubDoneChan chan bool
 
+var githubDone = sync.OnceValue(func() bool {
+	ok := <-GithubDoneChan
+	githubFinished.Store(true)
+	return ok
+})
+
+var githubFinished atomic.Bool
+
+// WaitForGithub blocks until the latest release was fetched and returns whether that succeeded.
+// Unlike receiving from GithubDoneChan, it may be called any number of times
+func WaitForGithub() bool {
+	return githubDone()
+}
+
+// GithubFinished reports whether fetching the latest release is done, without waiting for it
+func GithubFinished() bool {
+	if githubFinished.Load() {
+		return true
+	}
+	select {
+	case ok := <-GithubDoneChan:
+		// Leave the result to WaitForGithub
+		GithubDoneChan <- ok
+		return true
+	default:
+		return false
+	}
+}
+
 var InstalledHash = "None"
 var LatestHash = "Unknown"
 var IsDevInstall bool
@@ -60,19 +122,18 @@ func GetGithubRelease(url, fallbackUrl string) (*GithubRelease, error) {
 	defer res.Body.Close()
 
 	if res.StatusCode >= 300 {
-		isRateLimitedOrBlocked := res.StatusCode == 401 || res.StatusCode == 403 || res.StatusCode == 429
+		statusErr := &HttpStatusError{res.StatusCode, res.Status}
 		triedFallback := url == fallbackUrl
 
 		// GitHub has a very strict 60 req/h rate limit and some (mostly indian) isps block github for some reason.
 		// If that is the case, try our fallback at https://potatocord.dev/releases/project
-		if isRateLimitedOrBlocked && !triedFallback {
+		if statusErr.RateLimited() && !triedFallback {
 			Log.Error(fmt.Sprintf("Failed to fetch %s (status code %d). Trying fallback url %s", url, res.StatusCode, fallbackUrl))
 			return GetGithubRelease(fallbackUrl, fallbackUrl)
 		}
 
-		err = errors.New(res.Status)
 		Log.Error(url, "returned Non-OK status", GithubError)
-		return nil, err
+		return nil, statusErr
 	}
 
 	var data GithubRelease
@@ -88,7 +149,7 @@ func GetGithubRelease(url, fallbackUrl string) (*GithubRelease, error) {
 func GetBuildsRepoRelease() (*GithubRelease, error) {
 	Log.Debug("Fetching latest commit from builds repo", BuildsApiUrl)
 
-	name := "DevBuild Unknown"
+	name, hash := "DevBuild Unknown", ""
 
 	req, err := http.NewRequest("GET", BuildsApiUrl, nil)
 	if err == nil {
@@ -101,19 +162,19 @@ func GetBuildsRepoRelease() (*GithubRelease, error) {
 			if res.StatusCode < 300 {
 				var commit GithubCommit
 				if err = json.NewDecoder(res.Body).Decode(&commit); err == nil {
-					name = "DevBuild " + commit.Sha[:7]
+					hash = commit.Sha[:7]
+					name = "DevBuild " + hash
 				}
 			}
 		}
 	}
 
 	return &GithubRelease{
-		Name:    name,
-		TagName: "devbuild",
-		Assets: []struct {
-			Name        string `json:"name"`
-			DownloadURL string `json:"browser_download_url"`
-		}{
+		Name:     name,
+		Hash:     hash,
+		TagName:  "devbuild",
+		Fallback: hash == "",
+		Assets: []ReleaseAsset{
 			{
 				Name:        "potatocord.asar",
 				DownloadURL: BuildsRawUrl + "/potatocord.asar",
@@ -122,47 +183,210 @@ func GetBuildsRepoRelease() (*GithubRelease, error) {
 	}, nil
 }
 
+// FetchLatestRelease returns the latest release from the first UpdateSource that works
+func FetchLatestRelease() (data *GithubRelease, err error) {
+	for _, source := range GetUpdateSources() {
+		Log.Debug("Fetching latest release from", source.Name())
+		ReportStage(StageFetch, "Fetching the latest release from "+source.Name())
+		if data, err = source.LatestRelease(); err == nil {
+			if err = data.Validate(); err == nil {
+				return
+			}
+		}
+		Log.Warn("Failed to fetch latest release from", source.Name()+":", err)
+	}
+	return
+}
+
+// GetReleaseHash returns the build hash of the release. It is read from the release's metadata.json asset or else its
+// tag, which is either the hash itself or ends in -<hash>. Returns "Unknown" if neither contains a commit hash
+func GetReleaseHash(data *GithubRelease) string {
+	if data.Hash == "" {
+		if b, err := fetchReleaseAsset(data, ReleaseMetadataAsset, 64*1024, true); err == nil {
+			var metadata ReleaseMetadata
+			if err = json.Unmarshal(b, &metadata); err != nil {
+				Log.Warn("Failed to parse", ReleaseMetadataAsset+":", err)
+			}
+			data.Hash = metadata.Hash
+			data.Metadata = &metadata
+		}
+	}
+
+	if hash := strings.ToLower(data.Hash); commitHashRegex.MatchString(hash) {
+		Log.Debug("Using hash", hash, "from release", ReleaseMetadataAsset)
+		return hash
+	} else if hash != "" {
+		Log.Debug("Release", ReleaseMetadataAsset, "doesn't contain a valid hash:", data.Hash)
+	}
+
+	// Tags like build-20231015 end in a date, not a hash
+	tag := data.TagName[strings.LastIndex(data.TagName, "-")+1:]
+	if hash := strings.ToLower(tag); commitHashRegex.MatchString(hash) && strings.Trim(hash, "0123456789") != "" {
+		Log.Debug("Using hash", hash, "from release tag")
+		return hash
+	}
+
+	Log.Warn("Failed to determine hash of release", data.Name+": Neither", ReleaseMetadataAsset, "nor the tag", data.TagName, "contains one")
+	return "Unknown"
+}
+
+// HashesMatch compares two build hashes, which may be abbreviated to different lengths
+func HashesMatch(a, b string) bool {
+	if !commitHashRegex.MatchString(a) || !commitHashRegex.MatchString(b) {
+		return a == b
+	}
+	n := min(len(a), len(b))
+	return a[:n] == b[:n]
+}
+
+// Validate checks that the release can be installed and resolves its hash.
+// The errors describe exactly what is wrong, as a broken release is otherwise hard to tell apart from a broken installer
+func (r *GithubRelease) Validate() error {
+	label := Ternary(r.Name != "", r.Name, r.TagName)
+	if label == "" {
+		return errors.New("Invalid release: It has neither a name nor a tag")
+	}
+
+	if len(r.Assets) == 0 {
+		return errors.New("Release " + label + " has no assets yet. Is it still being published?")
+	}
+
+	for i, ass := range r.Assets {
+		if ass.Name == "" || ass.DownloadURL == "" {
+			return fmt.Errorf("Invalid release %s: Asset %d is missing its name or download url", label, i)
+		}
+	}
+
+	if findAsarAsset(r) == nil {
+		return errors.New("Release " + label + " has no desktop.asar or potatocord.asar asset")
+	}
+
+	hash := GetReleaseHash(r)
+	if hash == "Unknown" && r.Fallback {
+		Log.Warn("The build hash of release", label, "is unknown, so updates can't be detected")
+	} else if hash == "Unknown" {
+		return errors.New("Couldn't determine the build hash of release " + label + ". Neither its " + ReleaseMetadataAsset + " nor its tag contains a commit hash")
+	}
+	r.Hash = hash
+
+	return nil
+}
+
+func findAsarAsset(data *GithubRelease) *ReleaseAsset {
+	for i, ass := range data.Assets {
+		if ass.Name == "desktop.asar" || ass.Name == "potatocord.asar" {
+			return &data.Assets[i]
+		}
+	}
+	return nil
+}
+
+func findReleaseAsset(data *GithubRelease, name string) string {
+	for _, ass := range data.Assets {
+		if ass.Name == name {
+			return ass.DownloadURL
+		}
+	}
+	return ""
+}
+
+// fetchReleaseAsset downloads a small release asset, reading at most limit bytes. Checksums must not come from the
+// mirrors they're meant to verify, so those are only tried if useMirrors is set
+func fetchReleaseAsset(data *GithubRelease, name string, limit int64, useMirrors bool) ([]byte, error) {
+	url := findReleaseAsset(data, name)
+	if url == "" {
+		return nil, errors.New("Release has no " + name + " asset")
+	}
+
+	res, err := DownloadWithFailover(Ternary(useMirrors, GetAssetUrls(url, name), []string{url}))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return io.ReadAll(io.LimitReader(res.Body, limit))
+}
+
+// fetchReleaseText returns the first word of a small text release asset
+func fetchReleaseText(data *GithubRelease, name string, useMirrors bool) (string, error) {
+	b, err := fetchReleaseAsset(data, name, 1024, useMirrors)
+	if err != nil {
+		return "", err
+	}
+
+	// sha256sum style files are "<hash>  <file>"
+	fields := strings.Fields(string(b))
+	if len(fields) == 0 {
+		return "", errors.New(name + " is empty")
+	}
+	return fields[0], nil
+}
+
+func isDevInstallEnv() bool {
+	return EnvDevInstall.IsEnabled()
+}
+
 func InitGithubDownloader() {
 	GithubDoneChan = make(chan bool, 1)
 
-	IsDevInstall = os.Getenv("POTATOCORD_DEV_INSTALL") == "1" || os.Getenv("VENCORD_DEV_INSTALL") == "1"
+	IsDevInstall = isDevInstallEnv()
 	Log.Debug("Is Dev Install: ", IsDevInstall)
 	if IsDevInstall {
 		GithubDoneChan <- true
 		return
 	}
 
+	if hash := ReadInstalledHash(); hash != "" {
+		InstalledHash = hash
+	}
+
+	if IsOffline() {
+		if data, _, err := ReadCachedRelease(); err != nil {
+			GithubError = err
+		} else {
+			ReleaseData = *data
+			LatestHash = GetReleaseHash(data)
+		}
+		GithubDoneChan <- GithubError == nil
+		return
+	}
+
+	if ShareSourceUrl == "" {
+		AddAssetMirrors(EnvMirror.GetList())
+		AddAssetMirrors(Settings.AcceptedMirrors)
+	}
+
 	go func() {
 		// Make sure UI updates once the request either finished or failed
 		defer func() {
 			GithubDoneChan <- GithubError == nil
 		}()
 
-		data, err := GetGithubRelease(ReleaseUrl, ReleaseUrlFallback)
-		if err != nil {
-			Log.Warn("Failed to fetch GitHub Release, trying builds repo fallback:", err)
-			data, err = GetBuildsRepoRelease()
-		}
-
+		data, err := FetchLatestRelease()
 		if err != nil {
 			GithubError = err
 			return
 		}
 
 		ReleaseData = *data
-
-		i := strings.LastIndex(data.Name, " ") + 1
-		LatestHash = data.Name[i:]
+		LatestHash = GetReleaseHash(data)
+		PendingMirrorHints = findMirrorHints(data.Metadata)
+		// Mirrors are probed with the release's asar, so it has to be known first
+		go ProbeMirrors()
+		CacheRelease(data, LatestHash)
 		Log.Debug("Finished fetching GitHub Data")
-		Log.Debug("Latest hash is", LatestHash, "Local Install is", Ternary(LatestHash == InstalledHash, "up to date!", "outdated!"))
+		Log.Debug("Latest hash is", LatestHash, "Local Install is", Ternary(HashesMatch(LatestHash, InstalledHash), "up to date!", "outdated!"))
 	}()
+}
 
+// ReadInstalledHash returns the hash of the Potatocord build at PotatocordDirectory, or an empty string if there is none
+func ReadInstalledHash() string {
 	// either .asar file or directory with main.js file (in DEV)
 	PotatocordFile := PotatocordDirectory
 
 	stat, err := os.Stat(PotatocordFile)
 	if err != nil {
-		return
+		return ""
 	}
 
 	// dev
@@ -170,82 +394,396 @@ func InitGithubDownloader() {
 		PotatocordFile = path.Join(PotatocordFile, "main.js")
 	}
 
-	// Check hash of installed version if exists
-	b, err := os.ReadFile(PotatocordFile)
+	return ReadAsarHash(PotatocordFile)
+}
+
+// ReadAsarHash returns the build hash embedded in the given Potatocord file, or an empty string if there is none
+func ReadAsarHash(file string) string {
+	b, err := os.ReadFile(file)
 	if err != nil {
-		return
+		return ""
 	}
 
-	Log.Debug("Found existing Potatocord Install. Checking for hash...")
-
-	re := regexp.MustCompile(`// (Vencord|Potatocord) (\w+)`)
-	match := re.FindSubmatch(b)
-	if match != nil {
-		InstalledHash = string(match[2])
-		Log.Debug("Existing hash is", InstalledHash)
+	Log.Debug("Checking", file, "for hash...")
 
-	} else {
+	match := asarHashRe.FindSubmatch(b)
+	if match == nil {
 		Log.Debug("Didn't find hash")
-
+		return ""
 	}
+
+	Log.Debug("Hash is", string(match[2]))
+	return string(match[2])
 }
 
-func installLatestBuilds() (retErr error) {
+func installLatestBuilds() error {
 	Log.Debug("Installing latest builds...")
+	return installBuild(LatestHash)
+}
 
+// installBuild installs the given Potatocord build to PotatocordDirectory. Builds other than the latest one can only
+// be installed if they are cached or retained
+func installBuild(hash string) (retErr error) {
 	if IsDevInstall {
 		Log.Debug("Skipping due to dev install")
 		return
 	}
 
-	downloadUrl := ""
-	for _, ass := range ReleaseData.Assets {
-		if ass.Name == "desktop.asar" || ass.Name == "potatocord.asar" {
-			downloadUrl = ass.DownloadURL
-			break
-		}
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
 	}
+	defer release()
 
-	if downloadUrl == "" {
-		retErr = errors.New("Didn't find desktop.asar download link")
-		Log.Error(retErr)
-		return
-	}
+	// Replacing the build keeps its mode and owner, which admins may have changed for system-wide installs
+	attrs, existed := getFileAttrs(PotatocordDirectory)
 
-	Log.Debug("Downloading desktop.asar")
+	if err = BackupPotatocordFile(); err != nil {
+		Log.Warn("Failed to back up the installed Potatocord version:", err)
+	}
 
-	res, err := http.Get(downloadUrl)
-	if err == nil && res.StatusCode >= 300 {
-		err = errors.New(res.Status)
+	// Download next to the installed build and only replace it once the download is complete, so a failed download
+	// doesn't leave a truncated build behind. If we can't write to the install directory, e.g. the machine-wide one,
+	// download without privileges and only elevate for copying the download there
+	elevate := NeedsElevation(path.Dir(PotatocordDirectory))
+	dirExisted := ExistsFile(path.Dir(PotatocordDirectory))
+	if !elevate {
+		if err = EnsureDir(path.Dir(PotatocordDirectory)); err != nil {
+			Log.Error(err.Error())
+			retErr = err
+			return
+		}
 	}
+	saved, err := saveBuild(hash, Ternary(elevate, "", path.Dir(PotatocordDirectory)), "Installing")
 	if err != nil {
-		Log.Error("Failed to download desktop.asar:", err)
+		Log.Error(err.Error())
 		retErr = err
 		return
 	}
-	out, err := os.OpenFile(PotatocordDirectory, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	defer os.Remove(saved.File)
+	if saved.FromCache {
+		Log.Info("Installing Potatocord", hash, "from cache")
+	}
+
+	if elevate {
+		// Downloaded to the temp directory, which may be on another disk
+		if err = checkDiskSpace(path.Dir(PotatocordDirectory), saved.Size); err != nil {
+			Log.Error(err.Error())
+			retErr = err
+			return
+		}
+		err = RunPrivileged([]PrivilegedOp{
+			{Op: "mkdir", Dst: path.Dir(PotatocordDirectory)},
+			{Op: "copy", Src: saved.File, Dst: PotatocordDirectory, Attrs: &attrs},
+		})
+	} else {
+		if err = attrs.apply(saved.File); err != nil {
+			Log.Warn("Failed to keep the mode and owner of", PotatocordDirectory+":", err)
+		}
+		err = retryOnSharingViolation(PotatocordDirectory, func() error {
+			return os.Rename(saved.File, PotatocordDirectory)
+		})
+	}
+	if err == nil {
+		err = CheckNotQuarantined(PotatocordDirectory)
+	}
 	if err != nil {
-		Log.Error("Failed to create", PotatocordDirectory+":", err)
+		err = antivirusError(PotatocordDirectory, err)
+		Log.Error("Failed to copy Potatocord to", PotatocordDirectory+":", err)
 		retErr = err
 		return
 	}
-	read, err := io.Copy(out, res.Body)
+
+	if !existed {
+		_ = FixOwnership(PotatocordDirectory)
+	}
+	fixWrittenFile(PotatocordDirectory)
+	recordInstalledBuild(hash, !existed)
+	if !dirExisted {
+		recordTouchedFiles(true, path.Dir(PotatocordDirectory))
+	}
+
+	InstalledHash = hash
+	return
+}
+
+// savedBuild is a verified build saveBuild wrote to a temp file
+type savedBuild struct {
+	File      string
+	Size      int64
+	Sha256    string
+	FromCache bool
+}
+
+// saveBuild writes the given build to a temp file in dir, or the system's temp directory if dir is empty, and verifies
+// it. Downloads that are broken or turn out to be another build are discarded and the next mirror is tried instead.
+// The caller must remove the file
+func saveBuild(hash, dir, cachedVerb string) (_ *savedBuild, retErr error) {
+	sources, err := findBuildSources(hash)
 	if err != nil {
-		Log.Error("Failed to download to", PotatocordDirectory+":", err)
-		retErr = err
-		return
+		return nil, err
 	}
-	contentLength := res.Header.Get("Content-Length")
-	expected := strconv.FormatInt(read, 10)
-	if expected != contentLength {
-		err = errors.New("Unexpected end of input. Content-Length was " + contentLength + ", but I only read " + expected)
-		Log.Error(err.Error())
-		retErr = err
-		return
+
+	out, err := os.CreateTemp(dir, "potatocord-*.asar.download")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = out.Close()
+		if retErr != nil {
+			_ = os.Remove(out.Name())
+		}
+	}()
+
+	var lastErr error
+	checkedSpace := false
+	for i, source := range sources {
+		if i > 0 {
+			Log.Warn("Trying", source.name)
+		}
+		body, size, err := source.open()
+		if errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+		if err != nil {
+			if lastErr == nil || !source.lastResort {
+				lastErr = err
+			}
+			continue
+		}
+
+		if !checkedSpace {
+			checkedSpace = true
+			if err = checkDiskSpace(path.Dir(out.Name()), size); err != nil {
+				_ = body.Close()
+				return nil, err
+			}
+		}
+
+		// Start over if an earlier source failed halfway
+		if err = out.Truncate(0); err == nil {
+			_, err = out.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			_ = body.Close()
+			return nil, err
+		}
+
+		h := sha256.New()
+		read, err := io.Copy(io.MultiWriter(out, h), newProgressReader(body, StageDownload, size, Ternary(source.fromCache, cachedVerb, "Downloading")+" Potatocord "+hash))
+		_ = body.Close()
+		if err = cancelledOr(err); errors.Is(err, ErrCancelled) {
+			return nil, err
+		}
+		if err == nil && size >= 0 && read != size {
+			err = errors.New("Unexpected end of input. Content-Length was " + strconv.FormatInt(size, 10) + ", but I only read " + strconv.FormatInt(read, 10))
+		}
+		if err == nil {
+			ReportStage(StageVerify, "Verifying Potatocord "+hash)
+			if err = ValidatePotatocordAsar(out.Name(), hash); err != nil {
+				if file, ok := getCachePath(hash); ok && source.fromCache {
+					_ = os.Remove(file)
+				}
+				err = withClass(ErrVerificationFailed, errors.New("The downloaded Potatocord build is broken: "+err.Error()))
+			}
+		}
+		if err != nil {
+			Log.Error("Failed to get Potatocord", hash, "from", source.name+":", err)
+			lastErr = err
+			continue
+		}
+
+		if err = out.Close(); err != nil {
+			return nil, err
+		}
+		if !source.fromCache {
+			AddToCache(hash, out.Name())
+		}
+		return &savedBuild{out.Name(), read, hex.EncodeToString(h.Sum(nil)), source.fromCache}, nil
 	}
 
-	_ = FixOwnership(PotatocordDirectory)
+	if sources[0].fromCache {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("Failed to download desktop.asar: %w", lastErr)
+}
 
-	InstalledHash = LatestHash
-	return
+// buildSource is somewhere saveBuild can read a build from
+type buildSource struct {
+	name      string
+	fromCache bool
+	// Only tried once everything else failed, so its error isn't the interesting one
+	lastResort bool
+	// Returns the body and its size, or -1 if the size is unknown
+	open func() (io.ReadCloser, int64, error)
+}
+
+// findBuildSources returns where the given build can be read from: the cache or the retained versions, or else the
+// download urls of the latest build followed by the IPFS gateways
+func findBuildSources(hash string) ([]buildSource, error) {
+	file, _, err := findBuild(hash)
+	if err != nil {
+		return nil, err
+	}
+	if file != "" {
+		if !isCached(hash) {
+			// Retained versions are as good as cached ones, but may be pruned while installing
+			AddToCache(hash, file)
+		}
+		return []buildSource{{name: "the cache", fromCache: true, open: func() (io.ReadCloser, int64, error) {
+			return OpenCachedBuild(hash)
+		}}}, nil
+	}
+	return latestAsarSources()
+}
+
+// findBuild returns where findBuildSources gets the given build from: the cached or retained file, or else the download url
+// of the latest build
+func findBuild(hash string) (file, downloadUrl string, err error) {
+	if cached, ok := getCachePath(hash); ok && isCached(hash) {
+		return cached, "", nil
+	}
+	if backup := ReadManifest().FindBackup(hash); backup != nil {
+		return backup.File, "", nil
+	}
+
+	if IsOffline() {
+		return "", "", Ternary(GithubError != nil, GithubError, errBuildNotCached(hash))
+	}
+	if !HashesMatch(hash, LatestHash) {
+		return "", "", errors.New("Potatocord " + hash + " is neither cached nor retained, and only the latest version can be downloaded")
+	}
+	asset := findAsarAsset(&ReleaseData)
+	if asset == nil {
+		return "", "", errors.New("Didn't find desktop.asar download link")
+	}
+	return "", GetAssetUrls(asset.DownloadURL, asset.Name)[0], nil
+}
+
+// latestAsarSources returns the download urls of the latest asar, followed by the IPFS gateways
+func latestAsarSources() ([]buildSource, error) {
+	asset := findAsarAsset(&ReleaseData)
+	if asset == nil {
+		return nil, errors.New("Didn't find desktop.asar download link")
+	}
+
+	// Mirrors aren't trusted, so their downloads are only used if they match the checksum of the release
+	urls := GetAssetUrls(asset.DownloadURL, asset.Name)
+	checksum, err := releaseText(&ReleaseData, asset.Name+ipfsChecksumSuffix)
+	if err != nil && len(urls) > 1 {
+		Log.Warn("Not using mirrors as", asset.Name, "has no checksum to verify them with:", err)
+		urls = []string{asset.DownloadURL}
+	}
+
+	sources := SliceMap(urls, func(url string) buildSource {
+		return buildSource{name: url, open: func() (io.ReadCloser, int64, error) {
+			res, err := DownloadWithFailover([]string{url})
+			if err != nil {
+				return nil, 0, err
+			}
+			if checksum != "" {
+				return &checksumReader{ReadCloser: res.Body, hash: sha256.New(), name: asset.Name, expected: checksum}, res.ContentLength, nil
+			}
+			return res.Body, res.ContentLength, nil
+		}}
+	})
+	return append(sources, ipfsSources(asset)...), nil
+}
+
+// checksumReader fails the read that reaches the end of a download if the download doesn't match its checksum
+type checksumReader struct {
+	io.ReadCloser
+	hash     hash.Hash
+	name     string
+	expected string
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		if actual := hex.EncodeToString(r.hash.Sum(nil)); !strings.EqualFold(actual, r.expected) {
+			err = withClass(ErrVerificationFailed, errors.New("Downloaded "+r.name+" has checksum "+actual+", but the release says "+r.expected))
+		}
+	}
+	return n, err
+}
+
+// GetAssetUrls returns the download url of a release asset followed by the same asset on all AssetMirrors.
+// If ProbeMirrors found a mirror to be faster than the download url, that mirror comes first
+func GetAssetUrls(downloadUrl, assetName string) []string {
+	urls := []string{downloadUrl}
+	fastest := getFastestMirror()
+	for _, mirror := range getAssetMirrors() {
+		u := mirror + "/" + assetName
+		if u == downloadUrl {
+			continue
+		}
+		if mirror == fastest {
+			urls = Prepend(urls, u)
+		} else {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// DownloadWithFailover requests the given urls in order and returns the first successful response.
+// The caller is responsible for closing its body
+func DownloadWithFailover(urls []string) (*http.Response, error) {
+	var lastErr error
+	for i, url := range urls {
+		if IsCancelled() {
+			return nil, ErrCancelled
+		}
+		if i > 0 {
+			Log.Warn("Trying mirror", url)
+		}
+
+		req, err := http.NewRequestWithContext(cancelCtx, "GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+
+		res, err := http.DefaultClient.Do(req)
+		if err == nil && res.StatusCode >= 300 {
+			_ = res.Body.Close()
+			err = &HttpStatusError{res.StatusCode, res.Status}
+		}
+		if err == nil {
+			return res, nil
+		}
+		if IsCancelled() {
+			return nil, ErrCancelled
+		}
+
+		Log.Error("Failed to download", url+":", err)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// checkDiskSpace makes sure size more bytes fit in dir before writing there, so a full disk can't leave behind a
+// half written asar. The installed build, its backup and the new one all exist at once, so nothing is reused
+func checkDiskSpace(dir string, size int64) error {
+	if size <= 0 {
+		Log.Warn("Server didn't send a Content-Length, skipping disk space check")
+		return nil
+	}
+
+	// The directory may not have been created yet
+	free, err := GetFreeDiskSpace(findExistingDir(dir))
+	if err != nil {
+		Log.Warn("Failed to check free disk space:", err)
+		return nil
+	}
+
+	needed := uint64(size)
+	Log.Debug("Need", FormatBytes(needed), "of disk space in", dir+", available:", FormatBytes(free))
+	if free < needed {
+		return errors.New("Not enough disk space to download Potatocord to " + dir + ". " +
+			FormatBytes(needed) + " are required, but only " + FormatBytes(free) + " are available")
+	}
+	return nil
 }
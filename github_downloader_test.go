@@ -0,0 +1,58 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "testing"
+
+func TestGetReleaseHash(t *testing.T) {
+	tests := []struct {
+		name        string
+		hash, tag   string
+		title, want string
+	}{
+		{"metadata", "ABCDEF1", "devbuild", "DevBuild", "abcdef1"},
+		{"metadata over tag", "abcdef1", "build-1234abc", "", "abcdef1"},
+		{"invalid metadata", "not a hash", "build-1234abc", "", "1234abc"},
+		{"tag is hash", "", "f00ba47", "", "f00ba47"},
+		{"tag ends in hash", "", "devbuild-0123456789abcdef0123456789abcdef01234567", "", "0123456789abcdef0123456789abcdef01234567"},
+		{"tag ends in date", "", "build-20231015", "", "Unknown"},
+		{"tag is branch", "", "devbuild", "", "Unknown"},
+		{"title isn't used", "", "latest", "DevBuild abc1234", "Unknown"},
+		{"nothing", "", "", "", "Unknown"},
+	}
+	for _, test := range tests {
+		// Without assets, there is no metadata.json to fetch
+		data := &GithubRelease{Name: test.title, TagName: test.tag, Hash: test.hash}
+		if got := GetReleaseHash(data); got != test.want {
+			t.Errorf("%s: GetReleaseHash() = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestHashesMatch(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"abcdef1", "abcdef1", true},
+		{"abcdef1", "abcdef1234567890abcdef1234567890abcdef12", true},
+		{"abcdef1234567890abcdef1234567890abcdef12", "abcdef1", true},
+		{"abcdef1", "abcdef2", false},
+		{"abcdef1", "abcdef2234567890abcdef1234567890abcdef12", false},
+		{"Unknown", "Unknown", true},
+		{"Unknown", "abcdef1", false},
+		{"None", "", false},
+		{"", "", true},
+		// Too short to be a hash, so only equal strings match
+		{"abc", "abcdef1", false},
+	}
+	for _, test := range tests {
+		if got := HashesMatch(test.a, test.b); got != test.want {
+			t.Errorf("HashesMatch(%q, %q) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
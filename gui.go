@@ -26,6 +26,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -47,6 +48,50 @@ var (
 
 	acceptedOpenAsar   bool
 	showedUpdatePrompt bool
+	showedMirrorPrompt bool
+	mirrorHints        []MirrorHint
+	skippedOnboarding  bool
+	removeUserData     bool
+
+	snapInstall *DiscordInstall
+
+	runningInstall  *DiscordInstall
+	runningAction   func()
+	relaunchDiscord = true
+	restartInstall  *DiscordInstall
+
+	conflictInstall *DiscordInstall
+	conflictingMods []ConflictingMod
+	conflictAction  func()
+
+	installDirInput    string
+	installForAllUsers bool
+
+	vencordMigration      *VencordMigration
+	showedMigrationPrompt bool
+
+	updatedInstall    *DiscordInstall
+	repatchErr        error
+	showRepatchPrompt bool
+
+	patchAllSelected map[string]bool
+
+	// What is running in the background, shown until it's done. Empty if nothing is
+	progressMessage string
+
+	reportInstall  *DiscordInstall
+	reportWithLogs bool
+
+	previousVersion  *PotatocordBackup
+	retainedVersions []PotatocordBackup
+
+	potatocordCorruption error
+
+	troubleshootInstall *DiscordInstall
+	troubleshootResults []TroubleshootResult
+	appliedFixes        bool
+	// Whether checking or fixing is running in the background
+	troubleshootBusy bool
 
 	win *g.MasterWindow
 )
@@ -59,13 +104,38 @@ func init() {
 }
 
 func main() {
+	RunElevatedHelper()
+	if TargetUserError != nil {
+		Log.Error(TargetUserError)
+		os.Exit(1)
+	}
+	if BaseDirError != nil {
+		Log.Error(BaseDirError)
+		os.Exit(1)
+	}
+	CheckForSelfUpdate()
+	// Opening a window would fail, e.g. over SSH, so offer the basics in the terminal instead
+	if !HasDisplay() && isTerminal(os.Stdin) {
+		LogLevel = LevelInfo
+		InitGithubDownloader()
+		ok := RunSimpleMenu()
+		LogChanges()
+		os.Exit(Ternary(ok, 0, 1))
+	}
 	InitGithubDownloader()
-	discords = FindDiscords()
-
-	customChoiceIdx = len(discords)
+	rescanDiscords()
+	if i := SliceIndexFunc(discords, func(d any) bool { return d.(*DiscordInstall).branch == GetDefaultBranch() }); i != -1 {
+		radioIdx = i
+	}
+	previousVersion = ReadManifest().LatestBackup()
+	if !Settings.DeclinedVencordMigration {
+		vencordMigration = FindVencordMigration()
+	}
 
 	go func() {
-		<-GithubDoneChan
+		WaitForGithub()
+		mirrorHints = PendingMirrorHints
+		_, potatocordCorruption = CheckPotatocordIntegrity()
 		g.Update()
 	}()
 
@@ -74,7 +144,37 @@ func main() {
 		g.Update()
 	}()
 
+	go WatchDiscordInstalls(func() {
+		found := FindDiscords()
+		runOnUiThread(func() {
+			setDiscords(found)
+		})
+	})
+
+	go WatchDiscordUpdates(func(di *DiscordInstall) {
+		mode := GetRepatchMode()
+		if mode == RepatchNever {
+			return
+		}
+		var err error
+		if mode == RepatchAlways {
+			err = RepatchAfterUpdate(di)
+		}
+		found := FindDiscords()
+		runOnUiThread(func() {
+			if mode == RepatchAsk || err != nil {
+				updatedInstall = di
+				repatchErr = err
+				showRepatchPrompt = true
+			}
+			setDiscords(found)
+		})
+	})
+
 	win = g.NewMasterWindow("Potatocord Installer", 1200, 800, 0)
+	ensureFonts()
+	initTheme()
+	initScale()
 
 	icon, _, err := image.Decode(bytes.NewReader(iconBytes))
 	if err != nil {
@@ -100,6 +200,31 @@ func (w *CondWidget) Build() {
 	}
 }
 
+func rescanDiscords() {
+	setDiscords(FindDiscords())
+}
+
+// setDiscords shows the installs found, keeping the selected one selected. Must be called on the ui thread
+func setDiscords(found []any) {
+	var selected string
+	if radioIdx < len(discords) {
+		selected = discords[radioIdx].(*DiscordInstall).path
+	}
+
+	discords = found
+	customChoiceIdx = len(discords)
+	if radioIdx > customChoiceIdx {
+		radioIdx = customChoiceIdx
+	}
+
+	// Keep the selection if installs were added or removed before it
+	for i, d := range discords {
+		if d.(*DiscordInstall).path == selected {
+			radioIdx = i
+		}
+	}
+}
+
 func getChosenInstall() *DiscordInstall {
 	var choice *DiscordInstall
 	if radioIdx == customChoiceIdx {
@@ -119,23 +244,90 @@ func InstallLatestBuilds() (err error) {
 	}
 
 	err = installLatestBuilds()
-	if err != nil {
-		ShowModal("Uh Oh!", "Failed to install the latest Potatocord builds from GitHub:\n"+err.Error())
+	backup := ReadManifest().LatestBackup()
+	runOnUiThread(func() {
+		previousVersion = backup
+	})
+	// Without a window, e.g. in the terminal menu, the caller prints the error
+	if err != nil && win != nil {
+		ShowModal(T("Uh Oh!"), T("Failed to install the latest Potatocord builds from GitHub:\n%s", localizeErr(err)))
+		err = withClass(ErrAlreadyShown, err)
 	}
 	return
 }
 
+// whenClosed runs action right away if the install isn't running, otherwise it asks to close Discord first
+func whenClosed(di *DiscordInstall, action func()) {
+	// Also when run from the popup, once Discord was closed
+	run := func() {
+		action()
+		LogChanges()
+	}
+	if !di.IsRunning() {
+		run()
+		return
+	}
+
+	runningInstall = di
+	runningAction = run
+	g.OpenPopup("#discord-running")
+}
+
+// withoutConflicts runs action right away if there are no other client mods in the install, otherwise it asks
+// whether to disable them first
+func withoutConflicts(di *DiscordInstall, action func()) {
+	mods := FindConflictingMods(di)
+	if len(mods) == 0 {
+		action()
+		return
+	}
+
+	conflictInstall = di
+	conflictingMods = mods
+	conflictAction = action
+	g.OpenPopup("#conflicting-mods")
+}
+
+// useChosenScope switches to the scope the install should be modified in and shows why if that isn't possible
+func useChosenScope(di *DiscordInstall) bool {
+	if err := UseScopeFor(di, Ternary(installForAllUsers, ScopeSystem, "")); err != nil {
+		ShowModal(T("Can't install for all users"), localizeErr(err))
+		return false
+	}
+	return true
+}
+
 func handlePatch() {
 	choice := getChosenInstall()
-	if choice != nil {
-		choice.Patch()
+	if choice != nil && useChosenScope(choice) {
+		withoutConflicts(choice, func() {
+			whenClosed(choice, choice.Patch)
+		})
+	}
+}
+
+func handleRepair() {
+	choice := getChosenInstall()
+	if choice == nil || CheckScuffedInstall() || !useChosenScope(choice) {
+		return
 	}
+	withoutConflicts(choice, func() {
+		whenClosed(choice, func() {
+			err := choice.Repair()
+			previousVersion = ReadManifest().LatestBackup()
+			if err != nil {
+				handleErr(choice, err, "repair")
+			} else {
+				onPatched(choice)
+			}
+		})
+	})
 }
 
 func handleUnpatch() {
 	choice := getChosenInstall()
-	if choice != nil {
-		choice.Unpatch()
+	if choice != nil && useChosenScope(choice) {
+		whenClosed(choice, choice.Unpatch)
 	}
 }
 
@@ -170,20 +362,32 @@ func handleOpenAsarConfirmed() {
 }
 
 func handleErr(di *DiscordInstall, err error, action string) {
+	if errors.Is(err, ErrAlreadyShown) {
+		return
+	}
+	if errors.Is(err, ErrSnapReadOnly) {
+		snapInstall = di
+		g.OpenPopup("#snap-migrate")
+		return
+	}
+
 	if errors.Is(err, os.ErrPermission) {
 		switch runtime.GOOS {
 		case "windows":
-			err = errors.New("Permission denied. Make sure your Discord is fully closed (from the tray)!")
+			err = errors.New(T("Permission denied. Make sure your Discord is fully closed (from the tray)!"))
 		case "darwin":
 			// FIXME: This text is not selectable which is a bit mehhh
-			command := "sudo chown -R \"${USER}:wheel\" " + di.path
-			err = errors.New("Permission denied. Please grant the installer Full Disk Access in the system settings (privacy & security page).\n\nIf that also doesn't work, try running the following command in your terminal:\n" + command)
+			command := "sudo chown -R \"${USER}:wheel\" " + shellQuote(di.path)
+			err = errors.New(T("Permission denied. Please grant the installer Full Disk Access in the system settings (privacy & security page).\n\nIf that also doesn't work, try running the following command in your terminal:\n%s", command))
 		default:
-			err = errors.New("Permission denied. Maybe try running me as Administrator/Root?")
+			err = errors.New(T("Permission denied. Maybe try running me as Administrator/Root?"))
 		}
 	}
 
-	ShowModal("Failed to "+action+" this Install", err.Error())
+	ShowModal(T("Failed to "+action+" this Install"), localizeErr(err))
+	if action == "patch" || action == "repair" {
+		reportInstall = di
+	}
 }
 
 func HandleScuffedInstall() {
@@ -196,6 +400,16 @@ func (di *DiscordInstall) Patch() {
 	}
 	if err := di.patch(); err != nil {
 		handleErr(di, err, "patch")
+	} else {
+		onPatched(di)
+	}
+}
+
+// onPatched offers restarting Discord if it is still running the previous build
+func onPatched(di *DiscordInstall) {
+	if di.IsRunning() {
+		restartInstall = di
+		g.OpenPopup("#restart-discord")
 	} else {
 		g.OpenPopup("#patched")
 	}
@@ -274,10 +488,10 @@ func makeRadioOnChange(i int) func() {
 
 func Tooltip(label string) g.Widget {
 	return g.Style().
-		SetStyle(g.StyleVarWindowPadding, 10, 8).
-		SetStyleFloat(g.StyleVarWindowRounding, 8).
+		SetStyle(g.StyleVarWindowPadding, Scaled(10), Scaled(8)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(8)).
 		To(
-			g.Tooltip(label),
+			g.Tooltip(T(label)),
 		)
 }
 
@@ -288,53 +502,69 @@ func InfoModal(id, title, description string) g.Widget {
 func RawInfoModal(id, title, description string, isOpenAsar bool) g.Widget {
 	isDynamic := strings.HasPrefix(id, "#modal") && !strings.Contains(description, "\n")
 	return g.Style().
-		SetStyle(g.StyleVarWindowPadding, 30, 30).
-		SetStyleFloat(g.StyleVarWindowRounding, 12).
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
 		To(
 			g.PopupModal(id).
 				Flags(g.WindowFlagsNoTitleBar | Ternary(isDynamic, g.WindowFlagsAlwaysAutoResize, 0)).
 				Layout(
 					g.Align(g.AlignCenter).To(
-						g.Style().SetFontSize(30).To(
+						FontSize(30).To(
 							g.Label(title),
 						),
-						g.Style().SetFontSize(20).To(
+						FontSize(20).To(
 							g.Label(description).Wrapped(isDynamic),
 						),
 						&CondWidget{id == "#scuffed-install", func() g.Widget {
 							return g.Column(
-								g.Dummy(0, 10),
-								g.Button("Take me there!").OnClick(func() {
+								g.Dummy(0, Scaled(10)),
+								g.Button(T("Take me there!")).OnClick(func() {
 									// this issue only exists on windows so using Windows specific path is oki
 									username := os.Getenv("USERNAME")
 									programData := os.Getenv("PROGRAMDATA")
 									g.OpenURL("file://" + path.Join(programData, username))
-								}).Size(200, 30),
+								}).Size(Scaled(200), Scaled(30)),
+							)
+						}, nil},
+						&CondWidget{strings.HasPrefix(id, "#modal") && reportInstall != nil, func() g.Widget {
+							return g.Column(
+								g.Dummy(0, Scaled(10)),
+								g.Checkbox(T("Include Discord's logs (they may contain personal information)"), &reportWithLogs),
+								g.Button(T("Create diagnostics report")).OnClick(func() {
+									di := reportInstall
+									g.CloseCurrentPopup()
+									out, err := CollectDiagnostics(di, reportWithLogs)
+									if err != nil {
+										ShowModal(T("Failed to create report"), localizeErr(err))
+									} else {
+										ShowModal(T("Report created"), T("Please attach the following file when reporting this issue:\n%s", out))
+									}
+								}).Size(Scaled(250), Scaled(30)),
 							)
 						}, nil},
-						g.Dummy(0, 20),
+						g.Dummy(0, Scaled(20)),
 						&CondWidget{isOpenAsar,
 							func() g.Widget {
 								return g.Row(
-									g.Button("Accept").
+									g.Button(T("Accept")).
 										OnClick(func() {
 											acceptedOpenAsar = true
 											g.CloseCurrentPopup()
 										}).
-										Size(100, 30),
-									g.Button("Cancel").
+										Size(Scaled(100), Scaled(30)),
+									g.Button(T("Cancel")).
 										OnClick(func() {
 											g.CloseCurrentPopup()
 										}).
-										Size(100, 30),
+										Size(Scaled(100), Scaled(30)),
 								)
 							},
 							func() g.Widget {
-								return g.Button("Ok").
+								return g.Button(T("Ok")).
 									OnClick(func() {
 										g.CloseCurrentPopup()
 									}).
-									Size(100, 30)
+									Size(Scaled(100), Scaled(30))
 							},
 						},
 					),
@@ -344,27 +574,27 @@ func RawInfoModal(id, title, description string, isOpenAsar bool) g.Widget {
 
 func UpdateModal() g.Widget {
 	return g.Style().
-		SetStyle(g.StyleVarWindowPadding, 30, 30).
-		SetStyleFloat(g.StyleVarWindowRounding, 12).
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
 		To(
 			g.PopupModal("#update-prompt").
 				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
 				Layout(
 					g.Align(g.AlignCenter).To(
-						g.Style().SetFontSize(30).To(
-							g.Label("Your Installer is outdated!"),
+						FontSize(30).To(
+							g.Label(T("Your Installer is outdated!")),
 						),
-						g.Style().SetFontSize(20).To(
-							g.Label(
+						FontSize(20).To(
+							g.Label(T(
 								"Would you like to update now?\n\n"+
 									"Once you press Update Now, the new installer will automatically be downloaded.\n"+
 									"The installer will temporarily seem unresponsive. Just wait!\n"+
 									"Once the update is done, the Installer will automatically reopen.\n\n"+
 									"On MacOs, Auto updates are not supported, so it will instead open in browser.",
-							),
+							)),
 						),
 						g.Row(
-							g.Button("Update Now").
+							g.Button(T("Update Now")).
 								OnClick(func() {
 									if runtime.GOOS == "darwin" {
 										g.CloseCurrentPopup()
@@ -376,223 +606,1233 @@ func UpdateModal() g.Widget {
 									g.CloseCurrentPopup()
 
 									if err != nil {
-										ShowModal("Failed to update self!", err.Error())
+										ShowModal(T("Failed to update self!"), localizeErr(err))
 									} else {
 										if err = RelaunchSelf(); err != nil {
-											ShowModal("Failed to restart self! Please do it manually.", err.Error())
+											ShowModal(T("Failed to restart self! Please do it manually."), localizeErr(err))
 										}
 									}
 								}).
-								Size(100, 30),
-							g.Button("Later").
+								Size(Scaled(100), Scaled(30)),
+							g.Button(T("Later")).
 								OnClick(func() {
 									g.CloseCurrentPopup()
 								}).
-								Size(100, 30),
+								Size(Scaled(100), Scaled(30)),
 						),
 					),
 				),
 		)
 }
 
-func ShowModal(title, desc string) {
-	modalTitle = title
-	modalMessage = desc
-	modalId++
-	g.OpenPopup("#modal" + strconv.Itoa(modalId))
-}
+func MirrorHintsModal() g.Widget {
+	mirrors := strings.Join(SliceMap(mirrorHints, func(h MirrorHint) string {
+		return "  " + h.Name + " (" + h.Url + ")"
+	}), "\n")
 
-func renderInstaller() g.Widget {
-	candidates := makeAutoComplete()
-	wi, _ := win.GetSize()
-	w := float32(wi) - 96
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#mirror-hints").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Mirrors for your region")),
+						),
+						FontSize(20).To(
+							g.Label(T(
+								"The following mirrors are suggested for your region:\n\n%s\n\n"+
+									"They will be used in case downloading from GitHub is slow or fails.\n"+
+									"Would you like to use them?",
+								mirrors,
+							)),
+						),
+						g.Row(
+							g.Button(T("Use Mirrors")).
+								OnClick(func() {
+									AcceptMirrorHints()
+									mirrorHints = nil
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(120), Scaled(30)),
+							g.Button(T("No Thanks")).
+								OnClick(func() {
+									DeclineMirrorHints()
+									mirrorHints = nil
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(120), Scaled(30)),
+						),
+					),
+				),
+		)
+}
 
-	var currentDiscord *DiscordInstall
-	if radioIdx != customChoiceIdx {
-		currentDiscord = discords[radioIdx].(*DiscordInstall)
-	}
-	var isOpenAsar = currentDiscord != nil && currentDiscord.IsOpenAsar()
+func SnapMigrateModal() g.Widget {
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#snap-migrate").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Discord from Snap")),
+						),
+						FontSize(20).To(
+							g.Label(T(ErrSnapReadOnly.Error())+"\n\n"+
+								T("This installs the Flatpak version of Discord and copies your data over.\n"+
+									"The snap is kept, you can remove it afterwards. This may take a while.")),
+						),
+						g.Row(
+							g.Button(T("Migrate")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+									go migrateSnap(snapInstall)
+								}).
+								Size(Scaled(100), Scaled(30)),
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(100), Scaled(30)),
+						),
+					),
+				),
+		)
+}
 
-	if CanUpdateSelf() && !showedUpdatePrompt {
-		showedUpdatePrompt = true
-		g.OpenPopup("#update-prompt")
-	}
+// migrateSnap moves the snap to the Flatpak in the background, then patches the Flatpak
+func migrateSnap(snap *DiscordInstall) {
+	showProgress(T("Migrating Discord to the Flatpak. This may take a while..."))
+	flatpak, err := MigrateSnapToFlatpak(snap)
+	found := FindDiscords()
+	runOnUiThread(func() {
+		progressMessage = ""
+		setDiscords(found)
+		if err != nil {
+			ShowModal(T("Failed to migrate to the Flatpak"), localizeErr(err))
+		} else {
+			flatpak.Patch()
+		}
+	})
+}
 
-	layout := g.Layout{
-		g.Dummy(0, 20),
-		g.Separator(),
-		g.Dummy(0, 5),
+func DiscordRunningModal() g.Widget {
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#discord-running").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Discord is running")),
+						),
+						FontSize(20).To(
+							g.Label(T("Discord has to be closed, otherwise the changes can't be applied\n"+
+								"or won't take effect until you fully close and restart it.")),
+							g.Dummy(0, Scaled(10)),
+							g.Checkbox(T("Start Discord again afterwards"), &relaunchDiscord),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Button(T("Close Discord")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
 
-		g.Style().SetFontSize(20).To(
-			renderErrorCard(
-				DiscordYellow,
-				"**Github** and **potatocord.dev** are the only official places to get Potatocord. Any other site claiming to be us is malicious.\n"+
-					"If you downloaded from any other source, you should delete / uninstall everything immediately, run a malware scan and change your Discord password.",
-				90,
-			),
-		),
+									di := runningInstall
+									exe, err := di.CloseDiscord()
+									if err != nil {
+										ShowModal(T("Failed to close Discord"), localizeErr(err))
+										return
+									}
+									runningAction()
+									if relaunchDiscord && exe != "" {
+										if err = di.RelaunchDiscord(exe); err != nil {
+											Log.Warn("Failed to start Discord:", err)
+										}
+									}
+								}).
+								Size(Scaled(130), Scaled(30)),
+							&CondWidget{runtime.GOOS != "windows", func() g.Widget {
+								return g.Button(T("Continue Anyway")).
+									OnClick(func() {
+										g.CloseCurrentPopup()
+										runningAction()
+									}).
+									Size(Scaled(130), Scaled(30))
+							}, nil},
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(130), Scaled(30)),
+						),
+					),
+				),
+		)
+}
 
-		g.Dummy(0, 5),
+func handleMigrateVencord() {
+	m := vencordMigration
+	vencordMigration = nil
 
-		g.Style().SetFontSize(30).To(
-			g.Label("Please select an install to patch"),
-		),
+	exes := make([]string, len(m.Installs))
+	for i, di := range m.Installs {
+		exe, err := di.CloseDiscord()
+		if err != nil {
+			ShowModal(T("Failed to close Discord"), localizeErr(err))
+			return
+		}
+		exes[i] = exe
+	}
 
-		&CondWidget{len(discords) == 0, func() g.Widget {
-			s := "No Discord installs found. You first need to install Discord."
-			if runtime.GOOS == "linux" {
-				s += " snap is not supported."
+	err := m.Migrate()
+	for i, di := range m.Installs {
+		if exes[i] != "" {
+			if err := di.RelaunchDiscord(exes[i]); err != nil {
+				Log.Warn("Failed to start Discord:", err)
 			}
-			return g.Label(s)
-		}, nil},
-
-		g.Style().SetFontSize(20).To(
-			g.RangeBuilder("Discords", discords, func(i int, v any) g.Widget {
-				d := v.(*DiscordInstall)
-				//goland:noinspection GoDeprecation
-				text := strings.Title(d.branch) + " - " + d.path
-				if d.isPatched {
-					text += " [PATCHED]"
-				}
-				return g.RadioButton(text, radioIdx == i).
-					OnChange(makeRadioOnChange(i))
-			}),
+		}
+	}
+	rescanDiscords()
 
-			g.RadioButton("Custom Install Location", radioIdx == customChoiceIdx).
-				OnChange(makeRadioOnChange(customChoiceIdx)),
-		),
+	if err != nil {
+		ShowModal(T("Failed to migrate from Vencord"), localizeErr(err))
+	} else {
+		ShowModal(T("Successfully migrated!"), T("Vencord was replaced with Potatocord.\nIf Discord is still open, restart it to load Potatocord."))
+	}
+}
 
-		g.Dummy(0, 5),
-		g.Style().
-			SetStyle(g.StyleVarFramePadding, 16, 16).
-			SetFontSize(20).
-			To(
-				g.InputText(&customDir).Hint("The custom location").
-					Size(w - 16).
-					Flags(g.InputTextFlagsCallbackCompletion).
-					OnChange(onCustomInputChanged).
-					// this library has its own autocomplete but it's broken
-					Callback(
-						func(data imgui.InputTextCallbackData) int32 {
-							if len(candidates) == 0 {
-								return 0
-							}
-							// just wrap around
-							if autoCompleteIdx >= len(candidates) {
-								autoCompleteIdx = 0
-							}
+func setRepatchMode(mode RepatchMode) {
+	Settings.RepatchAfterUpdate = mode
+	if err := Settings.Save(); err != nil {
+		Log.Warn("Failed to save installer settings:", err)
+	}
+}
 
-							// used by change handler
-							didAutoComplete = true
+func handleRepatch() {
+	g.CloseCurrentPopup()
+	di := updatedInstall
+	updatedInstall = nil
+	if err := RepatchAfterUpdate(di); err != nil {
+		handleErr(di, err, "patch")
+	} else {
+		rescanDiscords()
+		g.OpenPopup("#patched")
+	}
+}
 
-							start := len(customDir)
-							// Delete previous auto complete
-							if lastAutoComplete != "" {
-								start -= len(lastAutoComplete)
-								data.DeleteBytes(start, len(lastAutoComplete))
-							} else if autoCompleteFile != "" { // delete partial input
-								start -= len(autoCompleteFile)
-								data.DeleteBytes(start, len(autoCompleteFile))
-							}
+func DiscordUpdatedModal() g.Widget {
+	if updatedInstall == nil {
+		return g.Dummy(0, 0)
+	}
 
-							// Insert auto complete
-							lastAutoComplete = candidates[autoCompleteIdx].(string)
-							data.InsertBytes(start, []byte(lastAutoComplete))
-							autoCompleteIdx++
+	description := T("Discord %s updated, which removed Potatocord.", updatedInstall.branch) + "\n"
+	if repatchErr != nil {
+		description += T("Re-applying it automatically failed:") + " " + localizeErr(repatchErr) + "\n"
+	}
+	description += T("Would you like to re-apply it now? Discord will be restarted if it is running.")
 
-							return 0
-						},
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#discord-updated").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Discord updated")),
+						),
+						FontSize(20).To(
+							g.Label(description),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Button(T("Re-apply")).
+								OnClick(handleRepatch).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Always Re-apply")).
+								OnClick(func() {
+									setRepatchMode(RepatchAlways)
+									handleRepatch()
+								}).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Not Now")).
+								OnClick(func() {
+									updatedInstall = nil
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Never")).
+								OnClick(func() {
+									setRepatchMode(RepatchNever)
+									updatedInstall = nil
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+						),
 					),
-			),
-		g.RangeBuilder("AutoComplete", candidates, func(i int, v any) g.Widget {
-			dir := v.(string)
-			return g.Label(dir)
-		}),
+				),
+		)
+}
 
-		g.Dummy(0, 20),
+func MigrateVencordModal() g.Widget {
+	if vencordMigration == nil {
+		return g.Dummy(0, 0)
+	}
 
-		g.Style().SetFontSize(20).To(
-			g.Row(
-				g.Style().
-					SetColor(g.StyleColorButton, DiscordGreen).
-					SetDisabled(GithubError != nil).
-					To(
-						g.Button("Install").
+	installs := strings.Join(SliceMap(vencordMigration.Installs, func(di *DiscordInstall) string {
+		return "  " + di.DisplayName() + " (" + di.path + ")"
+	}), "\n")
+	settings := T("Your Vencord settings were not found, so Potatocord will start with default settings.")
+	if vencordMigration.DataDir != "" {
+		settings = T("Your settings, QuickCSS and themes will be carried over from\n%s.", vencordMigration.DataDir)
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#migrate-vencord").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Vencord is installed")),
+						),
+						FontSize(20).To(
+							g.Label(
+								T("Vencord is injected into:")+"\n\n"+installs+"\n\n"+
+									T("Would you like to replace it with Potatocord?")+"\n"+settings+"\n\n"+
+									T("Vencord's files are kept, so you can go back. Running Discord will be restarted."),
+							),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Button(T("Migrate")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+									handleMigrateVencord()
+								}).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Not Now")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Don't Ask Again")).
+								OnClick(func() {
+									vencordMigration = nil
+									Settings.DeclinedVencordMigration = true
+									if err := Settings.Save(); err != nil {
+										Log.Warn("Failed to save installer settings:", err)
+									}
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func ConflictingModsModal() g.Widget {
+	var lines []string
+	canDisable := true
+	for _, mod := range conflictingMods {
+		lines = append(lines, "- "+mod.DisplayName()+" ("+mod.File+")")
+		canDisable = canDisable && mod.disable != nil
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#conflicting-mods").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Other Client Mods Found")),
+						),
+						FontSize(20).To(
+							g.Label(T("This Discord install was modified by other client mods:")+"\n\n"+
+								strings.Join(lines, "\n")+"\n\n"+
+								T("Using them together with Potatocord will likely break Discord.")+"\n"+
+								Ternary(canDisable,
+									T("Disabling them keeps the modified files with the suffix %s.", disabledModSuffix),
+									T("Some of them can't be disabled automatically. Uninstall them or reinstall Discord."))),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Style().
+								SetDisabled(!canDisable).
+								To(
+									g.Button(T("Disable & Continue")).
+										OnClick(func() {
+											g.CloseCurrentPopup()
+											PreparePatch(conflictInstall)
+											if err := DisableConflictingMods(conflictingMods); err != nil {
+												ShowModal(T("Failed to disable other client mods"), localizeErr(err))
+												return
+											}
+											conflictAction()
+										}).
+										Size(Scaled(150), Scaled(30)),
+								),
+							g.Button(T("Continue Anyway")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+									conflictAction()
+								}).
+								Size(Scaled(150), Scaled(30)),
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func RestartDiscordModal() g.Widget {
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#restart-discord").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Successfully Patched")),
+						),
+						FontSize(20).To(
+							g.Label(T("Discord is still running the previous version.\n"+
+								"Restart it now to load Potatocord?")),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Button(T("Restart Discord")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+									if err := restartInstall.RestartDiscord(); err != nil {
+										ShowModal(T("Failed to restart Discord"), localizeErr(err))
+									}
+								}).
+								Size(Scaled(130), Scaled(30)),
+							g.Button(T("Later")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(130), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func InstallDirModal() g.Widget {
+	apply := func(dir string) {
+		g.CloseCurrentPopup()
+		err := SetInstallDir(dir)
+		rescanDiscords()
+		if err != nil {
+			ShowModal(T("Failed to change the install location"), localizeErr(err))
+		} else {
+			ShowModal(T("Install Location Changed"), T("Potatocord is now installed to %s.\n"+
+				"If Discord is open, fully close it and start it again.", GetInstallDir()))
+		}
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#install-dir").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Install Location")),
+						),
+						FontSize(20).To(
+							g.Label(T("The folder Potatocord is installed to. Installs patched with Potatocord\n"+
+								"are updated to load it from the new location.")),
+							g.Dummy(0, Scaled(10)),
+							g.InputText(&installDirInput).Hint(path.Dir(getDefaultPotatocordFile())).Size(Scaled(500)),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Button(T("Save")).
+								OnClick(func() {
+									apply(installDirInput)
+								}).
+								Size(Scaled(130), Scaled(30)),
+							g.Button(T("Reset to Default")).
+								OnClick(func() {
+									apply("")
+								}).
+								Size(Scaled(130), Scaled(30)),
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(130), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func UninstallEverythingModal() g.Widget {
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#uninstall-everything").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Uninstall Everything?")),
+						),
+						FontSize(20).To(
+							g.Label(T(
+								"This will remove Potatocord and OpenAsar from all your Discord installs\n"+
+									"and delete the downloaded Potatocord files, returning Discord to stock.",
+							)),
+							g.Dummy(0, Scaled(10)),
+							g.Checkbox(T("Also delete Potatocord's settings and data"), &removeUserData),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Style().
+								SetColor(g.StyleColorButton, DiscordRed).
+								To(
+									g.Button(T("Uninstall")).
+										OnClick(func() {
+											g.CloseCurrentPopup()
+
+											errs := UninstallEverything(removeUserData)
+											rescanDiscords()
+											if len(errs) != 0 {
+												ShowModal(T("Failed to uninstall everything"), strings.Join(SliceMap(errs, localizeErr), "\n"))
+											} else {
+												ShowModal(T("Successfully Uninstalled"), T("If Discord is still open, fully close it first. Then start it again, it should be back to stock!"))
+											}
+										}).
+										Size(Scaled(100), Scaled(30)),
+								),
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(100), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func PatchAllModal() g.Widget {
+	installs := FindPatchableDiscords(discords)
+	var selected []*DiscordInstall
+	for _, di := range installs {
+		if patchAllSelected[di.path] {
+			selected = append(selected, di)
+		}
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#patch-all").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Patch All")),
+						),
+						FontSize(20).To(
+							g.Label(T("Select the Discord installs to patch.")),
+							g.Label(T("Running installs are closed first and started again afterwards.")),
+							g.Dummy(0, Scaled(10)),
+							g.RangeBuilder("PatchAll", SliceMap(installs, func(di *DiscordInstall) any { return di }), func(i int, v any) g.Widget {
+								di := v.(*DiscordInstall)
+								checked := patchAllSelected[di.path]
+								return g.Checkbox(di.DisplayName()+" - "+di.path+di.StatusText(), &checked).
+									OnChange(func() {
+										patchAllSelected[di.path] = checked
+									})
+							}),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Row(
+							g.Style().
+								SetColor(g.StyleColorButton, DiscordGreen).
+								SetDisabled(len(selected) == 0).
+								To(
+									g.Button(T("Patch")).
+										OnClick(func() {
+											g.CloseCurrentPopup()
+											handlePatchAll(selected)
+										}).
+										Size(Scaled(100), Scaled(30)),
+								),
+							g.Button(T("Cancel")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(100), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func ProgressModal() g.Widget {
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#progress").
+				Flags(g.WindowFlagsNoTitleBar|g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Custom(func() {
+						if progressMessage == "" {
+							g.CloseCurrentPopup()
+						}
+					}),
+					g.Align(g.AlignCenter).To(
+						FontSize(20).To(
+							g.Label(progressMessage),
+						),
+					),
+				),
+		)
+}
+
+// showProgress shows what a goroutine is doing in the background. The goroutine clears progressMessage on the ui thread
+// once it's done
+func showProgress(message string) {
+	runOnUiThread(func() {
+		if progressMessage == "" {
+			g.OpenPopup("#progress")
+		}
+		progressMessage = message
+	})
+}
+
+func handlePatchAll(installs []*DiscordInstall) {
+	if CheckScuffedInstall() {
+		return
+	}
+	go patchAll(installs)
+}
+
+// patchAll patches the installs in the background, closing the running ones first and starting them again afterwards
+func patchAll(installs []*DiscordInstall) {
+	done := 0
+	results := RunAll(installs, "patch", "", func(di *DiscordInstall) error {
+		done++
+		showProgress(T("Patching Discord %s (%d of %d)...", di.branch, done, len(installs)))
+
+		exe, err := di.CloseDiscord()
+		if err != nil {
+			return err
+		}
+		err = di.patch()
+		if exe != "" {
+			if relaunchErr := di.RelaunchDiscord(exe); relaunchErr != nil {
+				Log.Warn("Failed to start Discord again:", relaunchErr)
+			}
+		}
+		return err
+	})
+	found := FindDiscords()
+
+	lines := SliceMap(results, func(r PatchResult) string {
+		if r.Error == "" {
+			return T("Patched Discord %s (%s)", r.Branch, r.Path)
+		}
+		return T("Failed to patch Discord %s (%s):", r.Branch, r.Path) + "\n    " + T(r.Error)
+	})
+	runOnUiThread(func() {
+		progressMessage = ""
+		setDiscords(found)
+		if err := FailedPatches(results); err != nil {
+			ShowModal(localizeErr(err), strings.Join(lines, "\n"))
+		} else {
+			ShowModal(T("Successfully Patched"), strings.Join(lines, "\n"))
+		}
+	})
+}
+
+func handleRollback() {
+	if backups := ReadManifest().Backups; len(backups) > 1 {
+		retainedVersions = backups
+		g.OpenPopup("#downgrade")
+		return
+	}
+
+	err := Rollback()
+	previousVersion = ReadManifest().LatestBackup()
+	if err != nil {
+		ShowModal(T("Failed to roll back"), localizeErr(err))
+	} else {
+		ShowModal(T("Successfully Rolled Back"), T("Restart Discord to use Potatocord %s.", InstalledHash))
+	}
+}
+
+func handleHeal() {
+	err := SelfHeal()
+	_, potatocordCorruption = CheckPotatocordIntegrity()
+	if err != nil {
+		ShowModal(T("Failed to heal Potatocord"), localizeErr(err))
+	} else {
+		ShowModal(T("Successfully Healed"), T("Restart Discord to use the intact Potatocord %s.", InstalledHash))
+	}
+}
+
+func handleRestoreVersion(backup PotatocordBackup) {
+	err := RestoreVersion(backup)
+	previousVersion = ReadManifest().LatestBackup()
+	if err != nil {
+		ShowModal(T("Failed to restore this version"), localizeErr(err))
+	} else {
+		ShowModal(T("Successfully Restored"), T("Restart Discord to use Potatocord %s.", InstalledHash))
+	}
+}
+
+func DowngradeModal() g.Widget {
+	var rows []g.Widget
+	for i := len(retainedVersions) - 1; i >= 0; i-- {
+		backup := retainedVersions[i]
+		rows = append(rows, g.Row(
+			g.Button(T("Restore")+"##"+backup.File).
+				OnClick(func() {
+					g.CloseCurrentPopup()
+					handleRestoreVersion(backup)
+				}).
+				Size(Scaled(100), Scaled(30)),
+			g.Label(T("%s - installed until %s", Ternary(backup.Hash != "", backup.Hash, T("Unknown version")), backup.Time.Format(time.DateTime))),
+		))
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#downgrade").
+				Flags(g.WindowFlagsNoTitleBar | g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					g.Align(g.AlignCenter).To(
+						FontSize(30).To(
+							g.Label(T("Roll Back")),
+						),
+						FontSize(20).To(
+							g.Label(T("Potatocord %s is installed. Which version would you like to go back to?", InstalledHash)),
+							g.Dummy(0, Scaled(10)),
+							g.Column(rows...),
+						),
+						g.Dummy(0, Scaled(20)),
+						g.Button(T("Cancel")).
+							OnClick(func() {
+								g.CloseCurrentPopup()
+							}).
+							Size(Scaled(100), Scaled(30)),
+					),
+				),
+		)
+}
+
+func handleTroubleshoot() {
+	choice := getChosenInstall()
+	if choice == nil {
+		return
+	}
+
+	troubleshootInstall = choice
+	troubleshootResults = nil
+	appliedFixes = false
+	troubleshootBusy = true
+	g.OpenPopup("#troubleshoot")
+	go func() {
+		results := Troubleshoot(choice)
+		runOnUiThread(func() {
+			troubleshootResults = results
+			troubleshootBusy = false
+		})
+	}()
+}
+
+// applyTroubleshootFixes applies the fixes in the background and checks again. Fixing may replace the install, e.g.
+// by reinstalling Discord, so it works on a copy of the one shown
+func applyTroubleshootFixes() {
+	install := *troubleshootInstall
+	di, results := &install, troubleshootResults
+	troubleshootBusy = true
+	go func() {
+		ApplyTroubleshootFixes(results)
+		results := Troubleshoot(di)
+		found := FindDiscords()
+		runOnUiThread(func() {
+			troubleshootInstall = di
+			troubleshootResults = results
+			appliedFixes = true
+			troubleshootBusy = false
+			setDiscords(found)
+		})
+	}()
+}
+
+func TroubleshootModal() g.Widget {
+	canFix, unresolved := false, appliedFixes
+	for _, r := range troubleshootResults {
+		if r.Problem != "" {
+			unresolved = true
+			canFix = canFix || r.Fix != nil
+		}
+	}
+	if len(troubleshootResults) != 0 && !unresolved {
+		// Nothing found, so the only thing left to do is to send a report
+		unresolved = true
+	}
+
+	resultLabels := g.Layout{}
+	for _, r := range troubleshootResults {
+		if r.Problem == "" {
+			resultLabels = append(resultLabels, g.Style().SetColor(g.StyleColorText, DiscordGreen).To(g.Label(T("OK - %s", T(r.Check)))))
+		} else {
+			resultLabels = append(resultLabels, g.Style().SetColor(g.StyleColorText, DiscordYellow).To(g.Label(T("Problem - %s: %s", T(r.Check), T(r.Problem)))))
+		}
+	}
+
+	return g.Style().
+		SetStyle(g.StyleVarWindowPadding, Scaled(30), Scaled(30)).
+		SetStyleFloat(g.StyleVarWindowRounding, Scaled(12)).
+		To(
+			g.PopupModal("#troubleshoot").
+				Flags(g.WindowFlagsNoTitleBar|g.WindowFlagsAlwaysAutoResize).
+				Layout(
+					FontSize(30).To(
+						g.Label(T("Troubleshooting")),
+					),
+					FontSize(20).To(
+						&CondWidget{troubleshootBusy, func() g.Widget {
+							return g.Label(T("Checking for problems..."))
+						}, nil},
+						resultLabels,
+						&CondWidget{appliedFixes && !troubleshootBusy, func() g.Widget {
+							return g.Label(T("Fixes were applied. Restart Discord and check if Potatocord loads now.\n" +
+								"If it still doesn't, please create a diagnostics report."))
+						}, nil},
+						g.Dummy(0, Scaled(10)),
+						&CondWidget{unresolved, func() g.Widget {
+							return g.Checkbox(T("Include Discord's logs in the report (they may contain personal information)"), &reportWithLogs)
+						}, nil},
+						g.Dummy(0, Scaled(10)),
+						g.Row(
+							g.Style().
+								SetColor(g.StyleColorButton, DiscordGreen).
+								SetDisabled(!canFix || appliedFixes || troubleshootBusy).
+								To(
+									g.Button(T("Apply Fixes")).
+										OnClick(applyTroubleshootFixes).
+										Size(Scaled(150), Scaled(30)),
+								),
+							g.Style().
+								SetDisabled(!unresolved || troubleshootBusy).
+								To(
+									g.Button(T("Create Report")).
+										OnClick(func() {
+											g.CloseCurrentPopup()
+											out, err := CollectDiagnostics(troubleshootInstall, reportWithLogs)
+											if err != nil {
+												ShowModal(T("Failed to create report"), localizeErr(err))
+											} else {
+												ShowModal(T("Report created"), T("Please attach the following file when reporting this issue:\n%s", out))
+											}
+										}).
+										Size(Scaled(150), Scaled(30)),
+								),
+							g.Button(T("Close")).
+								OnClick(func() {
+									g.CloseCurrentPopup()
+								}).
+								Size(Scaled(150), Scaled(30)),
+						),
+					),
+				),
+		)
+}
+
+func ShowModal(title, desc string) {
+	if win == nil {
+		Log.Error(title, desc)
+		return
+	}
+	if !isUiThread() {
+		runOnUiThread(func() {
+			ShowModal(title, desc)
+		})
+		return
+	}
+	reportInstall = nil
+	modalTitle = title
+	modalMessage = desc
+	modalId++
+	g.OpenPopup("#modal" + strconv.Itoa(modalId))
+}
+
+func renderInstaller() g.Widget {
+	candidates := makeAutoComplete()
+	wi, _ := win.GetSize()
+	w := float32(wi) - Scaled(96)
+
+	var currentDiscord *DiscordInstall
+	if radioIdx != customChoiceIdx {
+		currentDiscord = discords[radioIdx].(*DiscordInstall)
+	}
+	var isOpenAsar = currentDiscord != nil && currentDiscord.IsOpenAsar()
+
+	if CanUpdateSelf() && !showedUpdatePrompt {
+		showedUpdatePrompt = true
+		g.OpenPopup("#update-prompt")
+	} else if len(mirrorHints) != 0 && !showedMirrorPrompt {
+		showedMirrorPrompt = true
+		g.OpenPopup("#mirror-hints")
+	} else if showRepatchPrompt {
+		showRepatchPrompt = false
+		g.OpenPopup("#discord-updated")
+	} else if vencordMigration != nil && !showedMigrationPrompt {
+		showedMigrationPrompt = true
+		g.OpenPopup("#migrate-vencord")
+	}
+
+	layout := g.Layout{
+		g.Dummy(0, Scaled(20)),
+		g.Separator(),
+		g.Dummy(0, Scaled(5)),
+
+		FontSize(20).To(
+			renderErrorCard(
+				DiscordYellow,
+				T("**Github** and **potatocord.dev** are the only official places to get Potatocord. Any other site claiming to be us is malicious.\n"+
+					"If you downloaded from any other source, you should delete / uninstall everything immediately, run a malware scan and change your Discord password."),
+				90,
+			),
+		),
+
+		g.Dummy(0, Scaled(5)),
+
+		FontSize(30).To(
+			g.Label(T("Please select an install to patch")),
+		),
+
+		FontSize(20).To(
+			renderInstalls(),
+
+			g.RadioButton(T("Custom Install Location"), radioIdx == customChoiceIdx).
+				OnChange(makeRadioOnChange(customChoiceIdx)),
+		),
+
+		&CondWidget{len(FindPatchableDiscords(discords)) > 1, func() g.Widget {
+			return FontSize(20).To(
+				g.Style().
+					SetDisabled(GithubError != nil).
+					To(
+						g.Row(
+							g.Button(T("Patch All")).
+								OnClick(func() {
+									patchAllSelected = make(map[string]bool)
+									for _, di := range FindPatchableDiscords(discords) {
+										patchAllSelected[di.path] = true
+									}
+									g.OpenPopup("#patch-all")
+								}).
+								Size(Scaled(250), Scaled(30)),
+							Tooltip(T("Patch every Discord install listed above")),
+						),
+					),
+			)
+		}, nil},
+
+		g.Dummy(0, Scaled(5)),
+		FontSize(20).
+			SetStyle(g.StyleVarFramePadding, Scaled(16), Scaled(16)).
+			To(
+				g.InputText(&customDir).Hint(T("The custom location")).
+					Size(w - Scaled(16)).
+					Flags(g.InputTextFlagsCallbackCompletion).
+					OnChange(onCustomInputChanged).
+					// this library has its own autocomplete but it's broken
+					Callback(
+						func(data imgui.InputTextCallbackData) int32 {
+							if len(candidates) == 0 {
+								return 0
+							}
+							// just wrap around
+							if autoCompleteIdx >= len(candidates) {
+								autoCompleteIdx = 0
+							}
+
+							// used by change handler
+							didAutoComplete = true
+
+							start := len(customDir)
+							// Delete previous auto complete
+							if lastAutoComplete != "" {
+								start -= len(lastAutoComplete)
+								data.DeleteBytes(start, len(lastAutoComplete))
+							} else if autoCompleteFile != "" { // delete partial input
+								start -= len(autoCompleteFile)
+								data.DeleteBytes(start, len(autoCompleteFile))
+							}
+
+							// Insert auto complete
+							lastAutoComplete = candidates[autoCompleteIdx].(string)
+							data.InsertBytes(start, []byte(lastAutoComplete))
+							autoCompleteIdx++
+
+							return 0
+						},
+					),
+			),
+		g.RangeBuilder("AutoComplete", candidates, func(i int, v any) g.Widget {
+			dir := v.(string)
+			return g.Label(dir)
+		}),
+
+		&CondWidget{currentDiscord != nil && currentDiscord.IsMachineWide(), func() g.Widget {
+			return FontSize(20).To(
+				g.Dummy(0, Scaled(5)),
+				g.Checkbox(T("Install for all users of this computer (requires administrator rights)"), &installForAllUsers),
+			)
+		}, nil},
+
+		g.Dummy(0, Scaled(10)),
+		FontSize(20).To(
+			renderReleaseNotes(),
+		),
+
+		g.Dummy(0, Scaled(10)),
+
+		FontSize(20).To(
+			g.Row(
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordGreen).
+					SetDisabled(GithubError != nil).
+					To(
+						g.Button(T("Install")).
 							OnClick(handlePatch).
-							Size((w-40)/4, 50),
-						Tooltip("Patch the selected Discord Install"),
+							Size((w-Scaled(40))/4, Scaled(50)),
+						Tooltip(T("Patch the selected Discord Install")),
 					),
 				g.Style().
 					SetColor(g.StyleColorButton, DiscordBlue).
 					SetDisabled(GithubError != nil).
 					To(
-						g.Button("Reinstall / Repair").
-							OnClick(func() {
-								if IsDevInstall {
-									handlePatch()
-								} else {
-									err := InstallLatestBuilds()
-									if err == nil {
-										handlePatch()
-									}
-								}
-							}).
-							Size((w-40)/4, 50),
-						Tooltip("Reinstall & Update Potatocord"),
+						g.Button(T("Reinstall / Repair")).
+							OnClick(handleRepair).
+							Size((w-Scaled(40))/4, Scaled(50)),
+						Tooltip(T("Verify and reinstall Potatocord and re-apply it to the selected Discord Install")),
 					),
 				g.Style().
 					SetColor(g.StyleColorButton, DiscordRed).
 					To(
-						g.Button("Uninstall").
+						g.Button(T("Uninstall")).
 							OnClick(handleUnpatch).
-							Size((w-40)/4, 50),
-						Tooltip("Unpatch the selected Discord Install"),
+							Size((w-Scaled(40))/4, Scaled(50)),
+						Tooltip(T("Unpatch the selected Discord Install")),
 					),
 				g.Style().
 					SetColor(g.StyleColorButton, Ternary(isOpenAsar, DiscordRed, DiscordGreen)).
 					To(
-						g.Button(Ternary(isOpenAsar, "Uninstall OpenAsar", Ternary(currentDiscord != nil, "Install OpenAsar", "(Un-)Install OpenAsar"))).
+						g.Button(T(Ternary(isOpenAsar, "Uninstall OpenAsar", Ternary(currentDiscord != nil, "Install OpenAsar", "(Un-)Install OpenAsar")))).
 							OnClick(handleOpenAsar).
-							Size((w-40)/4, 50),
-						Tooltip("Manage OpenAsar"),
+							Size((w-Scaled(40))/4, Scaled(50)),
+						Tooltip(T("Manage OpenAsar")),
+					),
+			),
+		),
+
+		g.Dummy(0, Scaled(10)),
+		FontSize(20).To(
+			g.Row(
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordBlue).
+					To(
+						g.Button(T("Troubleshoot")).
+							OnClick(handleTroubleshoot).
+							Size((w-Scaled(40))/5, Scaled(40)),
+						Tooltip(T("Find and fix common reasons for Potatocord not loading")),
+					),
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordBlue).
+					SetDisabled(previousVersion == nil).
+					To(
+						g.Button(T("Roll Back")).
+							OnClick(handleRollback).
+							Size((w-Scaled(40))/5, Scaled(40)),
+						Tooltip(Ternary(previousVersion != nil, "Restore the previously installed Potatocord version", "There is no previous version to roll back to")),
+					),
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordRed).
+					To(
+						g.Button(T("Uninstall Everything")).
+							OnClick(func() {
+								g.OpenPopup("#uninstall-everything")
+							}).
+							Size((w-Scaled(40))/5, Scaled(40)),
+						Tooltip(T("Remove Potatocord and OpenAsar from all Discord installs")),
 					),
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordBlue).
+					SetDisabled(IsDevInstall).
+					To(
+						g.Button(T("Install Location")).
+							OnClick(func() {
+								installDirInput = Ternary(Settings.InstallDir != "", Settings.InstallDir, "")
+								g.OpenPopup("#install-dir")
+							}).
+							Size((w-Scaled(40))/5, Scaled(40)),
+						Tooltip(T("Change where Potatocord is installed to")),
+					),
+				g.Checkbox(T("Advanced Mode"), &Settings.AdvancedMode).
+					OnChange(func() {
+						if err := Settings.Save(); err != nil {
+							Log.Warn("Failed to save installer settings:", err)
+						}
+					}),
+				Tooltip(T("Unlock risky actions like overwriting other mods. Only enable this if you know what you are doing")),
+			),
+		),
+
+		g.Dummy(0, Scaled(10)),
+		FontSize(20).To(
+			g.Row(
+				renderThemeSelection(),
+				g.Dummy(Scaled(20), 0),
+				renderLanguageSelection(),
 			),
 		),
 
-		InfoModal("#patched", "Successfully Patched", "If Discord is still open, fully close it first.\n"+
-			"Then, start it and verify Potatocord installed successfully by looking for its category in Discord Settings"),
-		InfoModal("#unpatched", "Successfully Unpatched", "If Discord is still open, fully close it first. Then start it again, it should be back to stock!"),
-		InfoModal("#scuffed-install", "Hold On!", "You have a broken Discord Install.\n"+
+		InfoModal("#patched", T("Successfully Patched"), T("If Discord is still open, fully close it first.\n"+
+			"Then, start it and verify Potatocord installed successfully by looking for its category in Discord Settings")),
+		InfoModal("#unpatched", T("Successfully Unpatched"), T("If Discord is still open, fully close it first. Then start it again, it should be back to stock!")),
+		InfoModal("#scuffed-install", T("Hold On!"), T("You have a broken Discord Install.\n"+
 			"Sometimes Discord decides to install to the wrong location for some reason!\n"+
 			"You need to fix this before patching, otherwise Potatocord will likely not work.\n\n"+
 			"Use the below button to jump there and delete any folder called Discord or Squirrel.\n"+
 			"If the folder is now empty, feel free to go back a step and delete that folder too.\n"+
-			"Then see if Discord still starts. If not, reinstall it"),
-		RawInfoModal("#openasar-confirm", "OpenAsar", "OpenAsar is an open-source alternative of Discord desktop's app.asar.\n"+
+			"Then see if Discord still starts. If not, reinstall it")),
+		RawInfoModal("#openasar-confirm", "OpenAsar", T("OpenAsar is an open-source alternative of Discord desktop's app.asar.\n"+
 			"Potatocord is in no way affiliated with OpenAsar.\n"+
 			"You're installing OpenAsar at your own risk. If you run into issues with OpenAsar,\n"+
 			"no support will be provided, join the OpenAsar Server instead!\n\n"+
-			"To install OpenAsar, press Accept and click 'Install OpenAsar' again.", true),
-		InfoModal("#openasar-patched", "Successfully Installed OpenAsar", "If Discord is still open, fully close it first. Then start it again and verify OpenAsar installed successfully!"),
-		InfoModal("#openasar-unpatched", "Successfully Uninstalled OpenAsar", "If Discord is still open, fully close it first. Then start it again and it should be back to stock!"),
-		InfoModal("#invalid-custom-location", "Invalid Location", "The specified location is not a valid Discord install.\nMake sure you select the base folder.\n\nHint: Discord snap is not supported. use flatpak or .deb"),
+			"To install OpenAsar, press Accept and click 'Install OpenAsar' again."), true),
+		InfoModal("#openasar-patched", T("Successfully Installed OpenAsar"), T("If Discord is still open, fully close it first. Then start it again and verify OpenAsar installed successfully!")),
+		InfoModal("#openasar-unpatched", T("Successfully Uninstalled OpenAsar"), T("If Discord is still open, fully close it first. Then start it again and it should be back to stock!")),
+		InfoModal("#invalid-custom-location", T("Invalid Location"), T("The specified location is not a valid Discord install.\nMake sure you select the base folder.")),
 		InfoModal("#modal"+strconv.Itoa(modalId), modalTitle, modalMessage),
 
 		UpdateModal(),
+		MirrorHintsModal(),
+		SnapMigrateModal(),
+		DiscordRunningModal(),
+		RestartDiscordModal(),
+		ConflictingModsModal(),
+		InstallDirModal(),
+		MigrateVencordModal(),
+		DiscordUpdatedModal(),
+		UninstallEverythingModal(),
+		PatchAllModal(),
+		ProgressModal(),
+		DowngradeModal(),
+		TroubleshootModal(),
 	}
 
 	return layout
 }
 
+func getSupportedDiscordsText() string {
+	switch runtime.GOOS {
+	case "windows":
+		return T("Discord Stable, PTB, Canary and Development, installed with Discord's official installer.\n" +
+			"Discord from the Microsoft Store is not supported.")
+	case "darwin":
+		return T("Discord, Discord PTB, Discord Canary and Discord Development, installed to /Applications or ~/Applications.")
+	default:
+		return T("Discord Stable, PTB, Canary and Development from Discord's .deb or .tar.gz, your distribution's package or Flatpak.\n" +
+			"Discord from snap can't be patched, but the installer can migrate it to the Flatpak for you.")
+	}
+}
+
+func renderOnboarding() g.Widget {
+	return g.Layout{
+		g.Dummy(0, Scaled(20)),
+		g.Separator(),
+		g.Dummy(0, Scaled(5)),
+
+		FontSize(30).To(
+			g.Label(T("No Discord installs found")),
+		),
+		FontSize(20).To(
+			g.Label(T("Potatocord is a mod for the Discord Desktop app, so you first need to install Discord.")).Wrapped(true),
+			g.Dummy(0, Scaled(10)),
+			g.Label(T("Supported Discord versions:")),
+			g.Label(getSupportedDiscordsText()).Wrapped(true),
+			g.Dummy(0, Scaled(10)),
+			g.Label(T("Once you've installed Discord and started it at least once, press Re-scan.")).Wrapped(true),
+			g.Dummy(0, Scaled(20)),
+			g.Row(
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordBlue).
+					To(
+						g.Button(T("Download Discord")).
+							OnClick(func() {
+								g.OpenURL(DiscordDownloadUrl)
+							}).
+							Size(Scaled(250), Scaled(50)),
+					),
+				g.Style().
+					SetColor(g.StyleColorButton, DiscordGreen).
+					To(
+						g.Button(T("Re-scan")).
+							OnClick(rescanDiscords).
+							Size(Scaled(250), Scaled(50)),
+					),
+				g.Button(T("Use a custom location")).
+					OnClick(func() {
+						skippedOnboarding = true
+					}).
+					Size(Scaled(250), Scaled(50)),
+			),
+		),
+	}
+}
+
 func renderErrorCard(col color.Color, message string, height float32) g.Widget {
 	return g.Style().
 		SetColor(g.StyleColorChildBg, col).
 		SetStyleFloat(g.StyleVarAlpha, 0.9).
-		SetStyle(g.StyleVarWindowPadding, 10, 10).
-		SetStyleFloat(g.StyleVarChildRounding, 5).
+		SetStyle(g.StyleVarWindowPadding, Scaled(10), Scaled(10)).
+		SetStyleFloat(g.StyleVarChildRounding, Scaled(5)).
 		To(
 			g.Child().
-				Size(g.Auto, height).
+				Size(g.Auto, Scaled(height)).
 				Layout(
 					g.Row(
 						g.Style().SetColor(g.StyleColorText, color.Black).To(
@@ -604,7 +1844,12 @@ func renderErrorCard(col color.Color, message string, height float32) g.Widget {
 }
 
 func loop() {
-	g.PushWindowPadding(48, 48)
+	updateScale()
+	applyTheme()
+	g.PushWindowPadding(Scaled(48), Scaled(48))
+	if pushScaledFont() {
+		defer g.PopFont()
+	}
 
 	g.SingleWindow().
 		RegisterKeyboardShortcuts(
@@ -620,45 +1865,57 @@ func loop() {
 			}},
 		).
 		Layout(
+			g.Custom(runUiQueue),
 			g.Align(g.AlignCenter).To(
-				g.Style().SetFontSize(40).To(
+				FontSize(40).To(
 					g.Label("Potatocord Installer"),
 				),
 			),
 
-			g.Dummy(0, 20),
-			g.Style().SetFontSize(20).To(
+			g.Dummy(0, Scaled(20)),
+			FontSize(20).To(
 				g.Row(
-					g.Label(Ternary(IsDevInstall, "Dev Install: ", "Potatocord will be downloaded to: ")+PotatocordDirectory),
+					g.Label(T(Ternary(IsDevInstall, "Dev Install: %s", "Potatocord will be downloaded to: %s"), PotatocordDirectory)),
 					g.Style().
 						SetColor(g.StyleColorButton, DiscordBlue).
-						SetStyle(g.StyleVarFramePadding, 4, 4).
+						SetStyle(g.StyleVarFramePadding, Scaled(4), Scaled(4)).
 						To(
-							g.Button("Open Directory").OnClick(func() {
+							g.Button(T("Open Directory")).OnClick(func() {
 								g.OpenURL("file://" + path.Dir(PotatocordDirectory))
 							}),
 						),
 				),
 				&CondWidget{!IsDevInstall, func() g.Widget {
-					return g.Label("To customise this location, set the environment variable 'POTATOCORD_USER_DATA_DIR' and restart me").Wrapped(true)
+					return g.Label(T("To customise this location, set the environment variable 'POTATOCORD_USER_DATA_DIR' and restart me")).Wrapped(true)
+				}, nil},
+				g.Dummy(0, Scaled(10)),
+				g.Label(T("Installer Version: %s", buildinfo.InstallerTag+" ("+buildinfo.InstallerGitHash+")")+Ternary(IsSelfOutdated, " - "+T("OUTDATED"), "")),
+				g.Label(T("Local Potatocord Version: %s", InstalledHash)+Ternary(IsPotatocordOutdated(), " - "+T("OUTDATED"), "")),
+				&CondWidget{potatocordCorruption != nil, func() g.Widget {
+					return g.Column(
+						renderErrorCard(DiscordRed, potatocordCorruption.Error(), 40),
+						g.Style().
+							SetColor(g.StyleColorButton, DiscordGreen).
+							SetStyle(g.StyleVarFramePadding, Scaled(4), Scaled(4)).
+							To(
+								g.Button(T("Heal")).OnClick(handleHeal),
+							),
+					)
 				}, nil},
-				g.Dummy(0, 10),
-				g.Label("Installer Version: "+buildinfo.InstallerTag+" ("+buildinfo.InstallerGitHash+")"+Ternary(IsSelfOutdated, " - OUTDATED", "")),
-				g.Label("Local Potatocord Version: "+InstalledHash),
 				&CondWidget{
 					GithubError == nil,
 					func() g.Widget {
 						if IsDevInstall {
-							return g.Label("Not updating Potatocord due to being in DevMode")
+							return g.Label(T("Not updating Potatocord due to being in DevMode"))
 						}
-						return g.Label("Latest Potatocord Version: " + LatestHash)
+						return g.Label(T("Latest Potatocord Version: %s", LatestHash))
 					}, func() g.Widget {
-						return renderErrorCard(DiscordRed, "Failed to fetch Info from GitHub: "+GithubError.Error(), 40)
+						return renderErrorCard(DiscordRed, T("Failed to fetch Info from GitHub: %s", localizeErr(GithubError)), 40)
 					},
 				},
 			),
 
-			renderInstaller(),
+			&CondWidget{len(discords) == 0 && !skippedOnboarding, renderOnboarding, renderInstaller},
 		)
 
 	g.PopStyle()
@@ -0,0 +1,79 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	g "github.com/AllenDang/giu"
+)
+
+var (
+	htmlCommentRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+	mdImageRe     = regexp.MustCompile(`!\[([^\]]*)]\(([^)]*)\)`)
+	mdListItemRe  = regexp.MustCompile(`^( *)[-*+] +`)
+	mdHeadingRe   = regexp.MustCompile(`^#{4,} +`)
+	mdRuleRe      = regexp.MustCompile(`^ *([-*_] *){3,}$`)
+)
+
+// The release notes of the latest release, converted by toImguiMarkdown, and the notes they were converted from
+var releaseNotes, releaseNotesSource string
+
+// toImguiMarkdown converts release notes to the subset of markdown imgui can render. List items need two leading
+// spaces and an asterisk, there are only three levels of headings, and images would be downloaded while rendering,
+// so they are turned into links
+func toImguiMarkdown(md string) string {
+	md = strings.ReplaceAll(md, "\r\n", "\n")
+	md = htmlCommentRe.ReplaceAllString(md, "")
+	md = mdImageRe.ReplaceAllString(md, "[$1]($2)")
+	md = strings.ReplaceAll(md, "`", "")
+
+	lines := strings.Split(strings.TrimSpace(md), "\n")
+	for i, line := range lines {
+		switch {
+		case mdRuleRe.MatchString(line):
+			lines[i] = "___"
+		case mdListItemRe.MatchString(line):
+			lines[i] = mdListItemRe.ReplaceAllString(line, "  $1* ")
+		case mdHeadingRe.MatchString(line):
+			lines[i] = mdHeadingRe.ReplaceAllString(line, "### ")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderReleaseNotes shows what's new in the latest release above the install buttons, so it's clear what they
+// install. Expanded if it isn't installed yet
+func renderReleaseNotes() g.Widget {
+	if ReleaseData.Body != releaseNotesSource {
+		releaseNotesSource = ReleaseData.Body
+		releaseNotes = toImguiMarkdown(releaseNotesSource)
+	}
+	if releaseNotes == "" || GithubError != nil || IsDevInstall {
+		return g.Dummy(0, 0)
+	}
+
+	notes := g.Markdown(&releaseNotes)
+	if hasScalableFont {
+		notes.Header(0, scaledFont(26), true).
+			Header(1, scaledFont(22), false).
+			Header(2, scaledFont(20), false)
+	}
+
+	return g.TreeNode(T("What's new in Potatocord %s", LatestHash)).
+		Flags(g.TreeNodeFlagsCollapsingHeader | Ternary(!HashesMatch(LatestHash, InstalledHash), g.TreeNodeFlagsDefaultOpen, 0)).
+		Layout(
+			FontSize(18).To(
+				g.Child().
+					Size(g.Auto, Scaled(160)).
+					Layout(notes),
+			),
+		)
+}
@@ -0,0 +1,105 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+
+	g "github.com/AllenDang/giu"
+	"github.com/AllenDang/go-findfont"
+)
+
+// Tried in order if giu found none of its default fonts, which happens on minimal Linux setups
+var fallbackFonts = []string{
+	"DejaVuSans.ttf",
+	"LiberationSans-Regular.ttf",
+	"NotoSans-Regular.ttf",
+	"Roboto-Regular.ttf",
+	"Ubuntu-R.ttf",
+	"Arial.ttf",
+}
+
+// If no font could be loaded at all, imgui's built-in bitmap font is used. It can't be resized and giu panics
+// when trying to, so FontSize has to be used instead of giu's SetFontSize
+var hasScalableFont = true
+
+// ensureFonts makes sure there is a default font, falling back to any font on the system. Must be called after
+// creating the master window, which is when giu looks for its default fonts
+func ensureFonts() {
+	if len(g.GetDefaultFonts()) != 0 {
+		return
+	}
+
+	Log.Warn("Failed to find the default fonts, looking for another font")
+	var candidates []string
+	for _, name := range fallbackFonts {
+		if p, err := findfont.Find(name); err == nil {
+			candidates = append(candidates, p)
+		}
+	}
+	candidates = append(candidates, findfont.List()...)
+
+	for _, p := range candidates {
+		// stb_truetype, which imgui uses, doesn't reliably support the others
+		if !strings.HasSuffix(strings.ToLower(p), ".ttf") {
+			continue
+		}
+		b, err := os.ReadFile(p)
+		if err != nil || len(b) == 0 {
+			Log.Debug("Failed to load font", p+":", err)
+			continue
+		}
+
+		Log.Info("Using font", p)
+		g.SetDefaultFontFromBytes(b, 15)
+		return
+	}
+
+	Log.Warn("Didn't find any usable font. Falling back to the built-in font, text can't be resized and some characters may be missing")
+	hasScalableFont = false
+}
+
+// FontSize returns a StyleSetter with the given font size, scaled to the display, or the default size if fonts
+// can't be resized
+func FontSize(size float32) *g.StyleSetter {
+	style := g.Style()
+	if hasScalableFont {
+		style.SetFont(scaledFont(size))
+	}
+	return style
+}
+
+// The size giu adds its default fonts with
+func defaultFontSize() float32 {
+	return Ternary[float32](runtime.GOOS == "windows", 16, 15)
+}
+
+// pushScaledFont makes the default font follow the gui scale. giu only scales it on Windows, and only to the
+// display the window was opened on. Returns whether a font was pushed
+func pushScaledFont() bool {
+	if !hasScalableFont || GuiScale == 1 {
+		return false
+	}
+	return g.PushFont(scaledFont(defaultFontSize()))
+}
+
+// scaledFont returns the default font in the given size, scaled to the display
+func scaledFont(size float32) *g.FontInfo {
+	size *= GuiScale
+	font := g.GetDefaultFonts()[0]
+	if runtime.GOOS == "windows" {
+		// On Windows, giu multiplies the size of the fonts it adds by the display scale, but then looks them up by
+		// their unscaled size, so they are never found. Adding the font divided by the scale too makes the lookup
+		// find one with the right size
+		font.SetSize(size / g.Context.GetPlatform().GetContentScale())
+	}
+	return font.SetSize(size)
+}
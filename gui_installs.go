@@ -0,0 +1,105 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"image/color"
+	path "path/filepath"
+	"runtime"
+
+	g "github.com/AllenDang/giu"
+)
+
+// installStatus describes the patch status of the install and the colour to show it in, nil for the default one
+func installStatus(di *DiscordInstall) (string, color.Color) {
+	switch {
+	case di.isStore:
+		return T("Not supported"), DiscordRed
+	case di.isSnap:
+		return T("Needs migration"), DiscordYellow
+	case !di.isPatched:
+		return T("Not patched"), nil
+	default:
+		return T("Patched"), DiscordGreen
+	}
+}
+
+// renderInstalls lists every Discord install found with its patch status and the actions for it. The radio
+// button selects the install the actions below the list are for
+func renderInstalls() g.Widget {
+	rows := make([]*g.TableRowWidget, len(discords))
+	for i, d := range discords {
+		rows[i] = renderInstallRow(i, d.(*DiscordInstall))
+	}
+
+	return g.Table().
+		ID("Discords").
+		Flags(g.TableFlagsBorders|g.TableFlagsRowBg|g.TableFlagsSizingFixedFit).
+		Columns(
+			g.TableColumn("##selected"),
+			g.TableColumn(T("Branch")),
+			g.TableColumn(T("Location")).Flags(g.TableColumnFlagsWidthStretch),
+			g.TableColumn(T("Status")),
+			g.TableColumn(T("Version")),
+			g.TableColumn("##actions"),
+		).
+		Rows(rows...)
+}
+
+func renderInstallRow(i int, di *DiscordInstall) *g.TableRowWidget {
+	id := "##" + di.path
+	status, statusColor := installStatus(di)
+	hash := di.PatchedHash()
+	if hash == "Unknown" {
+		hash = T("Unknown")
+	}
+	// Opening an app bundle would start it instead of showing it
+	folder := Ternary(runtime.GOOS == "darwin", path.Dir(di.path), di.path)
+
+	// The actions select the row first, so they're done like the ones below the list, e.g. in the scope picked there
+	selectAnd := func(action func()) func() {
+		return func() {
+			radioIdx = i
+			action()
+		}
+	}
+
+	statusLabel := g.Widget(g.Label(status))
+	if statusColor != nil {
+		statusLabel = g.Style().SetColor(g.StyleColorText, statusColor).To(statusLabel)
+	}
+
+	return g.TableRow(
+		g.RadioButton(id, radioIdx == i).OnChange(makeRadioOnChange(i)),
+		g.Label(di.DisplayName()),
+		g.Label(di.path),
+		statusLabel,
+		g.Label(Ternary(hash != "", hash, "-")),
+		g.Row(
+			g.Style().
+				SetColor(g.StyleColorButton, DiscordGreen).
+				SetDisabled(GithubError != nil || di.isStore).
+				To(
+					g.Button(T(Ternary(!di.isPatched, "Install", Ternary(IsPotatocordOutdated(), "Update", "Reinstall")))+id).
+						OnClick(selectAnd(handlePatch)),
+				),
+			g.Style().
+				SetColor(g.StyleColorButton, DiscordRed).
+				SetDisabled(!di.isPatched).
+				To(
+					g.Button(T("Uninstall")+id).
+						OnClick(selectAnd(handleUnpatch)),
+				),
+			g.Button(T("Open Folder")+id).
+				OnClick(func() {
+					g.OpenURL("file://" + folder)
+				}),
+		),
+	)
+}
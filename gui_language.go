@@ -0,0 +1,32 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	g "github.com/AllenDang/giu"
+)
+
+func renderLanguageSelection() g.Widget {
+	// 0 follows the system, the others are Languages
+	languageIdx := int32(SliceIndexFunc(Languages, func(l LanguageOption) bool { return l.Code == Settings.Language }) + 1)
+	labels := Prepend(SliceMap(Languages, func(l LanguageOption) string { return l.Name }), T("Follow System"))
+
+	return g.Row(
+		g.Label(T("Language")),
+		g.Combo("##language", labels[languageIdx], labels, &languageIdx).
+			Size(Scaled(200)).
+			OnChange(func() {
+				Settings.Language = Ternary(languageIdx == 0, "", Languages[languageIdx-1].Code)
+				if err := Settings.Save(); err != nil {
+					Log.Warn("Failed to save installer settings:", err)
+				}
+				UseLanguage(GetLanguage())
+			}),
+	)
+}
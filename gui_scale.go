@@ -0,0 +1,83 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"runtime"
+	"strconv"
+
+	g "github.com/AllenDang/giu"
+	"github.com/AllenDang/imgui-go"
+)
+
+// GuiScale is the factor the gui is scaled by, so it isn't tiny on hi-DPI screens. It follows the display the
+// window is on, unless POTATOCORD_GUI_SCALE is set
+var GuiScale float32 = 1
+
+var scaleOverride float32
+
+// The factor imgui's style sizes are currently scaled by. giu scales them on Windows only, when creating the window
+var styleScale float32 = 1
+
+// initScale scales the gui to the display. Must be called after creating the master window
+func initScale() {
+	if runtime.GOOS == "windows" {
+		styleScale = contentScale()
+	}
+
+	if s := EnvGuiScale.Get(); s != "" {
+		if f, err := strconv.ParseFloat(s, 32); err == nil && f > 0 {
+			scaleOverride = float32(f)
+		} else {
+			Log.Warn("Ignoring invalid", EnvGuiScale.Name, s)
+		}
+	}
+
+	updateScale()
+}
+
+// contentScale returns the scale of the display the window is on. macOS lays the window out in points and
+// scales it by itself, so it's always 1 there
+func contentScale() float32 {
+	if runtime.GOOS == "darwin" {
+		return 1
+	}
+	return g.Context.GetPlatform().GetContentScale()
+}
+
+// updateScale rescales the gui if the window was moved to a display with another scale or its scale was changed.
+// Called every frame
+func updateScale() {
+	scale := Ternary(scaleOverride != 0, scaleOverride, contentScale())
+	if scale <= 0 {
+		scale = 1
+	}
+
+	if scale != styleScale {
+		imgui.CurrentStyle().ScaleAllSizes(scale / styleScale)
+		styleScale = scale
+	}
+	if scale == GuiScale {
+		return
+	}
+
+	Log.Debug("Scaling the gui by", scale)
+	if w, h := win.GetSize(); w > 0 && h > 0 {
+		win.SetSize(int(float32(w)*scale/GuiScale), int(float32(h)*scale/GuiScale))
+	}
+	GuiScale = scale
+	if !hasScalableFont {
+		imgui.CurrentIO().SetFontGlobalScale(scale)
+	}
+}
+
+// Scaled converts a size in pixels at a scale of 1 to the current scale
+func Scaled(size float32) float32 {
+	return size * GuiScale
+}
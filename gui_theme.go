@@ -0,0 +1,89 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	g "github.com/AllenDang/giu"
+	"github.com/AllenDang/imgui-go"
+)
+
+// How often to check whether the OS switched between dark and light mode, e.g. at sunset
+const systemThemeWatchInterval = 5 * time.Second
+
+var (
+	themeLabels = []string{"Follow System", "Dark", "Light"}
+	themeIdx    int32
+
+	// giu's colors, which are dark. imgui can reset to its own light colors, but not back to these
+	darkThemeColors []imgui.Vec4
+	themeIsDark     = true
+	// Updated by the goroutine watching the OS, read while rendering
+	systemPrefersDark atomic.Bool
+)
+
+// initTheme applies the chosen theme once the window was created and follows the OS while the theme is ThemeSystem
+func initTheme() {
+	themeIdx = int32(SliceIndex(Themes, GetTheme()))
+	style := imgui.CurrentStyle()
+	for id := imgui.StyleColorText; id <= imgui.StyleColorModalWindowDimBg; id++ {
+		darkThemeColors = append(darkThemeColors, style.GetColor(id))
+	}
+
+	systemPrefersDark.Store(SystemPrefersDark())
+	applyTheme()
+
+	go func() {
+		for range time.Tick(systemThemeWatchInterval) {
+			if GetTheme() != ThemeSystem {
+				continue
+			}
+			if dark := SystemPrefersDark(); systemPrefersDark.Swap(dark) != dark {
+				g.Update()
+			}
+		}
+	}()
+}
+
+// applyTheme switches the colors if the theme changed. Called every frame, as imgui must only be used on the
+// render thread
+func applyTheme() {
+	theme := GetTheme()
+	dark := theme == ThemeDark || theme == ThemeSystem && systemPrefersDark.Load()
+	if dark == themeIsDark {
+		return
+	}
+	themeIsDark = dark
+
+	if dark {
+		style := imgui.CurrentStyle()
+		for i, col := range darkThemeColors {
+			style.SetColor(imgui.StyleColorID(i), col)
+		}
+	} else {
+		imgui.StyleColorsLight()
+	}
+}
+
+func renderThemeSelection() g.Widget {
+	labels := SliceMap(themeLabels, func(label string) string { return T(label) })
+	return g.Row(
+		g.Label(T("Theme")),
+		g.Combo("##theme", labels[themeIdx], labels, &themeIdx).
+			Size(Scaled(200)).
+			OnChange(func() {
+				Settings.Theme = Themes[themeIdx]
+				if err := Settings.Save(); err != nil {
+					Log.Warn("Failed to save installer settings:", err)
+				}
+			}),
+	)
+}
@@ -0,0 +1,56 @@
+//go:build !cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+
+	g "github.com/AllenDang/giu"
+)
+
+// The gui's state, like discords and radioIdx, is only changed on the ui thread, which renders it. Goroutines working
+// in the background hand their results to it with runOnUiThread
+var (
+	uiThreadId  atomic.Uint64
+	uiQueue     []func()
+	uiQueueLock sync.Mutex
+)
+
+func isUiThread() bool {
+	return goroutineId() == uiThreadId.Load()
+}
+
+// runOnUiThread runs fn on the ui thread, right away if already on it and otherwise before the next frame is rendered
+func runOnUiThread(fn func()) {
+	if isUiThread() {
+		fn()
+		return
+	}
+
+	uiQueueLock.Lock()
+	uiQueue = append(uiQueue, fn)
+	uiQueueLock.Unlock()
+	g.Update()
+}
+
+// runUiQueue runs what was handed to the ui thread. Rendered first thing in the window, so the popups opened by the
+// queued functions belong to it
+func runUiQueue() {
+	uiThreadId.Store(goroutineId())
+
+	uiQueueLock.Lock()
+	queue := uiQueue
+	uiQueue = nil
+	uiQueueLock.Unlock()
+
+	for _, fn := range queue {
+		fn()
+	}
+}
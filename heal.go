@@ -0,0 +1,105 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+// CheckPotatocordIntegrity checks whether the content of the installed Potatocord file still is the build it claims
+// or was recorded to be. Returns the build to heal it with and, if the file is corrupted, why. Missing files and dev
+// installs aren't corrupted, there just is nothing to check
+func CheckPotatocordIntegrity() (string, error) {
+	stat, err := os.Stat(PotatocordDirectory)
+	if err != nil || stat.IsDir() {
+		return "", nil
+	}
+
+	embedded := ReadInstalledHash()
+	hash := embedded
+	installed := ReadManifest().Installed
+	if installed != nil && installed.File == PotatocordDirectory {
+		hash = installed.Hash
+	}
+	if hash == "" {
+		hash = LatestHash
+	}
+
+	if embedded == "" {
+		return hash, errors.New(PotatocordDirectory + " is corrupted, it doesn't contain a Potatocord version")
+	}
+	if err = ValidatePotatocordAsar(PotatocordDirectory, ""); err != nil {
+		return hash, errors.New("Potatocord is corrupted: " + err.Error())
+	}
+	if installed == nil || installed.File != PotatocordDirectory || installed.Sha256 == "" || !HashesMatch(embedded, installed.Hash) {
+		// Installed by hand or by an older installer, so there is nothing more to compare against
+		return hash, nil
+	}
+	if sum, err := hashFile(PotatocordDirectory); err != nil {
+		return hash, err
+	} else if !strings.EqualFold(sum, installed.Sha256) {
+		return hash, errors.New(PotatocordDirectory + " is corrupted, its content differs from Potatocord " + hash + " as installed")
+	}
+	return hash, nil
+}
+
+// SelfHeal replaces a corrupted Potatocord file with an intact copy of the same version, from the cache, a retained
+// version or, if it is the latest version, the network. Does nothing if the file is intact
+func SelfHeal() error {
+	defer WithLogContext("heal")()
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// Without knowing the latest version, it can't be downloaded. Cached versions can still be used without it
+	WaitForGithub()
+	hash, err := CheckPotatocordIntegrity()
+	if err == nil {
+		Log.Info("Potatocord files are intact")
+		return nil
+	}
+	Log.Warn(err.Error() + ". Healing with Potatocord " + hash)
+
+	// Set aside, so it neither is retained as previous version nor lost if healing fails
+	corrupted := PotatocordDirectory + ".corrupted"
+	elevate := NeedsElevation(path.Dir(PotatocordDirectory))
+	move := func(from, to string) error {
+		if elevate {
+			return RunPrivileged([]PrivilegedOp{{Op: "rename", Src: from, Dst: to}})
+		}
+		return os.Rename(from, to)
+	}
+	if err = move(PotatocordDirectory, corrupted); err != nil {
+		return errors.New("Failed to move the corrupted " + PotatocordDirectory + " aside: " + err.Error())
+	}
+
+	if err = installBuild(hash); err != nil {
+		if undoErr := move(corrupted, PotatocordDirectory); undoErr != nil {
+			Log.Error("Failed to move back", corrupted+":", undoErr)
+		}
+		return errors.New("Failed to heal Potatocord: " + err.Error())
+	}
+	if elevate {
+		err = RunPrivileged([]PrivilegedOp{{Op: "remove", Dst: corrupted}})
+	} else {
+		err = os.Remove(corrupted)
+	}
+	if err != nil {
+		Log.Warn("Failed to delete", corrupted+":", err)
+	}
+
+	if _, err = CheckPotatocordIntegrity(); err != nil {
+		return withClass(ErrVerificationFailed, errors.New("Potatocord is still corrupted after healing: "+err.Error()))
+	}
+	Log.Info("Successfully healed Potatocord", hash)
+	return nil
+}
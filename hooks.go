@@ -0,0 +1,134 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// A hook that doesn't finish by then is killed, so it can't hold the install lock forever
+const hookTimeout = 5 * time.Minute
+
+// InstallHooks are shell commands to run around installing and updating, from the config file
+type InstallHooks struct {
+	// Run before anything is changed. If one fails, the install is cancelled
+	PreInstall []string `json:"preInstall,omitempty"`
+	// Run once the install is done, whether it worked or not
+	PostInstall []string `json:"postInstall,omitempty"`
+}
+
+type HookEvent string
+
+const (
+	HookPreInstall  HookEvent = "pre-install"
+	HookPostInstall HookEvent = "post-install"
+)
+
+// HookContext is passed to hooks as POTATOCORD_HOOK_* environment variables
+type HookContext struct {
+	// install if the install wasn't patched before, otherwise update
+	Action  string
+	Branch  string
+	Path    string
+	OldHash string
+	NewHash string
+	// Only set for post-install hooks
+	Err error
+}
+
+func (c HookContext) env(event HookEvent) []string {
+	env := []string{
+		"POTATOCORD_HOOK_EVENT=" + string(event),
+		"POTATOCORD_HOOK_ACTION=" + c.Action,
+		"POTATOCORD_HOOK_BRANCH=" + c.Branch,
+		"POTATOCORD_HOOK_PATH=" + c.Path,
+		"POTATOCORD_HOOK_OLD_HASH=" + c.OldHash,
+		"POTATOCORD_HOOK_NEW_HASH=" + c.NewHash,
+	}
+	if event == HookPostInstall {
+		env = append(env, "POTATOCORD_HOOK_RESULT="+Ternary(c.Err == nil, "success", "failure"))
+		if c.Err != nil {
+			env = append(env, "POTATOCORD_HOOK_ERROR="+c.Err.Error())
+		}
+	}
+	return env
+}
+
+// RunHooks runs the commands configured for the event one after another and stops at the first that fails
+func RunHooks(event HookEvent, c HookContext) error {
+	commands := Ternary(event == HookPreInstall, Config.Hooks.PreInstall, Config.Hooks.PostInstall)
+	for _, command := range commands {
+		Log.Info("Running", event, "hook:", command)
+		if err := runHook(command, c.env(event)); err != nil {
+			return errors.New("The " + string(event) + " hook '" + command + "' failed: " + err.Error())
+		}
+	}
+	return nil
+}
+
+func runHook(command string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	// stdout is reserved for --json
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return errors.New("Timed out after " + hookTimeout.String())
+	}
+	return err
+}
+
+// withInstallHooks runs the pre-install hooks, then fn, then the post-install hooks. A failing pre-install hook
+// cancels the install, a failing post-install hook only logs a warning as the install is done by then
+func withInstallHooks(di *DiscordInstall, fn func() error) error {
+	if len(Config.Hooks.PreInstall) == 0 && len(Config.Hooks.PostInstall) == 0 {
+		return fn()
+	}
+
+	// Hooks see the install as it is before and after, without another one getting in between
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	c := HookContext{
+		Action:  Ternary(di.isPatched, "update", "install"),
+		Branch:  di.branch,
+		Path:    di.path,
+		OldHash: di.PatchedHash(),
+		NewHash: Ternary(LatestHash != "" && GithubError == nil, LatestHash, InstalledHash),
+	}
+	if err := RunHooks(HookPreInstall, c); err != nil {
+		Log.Error(err.Error() + ". Not installing")
+		return err
+	}
+
+	c.Err = fn()
+	if c.Err == nil {
+		c.NewHash = di.PatchedHash()
+	}
+	if err := RunHooks(HookPostInstall, c); err != nil {
+		Log.Warn(err)
+	}
+	return c.Err
+}
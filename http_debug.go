@@ -0,0 +1,62 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Headers that may carry credentials, which must not end up in logs people paste into issues
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// debugTransport logs every request and response with their headers when debug logging is enabled. Bodies aren't
+// logged, most of them are Potatocord builds
+type debugTransport struct {
+	http.RoundTripper
+}
+
+func (t debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if LogLevel > LevelDebug {
+		return t.RoundTripper.RoundTrip(req)
+	}
+
+	url := redactUrl(req.URL.String())
+	Log.Debug("HTTP", req.Method, url, formatHeaders(req.Header))
+	start := time.Now()
+	res, err := t.RoundTripper.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		Log.Debug("HTTP", req.Method, url, "failed after", took+":", err)
+		return res, err
+	}
+	Log.Debug("HTTP", res.Status, url, "in", took, formatHeaders(res.Header))
+	return res, nil
+}
+
+func formatHeaders(header http.Header) string {
+	lines := make([]string, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		if SliceContains(redactedHeaders, name) {
+			value = "<redacted>"
+		}
+		lines = append(lines, name+": "+value)
+	}
+	sort.Strings(lines)
+	return "{" + strings.Join(lines, "; ") + "}"
+}
+
+// redactUrl hides the webhook url, which contains its token
+func redactUrl(url string) string {
+	if webhook := EnvWebhookUrl.Get(); webhook != "" && strings.HasPrefix(url, webhook) {
+		return "<" + EnvWebhookUrl.Name + ">"
+	}
+	return url
+}
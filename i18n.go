@@ -0,0 +1,109 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The catalogs, one per language, keyed by the English message. Keep the format verbs like %s in the same order as
+// in the English message
+//
+//go:embed translations/*.json
+var translationFiles embed.FS
+
+// LanguageOption is a language the user can choose, named in that language
+type LanguageOption struct {
+	Code, Name string
+}
+
+// Languages are the languages there is a catalog for. English needs none
+var Languages = []LanguageOption{
+	{"en", "English"},
+	{"de", "Deutsch"},
+	{"es", "Español"},
+	{"fr", "Français"},
+	{"pt", "Português"},
+}
+
+// The translations of the current language. Messages without a translation are shown in English
+var catalog map[string]string
+
+// Language is the language messages are translated to, e.g. de, or en if there is no catalog for the chosen one
+var Language = "en"
+
+// GetLanguage returns the language chosen in the gui or the config file, or the system's, e.g. de or pt_BR. Empty if
+// unknown
+func GetLanguage() string {
+	switch {
+	case Settings.Language != "":
+		return Settings.Language
+	case Config.Language != "":
+		return Config.Language
+	default:
+		return GetUserLocale()
+	}
+}
+
+func readCatalog(language string) (map[string]string, bool) {
+	b, err := translationFiles.ReadFile("translations/" + language + ".json")
+	if err != nil {
+		return nil, false
+	}
+	var c map[string]string
+	if err = json.Unmarshal(b, &c); err != nil {
+		Log.Warn("Failed to parse the", language, "translations:", err)
+		return nil, false
+	}
+	return c, true
+}
+
+// UseLanguage translates messages to the language from now on, falling back to the base language, so pt_BR uses
+// the pt catalog if there is no pt_BR one. Unknown languages and en show messages in English
+func UseLanguage(language string) {
+	language = strings.ReplaceAll(language, "-", "_")
+	base, _, _ := strings.Cut(language, "_")
+	for _, lang := range []string{language, strings.ToLower(base)} {
+		if c, ok := readCatalog(lang); ok {
+			catalog, Language = c, lang
+			return
+		}
+	}
+	catalog, Language = nil, "en"
+}
+
+// T translates the message to the user's language. With arguments, the message is a format string for them
+func T(msg string, a ...any) string {
+	if translated, ok := catalog[msg]; ok {
+		msg = translated
+	}
+	if len(a) > 0 {
+		return fmt.Sprintf(msg, a...)
+	}
+	return msg
+}
+
+// localizeErr is the error's message in the user's language, if it is a known one
+func localizeErr(err error) string {
+	return fmt.Sprint(localize(err))
+}
+
+// localize translates log arguments that are exactly a known message, like ErrDiscordRunning, and keeps the rest
+func localize(arg any) any {
+	switch v := arg.(type) {
+	case string:
+		return T(v)
+	case error:
+		if translated := T(v.Error()); translated != v.Error() {
+			return translated
+		}
+	}
+	return arg
+}
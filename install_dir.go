@@ -0,0 +1,110 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+)
+
+const potatocordAsarName = "potatocord.asar"
+
+func getDefaultPotatocordFile() string {
+	return path.Join(Ternary(Config.InstallDir != "", Config.InstallDir, BaseDir), potatocordAsarName)
+}
+
+// GetInstallDir returns the directory Potatocord is installed to
+func GetInstallDir() string {
+	return path.Dir(PotatocordDirectory)
+}
+
+// SetInstallDir moves Potatocord to dir and repatches all installs so they load it from there. An empty dir restores
+// the default location. The choice is saved in the settings, so updates and repairs use it from then on
+func SetInstallDir(dir string) error {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+	if IsDevInstall {
+		return errors.New("Dev installs can't be moved")
+	}
+	for _, env := range []EnvVar{EnvDirectory, EnvInstallDir} {
+		if _, name := env.Lookup(); name != "" {
+			return errors.New("The install location is set by the " + name + " environment variable")
+		}
+	}
+
+	newFile := getDefaultPotatocordFile()
+	if dir != "" {
+		abs, err := path.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if !IsWritable(abs) {
+			return errors.New(abs + " is not writable")
+		}
+		if err = os.MkdirAll(abs, 0755); err != nil {
+			return err
+		}
+		dir, newFile = abs, path.Join(abs, potatocordAsarName)
+	}
+
+	oldFile := PotatocordDirectory
+	if newFile != oldFile && ExistsFile(oldFile) {
+		Log.Info("Moving", oldFile, "to", newFile)
+		if err := copyFile(oldFile, newFile); err != nil {
+			return errors.New("Failed to move Potatocord to " + newFile + ": " + err.Error())
+		}
+		_ = FixOwnership(newFile)
+		fixWrittenFile(newFile)
+	}
+
+	PotatocordDirectory = newFile
+	hash := ReadInstalledHash()
+	InstalledHash = Ternary(hash != "", hash, "None")
+	if newFile != oldFile && hash != "" {
+		recordInstalledBuild(hash, true)
+	}
+	Settings.InstallDir = dir
+	if err := Settings.Save(); err != nil {
+		return errors.New("Failed to save the install location: " + err.Error())
+	}
+	if newFile == oldFile {
+		return nil
+	}
+
+	var errs []error
+	for _, d := range FindDiscords() {
+		di := d.(*DiscordInstall)
+		if !di.isPatched {
+			continue
+		}
+
+		// Otherwise patching would download Potatocord again
+		WaitForGithub()
+		if err := di.patch(); err != nil {
+			errs = append(errs, errors.New("Failed to repatch "+di.path+": "+err.Error()))
+			continue
+		}
+		if di.isFlatpak {
+			if err := di.flatpakOverride("--nofilesystem=" + oldFile); err != nil {
+				Log.Warn("Failed to revoke Discord Flatpak access to", oldFile+":", err)
+			}
+		}
+	}
+
+	// Keep the old file if any install still loads it
+	if len(errs) == 0 {
+		Log.Debug("Deleting", oldFile)
+		_ = os.Remove(oldFile)
+		forgetTouchedFiles(oldFile)
+		recordRemoved(oldFile)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,126 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	path "path/filepath"
+	"sync"
+	"time"
+)
+
+// No install takes this long, so a lock this old was left behind by a process whose pid has been reused since
+const installLockStaleAfter = time.Hour
+
+type installLockInfo struct {
+	Pid     int       `json:"pid"`
+	Started time.Time `json:"started"`
+}
+
+// The lock is reentrant within the goroutine holding it, as e.g. Repair patches and patching unpatches first
+var installLock struct {
+	sync.Mutex
+	owner uint64
+	depth int
+	file  string
+	// Installs holding the lock are cancellable
+	leave func()
+}
+
+func getInstallLockPath() string {
+	// Like the settings, the lock stays in the user's directory when installing for all users, which may not be
+	// writable without elevation
+	return path.Join(Ternary(CurrentScope == ScopeSystem, userBaseDir, BaseDir), "install.lock")
+}
+
+// AcquireInstallLock makes sure no other installer, or other goroutine of this one, modifies Potatocord or Discord at
+// the same time. Locks of processes that are gone are taken over. The returned function releases the lock
+func AcquireInstallLock() (func(), error) {
+	id := goroutineId()
+	installLock.Lock()
+	defer installLock.Unlock()
+
+	if installLock.depth > 0 {
+		if installLock.owner != id {
+			return nil, fmt.Errorf("%w. Wait for it to finish and try again", ErrInstallInProgress)
+		}
+		installLock.depth++
+		return releaseInstallLock, nil
+	}
+
+	file := getInstallLockPath()
+	if err := createInstallLock(file); err != nil {
+		return nil, err
+	}
+	installLock.owner, installLock.depth, installLock.file = id, 1, file
+	installLock.leave = enterCancellable()
+	return releaseInstallLock, nil
+}
+
+func releaseInstallLock() {
+	installLock.Lock()
+	defer installLock.Unlock()
+
+	if installLock.depth--; installLock.depth == 0 {
+		installLock.leave()
+		if err := os.Remove(installLock.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			Log.Warn("Failed to remove install lock:", err)
+		}
+	}
+}
+
+func createInstallLock(file string) error {
+	if !ExistsFile(path.Dir(file)) {
+		if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+			return err
+		}
+		_ = FixOwnership(path.Dir(file))
+	}
+
+	b, _ := json.Marshal(installLockInfo{Pid: os.Getpid(), Started: time.Now()})
+	for attempt := 0; ; attempt++ {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(b)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(file)
+			}
+			return err
+		}
+		if !errors.Is(err, os.ErrExist) || attempt > 0 {
+			return err
+		}
+
+		holder, stale := readInstallLock(file)
+		if !stale {
+			return fmt.Errorf("%w (pid %d, started %s). Wait for it to finish and try again", ErrInstallInProgress,
+				holder.Pid, holder.Started.Format(time.DateTime))
+		}
+		Log.Warn("Removing stale install lock of pid", holder.Pid)
+		if err = os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.New("Failed to remove stale install lock " + file + ": " + err.Error())
+		}
+	}
+}
+
+// readInstallLock returns who holds the lock and whether they're gone
+func readInstallLock(file string) (installLockInfo, bool) {
+	var info installLockInfo
+	b, err := os.ReadFile(file)
+	if err != nil || json.Unmarshal(b, &info) != nil || info.Pid <= 0 {
+		// Either it is being written right now, or whoever wrote it died doing so
+		stat, err := os.Stat(file)
+		return info, err != nil || time.Since(stat.ModTime()) > 10*time.Second
+	}
+	return info, !isProcessAlive(info.Pid) || time.Since(info.Started) > installLockStaleAfter
+}
@@ -0,0 +1,148 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"testing"
+	"time"
+)
+
+// deadPid returns the pid of a process that has exited
+func deadPid(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func writeInstallLock(t *testing.T, file string, content []byte, modTime time.Time) {
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func lockInfo(pid int, started time.Time) []byte {
+	b, _ := json.Marshal(installLockInfo{Pid: pid, Started: started})
+	return b
+}
+
+func TestCreateInstallLock(t *testing.T) {
+	// The data directory may not exist yet
+	file := path.Join(t.TempDir(), "data", "install.lock")
+	if err := createInstallLock(file); err != nil {
+		t.Fatal(err)
+	}
+
+	holder, stale := readInstallLock(file)
+	if holder.Pid != os.Getpid() || stale {
+		t.Errorf("readInstallLock() = %+v, %v, want pid %d and not stale", holder, stale, os.Getpid())
+	}
+	if time.Since(holder.Started) > time.Minute {
+		t.Error("lock was started at", holder.Started)
+	}
+
+	if err := createInstallLock(file); !errors.Is(err, ErrInstallInProgress) {
+		t.Error("locking twice returned", err)
+	}
+}
+
+func TestInstallLockTakeover(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name     string
+		content  []byte
+		modTime  time.Time
+		takeover bool
+	}{
+		{"running", lockInfo(os.Getpid(), now), now, false},
+		{"dead pid", lockInfo(deadPid(t), now), now, true},
+		// The pid was reused since
+		{"over an hour old", lockInfo(os.Getpid(), now.Add(-2*time.Hour)), now, true},
+		{"being written", []byte(`{"pid":`), now, false},
+		{"half written", []byte(`{"pid":`), now.Add(-time.Minute), true},
+		{"empty", nil, now.Add(-time.Minute), true},
+		{"invalid pid", lockInfo(0, now), now.Add(-time.Minute), true},
+	}
+	for _, test := range tests {
+		file := path.Join(t.TempDir(), "install.lock")
+		writeInstallLock(t, file, test.content, test.modTime)
+
+		if _, stale := readInstallLock(file); stale != test.takeover {
+			t.Errorf("%s: readInstallLock() stale = %v, want %v", test.name, stale, test.takeover)
+		}
+
+		err := createInstallLock(file)
+		if test.takeover && err != nil {
+			t.Errorf("%s: didn't take over the lock: %v", test.name, err)
+		} else if !test.takeover && !errors.Is(err, ErrInstallInProgress) {
+			t.Errorf("%s: createInstallLock() = %v, want ErrInstallInProgress", test.name, err)
+		}
+
+		b, _ := os.ReadFile(file)
+		if test.takeover && string(b) == string(test.content) || !test.takeover && string(b) != string(test.content) {
+			t.Errorf("%s: lock file is %s", test.name, b)
+		}
+	}
+}
+
+func TestInstallLockIsReentrant(t *testing.T) {
+	baseDir := BaseDir
+	BaseDir = t.TempDir()
+	defer func() { BaseDir = baseDir }()
+	file := getInstallLockPath()
+
+	acquireElsewhere := func() error {
+		errs := make(chan error)
+		go func() {
+			release, err := AcquireInstallLock()
+			if err == nil {
+				release()
+			}
+			errs <- err
+		}()
+		return <-errs
+	}
+
+	release, err := AcquireInstallLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// E.g. Repair patching, which unpatches first
+	releaseInner, err := AcquireInstallLock()
+	if err != nil {
+		t.Fatal("acquiring the lock again in the same goroutine failed:", err)
+	}
+
+	if err = acquireElsewhere(); !errors.Is(err, ErrInstallInProgress) {
+		t.Error("another goroutine acquired the held lock:", err)
+	}
+
+	releaseInner()
+	if !ExistsFile(file) {
+		t.Error("releasing the inner lock removed", file)
+	}
+	if err = acquireElsewhere(); !errors.Is(err, ErrInstallInProgress) {
+		t.Error("another goroutine acquired the lock before it was released:", err)
+	}
+
+	release()
+	if ExistsFile(file) {
+		t.Error("releasing the lock left", file, "behind")
+	}
+	if err = acquireElsewhere(); err != nil {
+		t.Error("another goroutine couldn't acquire the released lock:", err)
+	}
+}
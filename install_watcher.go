@@ -0,0 +1,43 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"maps"
+	"os"
+	"time"
+)
+
+const InstallWatchInterval = 5 * time.Second
+
+// WatchDiscordInstalls calls onChange whenever a Discord install may have been added or removed. Installing or
+// removing something changes the modification time of its parent directory, so polling those is enough and much
+// cheaper than rescanning. It never returns
+func WatchDiscordInstalls(onChange func()) {
+	defer WithLogContext("installs")()
+
+	last := getParentDirStamps()
+	for {
+		time.Sleep(InstallWatchInterval)
+		if stamps := getParentDirStamps(); !maps.Equal(stamps, last) {
+			last = stamps
+			Log.Debug("Discord install directories changed")
+			onChange()
+		}
+	}
+}
+
+func getParentDirStamps() map[string]time.Time {
+	stamps := make(map[string]time.Time)
+	for _, dir := range getDiscordParentDirs() {
+		// Missing directories are left out, so them being created counts as a change too
+		if stat, err := os.Stat(dir); err == nil {
+			stamps[dir] = stat.ModTime()
+		}
+	}
+	return stamps
+}
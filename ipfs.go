@@ -0,0 +1,110 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Public IPFS gateways, used as a last resort if neither GitHub nor any of the AssetMirrors are reachable
+var IpfsGateways = []string{
+	"https://ipfs.io",
+	"https://dweb.link",
+	"https://gateway.pinata.cloud",
+}
+
+// Releases may publish <asset>.cid with the IPFS CID of the asset and <asset>.sha256 with its checksum, as assets or as
+// "<asset>.cid: <cid>" lines in the release notes. Gateways are untrusted, so a download from IPFS is only used if the
+// checksum matches
+const (
+	ipfsCidSuffix      = ".cid"
+	ipfsChecksumSuffix = ".sha256"
+)
+
+// Limits what a gateway may send if the release doesn't say how big the asset is
+const ipfsMaxSize = 256 << 20
+
+// ipfsSources returns the IPFS gateways as sources of the given release asset. The asset's CID and checksum are only
+// looked up once the first gateway is tried, as IPFS is the last resort
+func ipfsSources(asset *ReleaseAsset) []buildSource {
+	lookup := sync.OnceValues(func() ([2]string, error) {
+		cid, err := releaseText(&ReleaseData, asset.Name+ipfsCidSuffix)
+		if err != nil {
+			Log.Warn("Can't download", asset.Name, "from IPFS:", err)
+			return [2]string{}, err
+		}
+		checksum, err := releaseText(&ReleaseData, asset.Name+ipfsChecksumSuffix)
+		if err != nil {
+			Log.Warn("Can't download", asset.Name, "from IPFS without a checksum:", err)
+			return [2]string{}, err
+		}
+		Log.Info("Trying to download", asset.Name, "from IPFS (CID "+cid+")")
+		return [2]string{cid, checksum}, nil
+	})
+
+	return SliceMap(IpfsGateways, func(gateway string) buildSource {
+		return buildSource{name: gateway, lastResort: true, open: func() (io.ReadCloser, int64, error) {
+			ipfs, err := lookup()
+			if err != nil {
+				return nil, 0, err
+			}
+			return downloadFromIpfs(gateway, asset, ipfs[0], ipfs[1])
+		}}
+	})
+}
+
+// downloadFromIpfs requests the asset with the given CID from an IPFS gateway. Reading the body fails at its end if it
+// doesn't match the checksum, and stops at the asset's size, so an untrusted gateway can't fill the disk
+func downloadFromIpfs(gateway string, asset *ReleaseAsset, cid, checksum string) (io.ReadCloser, int64, error) {
+	res, err := DownloadWithFailover([]string{gateway + "/ipfs/" + cid})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit := Ternary(asset.Size > 0, asset.Size, ipfsMaxSize)
+	if res.ContentLength > limit {
+		_ = res.Body.Close()
+		return nil, 0, errors.New(gateway + " sent " + FormatBytes(uint64(res.ContentLength)) + " for " + asset.Name +
+			", but it can be at most " + FormatBytes(uint64(limit)))
+	}
+	// Anything beyond the limit is cut off, which then fails the checksum
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(res.Body, limit), res.Body}
+	return &checksumReader{ReadCloser: body, hash: sha256.New(), name: asset.Name, expected: checksum}, res.ContentLength, nil
+}
+
+// releaseText returns the value of a small text release asset like <asset>.cid. IPFS is only tried once the asset hosts
+// failed, so it's looked up in the release data first, which came from the API or the cache: GitHub's digest of the
+// asset for checksums, or a "<name>: <value>" line in the release notes
+func releaseText(data *GithubRelease, name string) (string, error) {
+	assetName, isChecksum := strings.CutSuffix(name, ipfsChecksumSuffix)
+	if isChecksum {
+		for _, asset := range data.Assets {
+			if sum, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok && asset.Name == assetName {
+				return sum, nil
+			}
+		}
+	}
+
+	for _, line := range strings.Split(data.Body, "\n") {
+		line = strings.TrimLeft(strings.ReplaceAll(line, "`", ""), "-* \t")
+		if value, ok := strings.CutPrefix(line, name+":"); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				return fields[0], nil
+			}
+		}
+	}
+
+	// A checksum from the mirrors could just as well be made up by them, so it's only fetched from the release itself
+	return fetchReleaseText(data, name, !isChecksum)
+}
@@ -0,0 +1,64 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReleaseTextWithoutAssetHosts(t *testing.T) {
+	data := &GithubRelease{
+		Body: "## Changes\n- Fixed things\n\n- `desktop.asar.cid`: `bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi`\n",
+		Assets: []ReleaseAsset{
+			{Name: "desktop.asar", DownloadURL: "http://127.0.0.1:1/desktop.asar", Digest: "sha256:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		},
+	}
+
+	if cid, err := releaseText(data, "desktop.asar"+ipfsCidSuffix); err != nil || cid != "bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi" {
+		t.Errorf("cid = %q, %v", cid, err)
+	}
+	if sum, err := releaseText(data, "desktop.asar"+ipfsChecksumSuffix); err != nil || sum != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("checksum = %q, %v", sum, err)
+	}
+	if _, err := releaseText(data, "other.asar"+ipfsCidSuffix); err == nil {
+		t.Error("found a cid that wasn't published")
+	}
+}
+
+func TestReleaseChecksumNotFromMirrors(t *testing.T) {
+	own := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.sha256" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  installer\n"))
+	}))
+	defer own.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("0000000000000000000000000000000000000000000000000000000000000000  installer\n"))
+	}))
+	defer mirror.Close()
+
+	mirrors := AssetMirrors
+	AssetMirrors = []string{mirror.URL}
+	defer func() { AssetMirrors = mirrors }()
+
+	data := &GithubRelease{
+		Assets: []ReleaseAsset{
+			{Name: "installer.sha256", DownloadURL: own.URL + "/installer.sha256"},
+			{Name: "missing.sha256", DownloadURL: own.URL + "/missing.sha256"},
+		},
+	}
+	if sum, err := releaseText(data, "installer"+ipfsChecksumSuffix); err != nil || sum != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Errorf("checksum = %q, %v", sum, err)
+	}
+	if sum, err := releaseText(data, "missing"+ipfsChecksumSuffix); err == nil {
+		t.Errorf("took checksum %q from a mirror", sum)
+	}
+}
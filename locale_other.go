@@ -0,0 +1,35 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// GetUserLocale returns the user's locale, e.g. en_US, or an empty string if unknown
+func GetUserLocale() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(env); locale != "" && locale != "C" && locale != "POSIX" {
+			// en_US.UTF-8
+			locale, _, _ = strings.Cut(locale, ".")
+			return locale
+		}
+	}
+
+	// Apps started from Finder don't get LANG
+	if runtime.GOOS == "darwin" {
+		if out, err := exec.Command("defaults", "read", "-g", "AppleLocale").Output(); err == nil {
+			return strings.TrimSpace(string(out))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+// ParseDiscordLocation parses a Discord install at a path given by the user. Unlike ParseDiscord, it explains why the
+// path isn't a usable install, e.g. because it's Discord's data folder or a folder inside the install
+func ParseDiscordLocation(p string) (*DiscordInstall, error) {
+	abs, err := path.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errors.New(abs + " doesn't exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New(abs + " is a file, not a Discord install. Pass the folder Discord is installed in, e.g. " + path.Dir(abs))
+	}
+
+	di := ParseDiscord(abs, "")
+	if di == nil {
+		return nil, errors.New(abs + " doesn't look like a Discord install. " + locationHint(abs))
+	}
+	if di.isStore {
+		return nil, errors.New(abs + " is a Microsoft Store install of Discord, which can't be modified")
+	}
+	return di, nil
+}
+
+// locationHint guesses what the user picked instead of the folder Discord is installed in
+func locationHint(dir string) string {
+	name := strings.ToLower(path.Base(dir))
+	switch {
+	case name == "resources" || name == "app" || strings.HasPrefix(name, "app-") && !ExistsFile(path.Join(dir, "resources")):
+		return "It seems to be a folder inside the install, try " + path.Dir(dir)
+	case name == "contents" || name == "macos":
+		return "It seems to be a folder inside the app, pass the Discord.app itself"
+	case ExistsFile(path.Join(dir, "Local Storage")) || ExistsFile(path.Join(dir, "settings.json")):
+		return "It seems to be Discord's data folder, which is separate from where Discord is installed"
+	default:
+		return "No resources folder with an app.asar was found. Make sure you select the base folder"
+	}
+}
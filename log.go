@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"github.com/fatih/color"
 	"os"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type Level = int
@@ -36,28 +40,124 @@ var levelColors = map[Level]*color.Color{
 var Log Handler
 var LogLevel = LevelInfo
 
+// Whether a flag or POTATOCORD_LOG_LEVEL chose the level, which the config file doesn't override then
+var logLevelSetByUser bool
+
 func init() {
-	debug := SliceContainsFunc(os.Args, func(s string) bool {
-		return s == "-debug" || s == "--debug"
-	})
+	if name := EnvLogLevel.Get(); name != "" {
+		if level, ok := ParseLogLevel(name); ok {
+			LogLevel, logLevelSetByUser = level, true
+		} else {
+			Log.Warn("Ignoring unknown POTATOCORD_LOG_LEVEL", name)
+		}
+	}
+
+	// Flags aren't parsed yet, but everything logged until then should respect them too
+	hasFlag := func(name string) bool {
+		return SliceContainsFunc(os.Args, func(s string) bool {
+			return s == "-"+name || s == "--"+name
+		})
+	}
+
+	if hasFlag("debug") || hasFlag("verbose") {
+		LogLevel, logLevelSetByUser = LevelDebug, true
+	} else if hasFlag("quiet") {
+		LogLevel, logLevelSetByUser = LevelError, true
+	}
+}
 
-	if debug {
-		LogLevel = LevelDebug
+// UseConfigLogLevel applies the log level of the config file, unless one was chosen otherwise
+func UseConfigLogLevel() {
+	if level, ok := ParseLogLevel(Config.LogLevel); ok && !logLevelSetByUser {
+		LogLevel = level
 	}
 }
 
+// ParseLogLevel parses a level name like debug or WARN
+func ParseLogLevel(name string) (Level, bool) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
 type Handler struct {
 }
 
+var logLock sync.Mutex
+
+// Operation contexts by goroutine id. Concurrent operations can't share a global prefix and passing a logger
+// through every helper isn't worth it, so each goroutine gets its own
+var (
+	logContexts     sync.Map
+	logContextCount atomic.Int32
+)
+
+// WithLogContext tags every line logged by the current goroutine with ctx, e.g. "patch:stable", until the returned
+// function is called. Nested contexts are joined with a slash. Goroutines started meanwhile don't inherit it
+func WithLogContext(ctx string) (restore func()) {
+	id := goroutineId()
+	prev, hadPrev := logContexts.Load(id)
+	if hadPrev {
+		ctx = prev.(string) + "/" + ctx
+	} else {
+		logContextCount.Add(1)
+	}
+	logContexts.Store(id, ctx)
+
+	return func() {
+		if hadPrev {
+			logContexts.Store(id, prev)
+		} else {
+			logContexts.Delete(id)
+			logContextCount.Add(-1)
+		}
+	}
+}
+
+func goroutineId() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	// goroutine 42 [running]:
+	idStr, _, _ := strings.Cut(strings.TrimPrefix(string(buf[:n]), "goroutine "), " ")
+	id, _ := strconv.ParseUint(idStr, 10, 64)
+	return id
+}
+
 func (h Handler) Log(level Level, a ...any) {
+	// The log file gets everything
+	if level < LogLevel && logFile == nil {
+		return
+	}
+
+	// Stack traces aren't free, so only look up the context if anyone set one
+	if logContextCount.Load() != 0 {
+		if ctx, ok := logContexts.Load(goroutineId()); ok {
+			a = Prepend(a, any("["+ctx.(string)+"]"))
+		}
+	}
+
+	logLock.Lock()
+	defer logLock.Unlock()
+	if logFile != nil {
+		logFile.write(level, a)
+	}
 	if level < LogLevel {
 		return
 	}
 
 	levelName := levelNames[level]
 	var prefix any = levelColors[level].Sprintf(levelName + strings.Repeat(" ", len("error")-len(levelName)))
-
+	// Print above the progress bar instead of into it
+	if progressBar != "" {
+		_, _ = fmt.Fprint(os.Stderr, "\r\x1b[K")
+	}
 	_, _ = fmt.Fprintln(os.Stderr, Prepend(a, prefix)...)
+	if progressBar != "" {
+		_, _ = fmt.Fprint(os.Stderr, progressBar)
+	}
 }
 
 func (h Handler) Debug(a ...any) {
@@ -0,0 +1,159 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	path "path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-appdir"
+)
+
+const (
+	// The log file is rotated once it grows past this
+	maxLogFileSize = 1024 * 1024
+	// How many rotated log files to keep next to the current one, as <file>.1 (the newest) to <file>.<n>
+	keptLogFiles = 3
+)
+
+// logFile is where the log is written to besides stderr, if anywhere
+var (
+	logFile *rotatingLog
+	// Where logFile was chosen, e.g. --log-file
+	logFileSource string
+)
+
+type rotatingLog struct {
+	path string
+	f    *os.File
+	size int64
+}
+
+func GetDefaultLogFile() string {
+	return path.Join(writableDirOr(appdir.New("Potatocord").UserLogs(), "PotatocordLogs"), "installer.log")
+}
+
+// UseLogFile makes the log also go to the file from POTATOCORD_LOG_FILE or the config file, if any
+func UseLogFile() {
+	file, source := EnvLogFile.Lookup()
+	if file == "" {
+		file, source = Config.LogFile, "config file"
+	}
+	if file == "" {
+		return
+	}
+	if err := OpenLogFile(file); err != nil {
+		Log.Warn("Ignoring the log file from", source+":", err)
+		return
+	}
+	logFileSource = source
+}
+
+// OpenLogFile writes the log to the file besides stderr, replacing the previous log file. It gets every line
+// including debug ones, regardless of the log level, so it's complete when attached to bug reports. Pass "default"
+// for the default location
+func OpenLogFile(file string) error {
+	if file == "default" {
+		// The other user could have replaced it with a link to any file, which would then be written as administrator
+		if TargetUser != "" {
+			return errors.New("The default log file can't be used when installing for another user. Pass a path instead")
+		}
+		file = GetDefaultLogFile()
+	}
+	file, err := path.Abs(file)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	l := &rotatingLog{path: file}
+	if err = l.open(); err != nil {
+		return err
+	}
+	_ = FixOwnership(file)
+
+	logLock.Lock()
+	prev := logFile
+	logFile = l
+	logLock.Unlock()
+	if prev != nil {
+		_ = prev.f.Close()
+	}
+
+	Log.Debug("Logging to", file)
+	return nil
+}
+
+// GetLogFile returns the file the log is written to, or "" if none
+func GetLogFile() string {
+	logLock.Lock()
+	defer logLock.Unlock()
+	if logFile == nil {
+		return ""
+	}
+	return logFile.path
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	l.f, l.size = f, stat.Size()
+	if l.size >= maxLogFileSize {
+		return l.rotate()
+	}
+	return nil
+}
+
+// write appends a line to the log. Must be called with logLock held
+func (l *rotatingLog) write(level Level, a []any) {
+	levelName := levelNames[level]
+	line := time.Now().Format("2006-01-02 15:04:05.000") + " " + levelName + strings.Repeat(" ", len("error")-len(levelName)) +
+		" " + fmt.Sprintln(a...)
+	n, err := l.f.WriteString(line)
+	l.size += int64(n)
+	if err == nil && l.size >= maxLogFileSize {
+		err = l.rotate()
+	}
+	if err != nil {
+		// Logging the error would end up here again
+		_, _ = fmt.Fprintln(os.Stderr, "Failed to write log file", l.path+":", err)
+	}
+}
+
+// rotate renames the log to <file>.1, shifting older ones up and deleting the oldest, and starts a new one
+func (l *rotatingLog) rotate() error {
+	_ = l.f.Close()
+	var renameErr error
+	for i := keptLogFiles; i > 0 && renameErr == nil; i-- {
+		from := Ternary(i == 1, l.path, l.path+"."+strconv.Itoa(i-1))
+		if err := os.Rename(from, l.path+"."+strconv.Itoa(i)); err != nil && !os.IsNotExist(err) {
+			renameErr = err
+		}
+	}
+
+	// If renaming failed, keep appending and try again once another maxLogFileSize was written
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	l.f, l.size = f, 0
+	return renameErr
+}
@@ -0,0 +1,339 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"time"
+)
+
+// How many previously installed Potatocord versions to keep for rolling back, unless configured otherwise
+const defaultKeptVersions = 3
+
+func GetKeptVersions() int {
+	switch {
+	case Settings.KeptVersions > 0:
+		return Settings.KeptVersions
+	case Config.KeepVersions > 0:
+		return Config.KeepVersions
+	default:
+		return defaultKeptVersions
+	}
+}
+
+// InstallManifest records what the installer did, so it can be undone later. Uninstalling, repairing and diagnostics
+// rely on it rather than guessing from what is on disk
+type InstallManifest struct {
+	// The installed Potatocord build
+	Installed *InstalledBuild `json:"installed,omitempty"`
+	// Previously installed Potatocord versions, oldest first
+	Backups []PotatocordBackup `json:"backups"`
+	// Where Potatocord is injected, by resources directory
+	Injections map[string]Injection `json:"injections,omitempty"`
+	// Every file the installer created or modified, by path
+	Files map[string]TouchedFile `json:"files,omitempty"`
+	// The Potatocord version each install was patched with, by resources directory
+	Patched map[string]string `json:"patched,omitempty"`
+	// The scope each install was patched in, by resources directory
+	Scopes map[string]InstallScope `json:"scopes,omitempty"`
+}
+
+type InstalledBuild struct {
+	File   string    `json:"file"`
+	Hash   string    `json:"hash"`
+	Sha256 string    `json:"sha256"`
+	Time   time.Time `json:"time"`
+}
+
+type Injection struct {
+	Discord string `json:"discord"`
+	Branch  string `json:"branch"`
+	// Our app.asar, and Discord's own that it was moved to
+	AppAsar   string `json:"appAsar"`
+	StockAsar string `json:"stockAsar"`
+	// The Potatocord file our app.asar loads, which Flatpaks were granted access to
+	Loads   string       `json:"loads"`
+	Hash    string       `json:"hash"`
+	Scope   InstallScope `json:"scope"`
+	Flatpak bool         `json:"flatpak,omitempty"`
+	Time    time.Time    `json:"time"`
+}
+
+type TouchedFile struct {
+	// Whether the file didn't exist before, rather than being modified
+	Created bool      `json:"created"`
+	Time    time.Time `json:"time"`
+}
+
+type PotatocordBackup struct {
+	File string    `json:"file"`
+	Hash string    `json:"hash"`
+	Time time.Time `json:"time"`
+}
+
+func getManifestPath() string {
+	return path.Join(BaseDir, "manifest.json")
+}
+
+func ReadManifest() *InstallManifest {
+	return readManifestFile(getManifestPath())
+}
+
+func readManifestFile(file string) *InstallManifest {
+	manifest := &InstallManifest{}
+	b, err := os.ReadFile(file)
+	if err == nil {
+		if err = json.Unmarshal(b, manifest); err != nil {
+			Log.Warn("Failed to parse install manifest:", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		Log.Warn("Failed to read install manifest:", err)
+	}
+	return manifest
+}
+
+func (m *InstallManifest) Save() error {
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	// The machine-wide manifest may need administrator rights
+	if err = writeFilePrivileged(getManifestPath(), b); err != nil {
+		return err
+	}
+	_ = FixOwnership(getManifestPath())
+	return nil
+}
+
+// touch records that file was created or modified. Files stay recorded as created when modified again later
+func (m *InstallManifest) touch(file string, created bool) {
+	if m.Files == nil {
+		m.Files = make(map[string]TouchedFile)
+	}
+	if prev, ok := m.Files[file]; ok {
+		created = created || prev.Created
+	}
+	m.Files[file] = TouchedFile{created, time.Now()}
+	recordWritten(file)
+}
+
+// recordTouchedFiles records files the installer created or modified outside of recording an injection or build
+func recordTouchedFiles(created bool, files ...string) {
+	manifest := ReadManifest()
+	for _, file := range files {
+		manifest.touch(file, created)
+	}
+	if err := manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+}
+
+// forgetTouchedFiles removes files from the manifest once they were restored or deleted
+func forgetTouchedFiles(files ...string) {
+	manifest := ReadManifest()
+	for _, file := range files {
+		delete(manifest.Files, file)
+	}
+	if err := manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+}
+
+// recordInstalledBuild records the Potatocord build that was just installed to PotatocordDirectory
+func recordInstalledBuild(hash string, created bool) {
+	sum, err := hashFile(PotatocordDirectory)
+	if err != nil {
+		Log.Warn("Failed to hash", PotatocordDirectory+":", err)
+	}
+
+	manifest := ReadManifest()
+	if manifest.Installed != nil {
+		recordVersion("Potatocord", manifest.Installed.Hash, hash)
+	} else {
+		recordVersion("Potatocord", "", hash)
+	}
+	manifest.Installed = &InstalledBuild{PotatocordDirectory, hash, sum, time.Now()}
+	manifest.touch(PotatocordDirectory, created)
+	if err = manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+}
+
+// LatestBackup returns the most recent backup or nil if there is none
+func (m *InstallManifest) LatestBackup() *PotatocordBackup {
+	if len(m.Backups) == 0 {
+		return nil
+	}
+	return &m.Backups[len(m.Backups)-1]
+}
+
+// BackupPotatocordFile copies the currently installed Potatocord file to a timestamped backup before it is overwritten
+func BackupPotatocordFile() error {
+	stat, err := os.Stat(PotatocordDirectory)
+	if err != nil || stat.IsDir() {
+		// Nothing installed yet, or a dev install
+		return nil
+	}
+
+	hash := ReadInstalledHash()
+	file := path.Join(BackupDir, "potatocord-"+time.Now().Format("20060102-150405")+"-"+Ternary(hash != "", hash, "unknown")+".asar")
+	Log.Debug("Backing up", PotatocordDirectory, "to", file)
+	if err = copyFile(PotatocordDirectory, file); err != nil {
+		return err
+	}
+	_ = FixOwnership(BackupDir)
+	recordBackup(file)
+
+	manifest := ReadManifest()
+	// Only keep the latest copy of each version
+	if hash != "" {
+		for i := 0; i < len(manifest.Backups); i++ {
+			if manifest.Backups[i].Hash == hash {
+				Log.Debug("Replacing older backup of", hash, manifest.Backups[i].File)
+				_ = os.Remove(manifest.Backups[i].File)
+				recordRemoved(manifest.Backups[i].File)
+				manifest.Backups = append(manifest.Backups[:i], manifest.Backups[i+1:]...)
+				i--
+			}
+		}
+	}
+	manifest.Backups = append(manifest.Backups, PotatocordBackup{file, hash, time.Now()})
+	for len(manifest.Backups) > GetKeptVersions() {
+		Log.Debug("Deleting old backup", manifest.Backups[0].File)
+		_ = os.Remove(manifest.Backups[0].File)
+		recordRemoved(manifest.Backups[0].File)
+		manifest.Backups = manifest.Backups[1:]
+	}
+	return manifest.Save()
+}
+
+// Rollback restores the previously installed Potatocord version
+func Rollback() error {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+	manifest := ReadManifest()
+	backup := manifest.LatestBackup()
+	if backup == nil {
+		return errors.New("There is no previous version to roll back to")
+	}
+
+	Log.Info("Rolling back to", Ternary(backup.Hash != "", backup.Hash, "unknown version"), "from", backup.Time.Format(time.DateTime))
+	if err := restorePotatocordFile(backup.File); err != nil {
+		return errors.New("Failed to restore " + backup.File + ": " + err.Error())
+	}
+	_ = FixOwnership(PotatocordDirectory)
+	fixWrittenFile(PotatocordDirectory)
+
+	_ = os.Remove(backup.File)
+	recordRemoved(backup.File)
+	manifest.Backups = manifest.Backups[:len(manifest.Backups)-1]
+	manifest.recordRestoredHash(backup.Hash)
+	if err := manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+	recordInstalledBuild(backup.Hash, false)
+
+	InstalledHash = Ternary(backup.Hash != "", backup.Hash, "None")
+	return nil
+}
+
+// restorePotatocordFile puts a retained version in place of the installed one, keeping its mode and owner. Like
+// installing, it elevates if the install directory can't be written to, e.g. the machine-wide one
+func restorePotatocordFile(src string) error {
+	if !NeedsElevation(path.Dir(PotatocordDirectory)) {
+		return copyFile(src, PotatocordDirectory)
+	}
+	attrs, _ := getFileAttrs(PotatocordDirectory)
+	return RunPrivileged([]PrivilegedOp{
+		{Op: "mkdir", Dst: path.Dir(PotatocordDirectory)},
+		{Op: "copy", Src: src, Dst: PotatocordDirectory, Attrs: &attrs},
+	})
+}
+
+// FindBackup returns the retained version with the given hash, or nil if it isn't retained
+func (m *InstallManifest) FindBackup(hash string) *PotatocordBackup {
+	for i := len(m.Backups) - 1; i >= 0; i-- {
+		if m.Backups[i].Hash != "" && HashesMatch(m.Backups[i].Hash, hash) {
+			return &m.Backups[i]
+		}
+	}
+	return nil
+}
+
+// RestoreVersion installs a retained previous version. The installed version is retained in its place, so it can
+// be restored again later
+func RestoreVersion(backup PotatocordBackup) error {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	Log.Info("Restoring", Ternary(backup.Hash != "", backup.Hash, "unknown version"), "from", backup.Time.Format(time.DateTime))
+	// Set it aside first, so retaining the installed version can't prune it
+	tmp := backup.File + ".restoring"
+	if err = os.Rename(backup.File, tmp); err != nil {
+		return errors.New("Failed to restore " + backup.File + ": " + err.Error())
+	}
+	defer os.Remove(tmp)
+
+	manifest := ReadManifest()
+	if i := SliceIndexFunc(manifest.Backups, func(b PotatocordBackup) bool { return b.File == backup.File }); i != -1 {
+		manifest.Backups = append(manifest.Backups[:i], manifest.Backups[i+1:]...)
+	}
+	if err = manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+	if err = BackupPotatocordFile(); err != nil {
+		Log.Warn("Failed to back up the installed Potatocord version:", err)
+	}
+
+	if err = restorePotatocordFile(tmp); err != nil {
+		// Keep it retained
+		if os.Rename(tmp, backup.File) == nil {
+			manifest = ReadManifest()
+			manifest.Backups = append([]PotatocordBackup{backup}, manifest.Backups...)
+			_ = manifest.Save()
+		}
+		return errors.New("Failed to restore " + backup.File + ": " + err.Error())
+	}
+	_ = FixOwnership(PotatocordDirectory)
+	fixWrittenFile(PotatocordDirectory)
+	recordRemoved(backup.File)
+
+	manifest = ReadManifest()
+	manifest.recordRestoredHash(backup.Hash)
+	if err = manifest.Save(); err != nil {
+		Log.Warn("Failed to update install manifest:", err)
+	}
+	recordInstalledBuild(backup.Hash, false)
+
+	InstalledHash = Ternary(backup.Hash != "", backup.Hash, "None")
+	return nil
+}
+
+// recordRestoredHash updates the version of every patched install, as they load whatever is installed and restoring
+// doesn't re-patch them
+func (m *InstallManifest) recordRestoredHash(hash string) {
+	for dir := range m.Patched {
+		if hash == "" {
+			delete(m.Patched, dir)
+		} else {
+			m.Patched[dir] = hash
+		}
+	}
+	for dir, injection := range m.Injections {
+		injection.Hash = hash
+		m.Injections[dir] = injection
+	}
+}
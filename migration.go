@@ -0,0 +1,259 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"regexp"
+
+	"github.com/ProtonMail/go-appdir"
+)
+
+// Vencord settings that only work with Vencord. Its cloud sync authenticates against Vencord's servers
+var incompatibleVencordSettings = []string{"cloud"}
+
+var requireRe = regexp.MustCompile(`require\(("(?:[^"\\]|\\.)*")\)`)
+
+// VencordMigration moves an existing Vencord install over to Potatocord
+type VencordMigration struct {
+	// Discord installs Vencord is injected into
+	Installs []*DiscordInstall
+	// Vencord's data directory with its settings. Empty if it wasn't found or is shared with Potatocord already
+	DataDir string
+	// The installed Vencord build, if known
+	Hash string
+}
+
+// FindVencordMigration looks for Discord installs with Vencord injected. Returns nil if there are none
+func FindVencordMigration() *VencordMigration {
+	m := &VencordMigration{}
+	for _, d := range FindDiscords() {
+		di := d.(*DiscordInstall)
+		mods := findVencordInjections(di)
+		if len(mods) == 0 {
+			continue
+		}
+		m.Installs = append(m.Installs, di)
+
+		for _, mod := range mods {
+			if patcher := readRequiredPath(mod.File); patcher != "" && m.DataDir == "" {
+				m.Hash = ReadAsarHash(patcher)
+				// Vencord is either injected from BaseDir/vencord.asar or, in older versions, BaseDir/dist/patcher.js
+				dir := path.Dir(patcher)
+				if path.Base(dir) == "dist" {
+					dir = path.Dir(dir)
+				}
+				if ExistsFile(path.Join(dir, "settings")) {
+					m.DataDir = dir
+				}
+			}
+		}
+	}
+	if len(m.Installs) == 0 {
+		return nil
+	}
+
+	if m.DataDir == "" {
+		if dir := appdir.New("Vencord").UserConfig(); ExistsFile(path.Join(dir, "settings")) {
+			m.DataDir = dir
+		}
+	}
+	// VENCORD_USER_DATA_DIR makes us use Vencord's data directory, so there is nothing to carry over
+	if m.DataDir != "" && path.Clean(m.DataDir) == path.Clean(BaseDir) {
+		m.DataDir = ""
+	}
+
+	Log.Info("Found Vencord", m.Hash, "in", len(m.Installs), "Discord installs. Data:", m.DataDir)
+	return m
+}
+
+func findVencordInjections(di *DiscordInstall) []ConflictingMod {
+	var mods []ConflictingMod
+	for _, mod := range FindConflictingMods(di) {
+		if mod.Name == "Vencord" {
+			mods = append(mods, mod)
+		}
+	}
+	return mods
+}
+
+// readRequiredPath returns the file the injection in file (or in the index.js of the folder file) loads
+func readRequiredPath(file string) string {
+	if IsDirectory(file) {
+		file = path.Join(file, "index.js")
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	match := requireRe.FindSubmatch(b)
+	if match == nil {
+		return ""
+	}
+	var p string
+	if err = json.Unmarshal(match[1], &p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// Migrate carries over Vencord's settings, replaces Vencord's injections with Potatocord and patches every install
+// it was injected into. Vencord's injected files are kept with disabledModSuffix and its data directory is left
+// untouched, so going back is possible
+func (m *VencordMigration) Migrate() error {
+	defer WithLogContext("migrate")()
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var errs []error
+	if m.DataDir != "" {
+		if err := migrateVencordData(m.DataDir); err != nil {
+			errs = append(errs, errors.New("Failed to carry over Vencord's settings: "+err.Error()))
+		}
+	}
+
+	for _, di := range m.Installs {
+		if err := migrateVencordInstall(di); err != nil {
+			errs = append(errs, errors.New("Failed to migrate "+di.path+": "+err.Error()))
+		}
+	}
+
+	if len(errs) == 0 {
+		Log.Info("Successfully migrated from Vencord")
+	}
+	return errors.Join(errs...)
+}
+
+func migrateVencordInstall(di *DiscordInstall) error {
+	for _, mod := range findVencordInjections(di) {
+		// Unpatching replaces the injected app.asar with Discord's own, so keep a copy first
+		if !IsDirectory(mod.File) && !ExistsFile(mod.File+disabledModSuffix) {
+			if err := copyFile(mod.File, mod.File+disabledModSuffix); err != nil {
+				return err
+			}
+		}
+		if err := mod.Disable(); err != nil {
+			return err
+		}
+	}
+
+	// Disabling may have unpatched it, so look at what is actually there now
+	fresh := ParseDiscord(di.path, di.branch)
+	if fresh == nil {
+		return errors.New(di.path + " is no longer a valid Discord install")
+	}
+	if err := fresh.patch(); err != nil {
+		return err
+	}
+	*di = *fresh
+	return nil
+}
+
+// migrateVencordData copies Vencord's settings, QuickCSS and themes to Potatocord. Anything Potatocord already has
+// is kept, so migrating twice or after setting up Potatocord doesn't lose anything
+func migrateVencordData(dataDir string) error {
+	Log.Info("Carrying over settings from", dataDir)
+	src, dst := path.Join(dataDir, "settings"), path.Join(BaseDir, "settings")
+
+	if err := mergeVencordSettings(path.Join(src, "settings.json"), path.Join(dst, "settings.json")); err != nil {
+		return err
+	}
+
+	quickCss := path.Join(dst, "quickCss.css")
+	if stat, err := os.Stat(quickCss); err != nil || stat.Size() == 0 {
+		if err = copyFile(path.Join(src, "quickCss.css"), quickCss); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	themes, _ := os.ReadDir(path.Join(dataDir, "themes"))
+	for _, theme := range themes {
+		target := path.Join(BaseDir, "themes", theme.Name())
+		if theme.IsDir() || ExistsFile(target) {
+			continue
+		}
+		if err := copyFile(path.Join(dataDir, "themes", theme.Name()), target); err != nil {
+			return err
+		}
+	}
+
+	_ = FixOwnership(dst)
+	_ = FixOwnership(path.Join(BaseDir, "themes"))
+	return nil
+}
+
+// mergeVencordSettings adds every setting and plugin config from src that dst doesn't have yet
+func mergeVencordSettings(src, dst string) error {
+	b, err := os.ReadFile(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var vencord map[string]json.RawMessage
+	if err = json.Unmarshal(b, &vencord); err != nil {
+		return errors.New("Vencord's settings.json is corrupted: " + err.Error())
+	}
+
+	settings := map[string]json.RawMessage{}
+	if b, err = os.ReadFile(dst); err == nil {
+		if err = json.Unmarshal(b, &settings); err != nil {
+			return errors.New("Potatocord's settings.json is corrupted: " + err.Error())
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for key, value := range vencord {
+		if SliceContains(incompatibleVencordSettings, key) {
+			continue
+		}
+		existing, ok := settings[key]
+		if !ok {
+			settings[key] = value
+			continue
+		}
+		if key == "plugins" {
+			if settings[key], err = mergePluginSettings(existing, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	if b, err = json.MarshalIndent(settings, "", "    "); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, 0644)
+}
+
+func mergePluginSettings(potatocord, vencord json.RawMessage) (json.RawMessage, error) {
+	var ours, theirs map[string]json.RawMessage
+	if err := json.Unmarshal(potatocord, &ours); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(vencord, &theirs); err != nil {
+		return nil, err
+	}
+	if ours == nil {
+		ours = map[string]json.RawMessage{}
+	}
+	for name, config := range theirs {
+		if _, ok := ours[name]; !ok {
+			ours[name] = config
+		}
+	}
+	return json.Marshal(ours)
+}
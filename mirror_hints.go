@@ -0,0 +1,97 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MirrorHint is a mirror suggested by the release metadata for users in specific regions, e.g. where GitHub is throttled
+type MirrorHint struct {
+	Name string `json:"name"`
+	// Same layout as the AssetMirrors: <url>/<asset name>
+	Url string `json:"url"`
+	// Languages (ru), regions (IR) or both (zh_CN) the mirror is meant for
+	Locales []string `json:"locales"`
+}
+
+// Mirror hints of the latest release that match the user's locale and were neither accepted nor declined yet
+var PendingMirrorHints []MirrorHint
+
+// MatchesLocale reports whether the hint is meant for the given locale, e.g. en_US or zh-Hans-CN
+func (h MirrorHint) MatchesLocale(locale string) bool {
+	parts := strings.FieldsFunc(strings.ToLower(locale), func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+	if len(parts) == 0 {
+		return false
+	}
+	lang, region := parts[0], parts[len(parts)-1]
+
+	for _, l := range h.Locales {
+		l = strings.ToLower(strings.ReplaceAll(l, "-", "_"))
+		if l == lang || (len(parts) > 1 && l == region) || l == lang+"_"+region {
+			return true
+		}
+	}
+	return false
+}
+
+// findMirrorHints returns the hints of the metadata relevant to the user, skipping invalid ones and
+// those that were already accepted or declined
+func findMirrorHints(metadata *ReleaseMetadata) []MirrorHint {
+	if metadata == nil || len(metadata.Mirrors) == 0 {
+		return nil
+	}
+
+	locale := GetLanguage()
+	Log.Debug("User locale is", Ternary(locale == "", "unknown", locale))
+
+	var hints []MirrorHint
+	for _, hint := range metadata.Mirrors {
+		if u, err := url.Parse(hint.Url); err != nil || u.Scheme != "https" {
+			Log.Warn("Ignoring invalid mirror hint", hint.Url)
+			continue
+		}
+		hint.Url = strings.TrimSuffix(hint.Url, "/")
+		if !hint.MatchesLocale(locale) || SliceContains(getAssetMirrors(), hint.Url) ||
+			SliceContains(Settings.DeclinedMirrors, hint.Url) {
+			continue
+		}
+		hints = append(hints, hint)
+	}
+	return hints
+}
+
+// AcceptMirrorHints adds the pending hints to the mirrors and remembers the decision
+func AcceptMirrorHints() {
+	for _, hint := range PendingMirrorHints {
+		Log.Info("Adding mirror", hint.Name, "("+hint.Url+")")
+		Settings.AcceptedMirrors = append(Settings.AcceptedMirrors, hint.Url)
+	}
+	AddAssetMirrors(SliceMap(PendingMirrorHints, func(h MirrorHint) string { return h.Url }))
+	PendingMirrorHints = nil
+	saveMirrorDecision()
+
+	go ProbeMirrors()
+}
+
+// DeclineMirrorHints discards the pending hints and remembers not to suggest them again
+func DeclineMirrorHints() {
+	for _, hint := range PendingMirrorHints {
+		Settings.DeclinedMirrors = append(Settings.DeclinedMirrors, hint.Url)
+	}
+	PendingMirrorHints = nil
+	saveMirrorDecision()
+}
+
+func saveMirrorDecision() {
+	if err := Settings.Save(); err != nil {
+		Log.Warn("Failed to save installer settings:", err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const mirrorProbeTimeout = 5 * time.Second
+
+// Used to check that GitHub's release downloads are reachable
+const githubProbeUrl = "https://github.com"
+
+var (
+	fastestMirror     string
+	fastestMirrorLock sync.Mutex
+	assetMirrorsLock  sync.Mutex
+)
+
+// ProbeMirrors concurrently measures how long the release and all AssetMirrors take to answer for the latest asar and
+// remembers the fastest, which GetAssetUrls then tries first. Only mirrors that actually have the asar count. Does
+// nothing if there are no mirrors to choose from or the release isn't known yet
+func ProbeMirrors() {
+	defer WithLogContext("mirrors")()
+	mirrors := getAssetMirrors()
+	asset := findAsarAsset(&ReleaseData)
+	if len(mirrors) == 0 || asset == nil {
+		return
+	}
+
+	type result struct {
+		mirror  string
+		latency time.Duration
+	}
+
+	// The release's own download url is the empty mirror
+	candidates := append([]string{""}, mirrors...)
+	results := make(chan result, len(candidates))
+	client := http.Client{Timeout: mirrorProbeTimeout}
+
+	for _, mirror := range candidates {
+		go func(mirror string) {
+			start := time.Now()
+			req, err := http.NewRequest("HEAD", Ternary(mirror == "", asset.DownloadURL, mirror+"/"+asset.Name), nil)
+			if err != nil {
+				results <- result{mirror, -1}
+				return
+			}
+			req.Header.Set("User-Agent", UserAgent)
+
+			res, err := client.Do(req)
+			if err == nil {
+				_ = res.Body.Close()
+				if res.StatusCode < 200 || res.StatusCode >= 300 {
+					err = errors.New(res.Status)
+				}
+			}
+			if err != nil {
+				Log.Debug("Mirror", mirrorName(mirror), "is unreachable:", err)
+				results <- result{mirror, -1}
+				return
+			}
+			results <- result{mirror, time.Since(start)}
+		}(mirror)
+	}
+
+	best := result{latency: -1}
+	for range candidates {
+		r := <-results
+		if r.latency < 0 {
+			continue
+		}
+		Log.Debug("Mirror", mirrorName(r.mirror), "responded in", r.latency)
+		if best.latency < 0 || r.latency < best.latency {
+			best = r
+		}
+	}
+
+	if best.latency < 0 {
+		Log.Debug("No mirror responded")
+		return
+	}
+
+	Log.Debug("Using fastest mirror", mirrorName(best.mirror), "("+best.latency.Round(time.Millisecond).String()+")")
+	fastestMirrorLock.Lock()
+	fastestMirror = best.mirror
+	fastestMirrorLock.Unlock()
+}
+
+func mirrorName(mirror string) string {
+	return Ternary(mirror == "", "GitHub", mirror)
+}
+
+func getAssetMirrors() []string {
+	assetMirrorsLock.Lock()
+	defer assetMirrorsLock.Unlock()
+	return append([]string(nil), AssetMirrors...)
+}
+
+// AddAssetMirrors adds mirrors that aren't known yet
+func AddAssetMirrors(mirrors []string) {
+	assetMirrorsLock.Lock()
+	defer assetMirrorsLock.Unlock()
+	for _, mirror := range mirrors {
+		if !SliceContains(AssetMirrors, mirror) {
+			AssetMirrors = append(AssetMirrors, mirror)
+		}
+	}
+}
+
+func getFastestMirror() string {
+	fastestMirrorLock.Lock()
+	defer fastestMirrorLock.Unlock()
+	return fastestMirror
+}
@@ -0,0 +1,150 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+)
+
+// The running mod can ask the installer daemon (--daemon) to act on its behalf, e.g. for an "Update" button inside
+// Discord. It does so by dropping a ModRequest as <id>.json into ModRequestDir. Once handled, the request file is
+// deleted and a ModResponse is written to <id>.result.json, which the mod should delete after reading it.
+// Only the user (and thus the mod) can write to BaseDir, so no further authentication is needed
+const (
+	ModRequestPollInterval = 3 * time.Second
+	// Requests older than this are dropped, so a daemon started later doesn't act on forgotten requests
+	modRequestMaxAge = 10 * time.Minute
+)
+
+const (
+	ModActionUpdate = "update" // Install the latest Potatocord build
+	ModActionRepair = "repair" // Verify and reinstall Potatocord and re-apply it to the requesting Discord
+)
+
+type ModRequest struct {
+	Action string `json:"action"`
+	// The branch of the Discord the mod runs in, used to find the install to repair
+	Branch string `json:"branch"`
+}
+
+type ModResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	// The installed Potatocord version after handling the request
+	Hash string `json:"hash"`
+}
+
+func getModRequestDir() string {
+	return path.Join(BaseDir, "requests")
+}
+
+// WatchModRequests polls for and handles requests from the mod. It never returns
+func WatchModRequests() {
+	defer WithLogContext("requests")()
+	dir := getModRequestDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		Log.Error("Failed to create", dir+", requests from Potatocord won't be handled:", err)
+		return
+	}
+	_ = FixOwnership(dir)
+
+	Log.Debug("Watching", dir, "for requests")
+	for {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".result.json") {
+				continue
+			}
+			handleModRequestFile(path.Join(dir, name))
+		}
+		time.Sleep(ModRequestPollInterval)
+	}
+}
+
+func handleModRequestFile(file string) {
+	defer os.Remove(file)
+	defer WithLogContext(strings.TrimSuffix(path.Base(file), ".json"))()
+
+	if stat, err := os.Stat(file); err != nil || time.Since(stat.ModTime()) > modRequestMaxAge {
+		Log.Debug("Dropping stale request", file)
+		return
+	}
+
+	var req ModRequest
+	b, err := os.ReadFile(file)
+	if err == nil {
+		err = json.Unmarshal(b, &req)
+	}
+	if err == nil {
+		Log.Info("Potatocord requested", req.Action, "for Discord", req.Branch)
+		err = handleModRequest(req)
+	}
+
+	res := ModResponse{Ok: err == nil, Hash: ReadInstalledHash()}
+	if err != nil {
+		Log.Error("Failed to handle request", path.Base(file)+":", err)
+		res.Error = err.Error()
+	}
+
+	out := strings.TrimSuffix(file, ".json") + ".result.json"
+	if b, err = json.Marshal(res); err == nil {
+		err = os.WriteFile(out, b, 0600)
+	}
+	if err != nil {
+		Log.Error("Failed to write", out+":", err)
+	}
+	_ = FixOwnership(out)
+}
+
+func handleModRequest(req ModRequest) error {
+	if IsDevInstall {
+		return errors.New("Dev installs can't be updated by the installer")
+	}
+
+	// Re-patching after Discord updates reads the release data while holding the lock too
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	data, err := FetchLatestRelease()
+	if err != nil {
+		return err
+	}
+	ReleaseData = *data
+	LatestHash = GetReleaseHash(data)
+
+	switch req.Action {
+	case ModActionUpdate:
+		if HashesMatch(LatestHash, ReadInstalledHash()) {
+			Log.Info("Potatocord is already up to date")
+			return nil
+		}
+		return installLatestBuilds()
+	case ModActionRepair:
+		var target *DiscordInstall
+		for _, d := range FindDiscords() {
+			if di := d.(*DiscordInstall); di.branch == req.Branch && di.isPatched {
+				target = di
+				break
+			}
+		}
+		if target == nil {
+			return errors.New("No patched Discord " + req.Branch + " found")
+		}
+		return target.Repair()
+	default:
+		return errors.New("Unknown action " + req.Action)
+	}
+}
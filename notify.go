@@ -0,0 +1,178 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+type NotificationEvent string
+
+const (
+	EventUpdateAvailable NotificationEvent = "update"
+	EventInstallBroken   NotificationEvent = "broken"
+	EventRepatched       NotificationEvent = "repatched"
+)
+
+type Notifier interface {
+	Notify(event NotificationEvent, title, body string) error
+}
+
+// Notifiers by name, as used in POTATOCORD_NOTIFY
+var notifierFactories = map[string]func() (Notifier, error){
+	"desktop": func() (Notifier, error) {
+		return DesktopNotifier{}, nil
+	},
+	"stdout": func() (Notifier, error) {
+		return StdoutNotifier{}, nil
+	},
+	"webhook": func() (Notifier, error) {
+		url := EnvWebhookUrl.Get()
+		if url == "" {
+			return nil, errors.New("POTATOCORD_WEBHOOK_URL is not set")
+		}
+		return WebhookNotifier{Url: url}, nil
+	},
+}
+
+const defaultNotifiers = "desktop"
+
+// GetNotifiers returns the notifiers configured for the given event. POTATOCORD_NOTIFY_<EVENT> (e.g. POTATOCORD_NOTIFY_UPDATE)
+// takes precedence over POTATOCORD_NOTIFY, both are comma separated lists of desktop, stdout and webhook. Use "none" to disable
+func GetNotifiers(event NotificationEvent) []Notifier {
+	names := os.Getenv(EnvNotify.Name + "_" + strings.ToUpper(string(event)))
+	if names == "" {
+		names = EnvNotify.Get()
+	}
+	if names == "" {
+		names = defaultNotifiers
+	}
+
+	var notifiers []Notifier
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" || name == "none" {
+			continue
+		}
+
+		factory, ok := notifierFactories[name]
+		if !ok {
+			Log.Warn("Unknown notifier", name)
+			continue
+		}
+
+		notifier, err := factory()
+		if err != nil {
+			Log.Warn("Failed to set up notifier", name+":", err)
+			continue
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers
+}
+
+// Notify sends a notification to all notifiers configured for the event
+func Notify(event NotificationEvent, title, body string) {
+	for _, notifier := range GetNotifiers(event) {
+		if err := notifier.Notify(event, title, body); err != nil {
+			Log.Warn("Failed to send notification:", err)
+		}
+	}
+}
+
+type StdoutNotifier struct{}
+
+func (StdoutNotifier) Notify(event NotificationEvent, title, body string) error {
+	_, err := fmt.Printf("[%s] %s: %s\n", event, title, body)
+	return err
+}
+
+// WebhookNotifier posts notifications to a webhook. Discord webhooks are sent as message content,
+// anything else receives the body as plain text with the title in the Title header, which is what ntfy expects
+type WebhookNotifier struct {
+	Url string
+}
+
+func (n WebhookNotifier) Notify(event NotificationEvent, title, body string) error {
+	var req *http.Request
+	var err error
+
+	if strings.Contains(n.Url, "discord.com/api/webhooks/") || strings.Contains(n.Url, "discordapp.com/api/webhooks/") {
+		payload, _ := json.Marshal(map[string]string{
+			"username": "Potatocord Installer",
+			"content":  "**" + title + "**\n" + body,
+		})
+		req, err = http.NewRequest("POST", n.Url, bytes.NewReader(payload))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+	} else {
+		req, err = http.NewRequest("POST", n.Url, strings.NewReader(body))
+		if err == nil {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+			req.Header.Set("Title", title)
+			req.Header.Set("Tags", string(event))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errors.New("Webhook returned " + res.Status)
+	}
+	return nil
+}
+
+const windowsNotificationScript = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:POTATOCORD_NOTIFY_TITLE, $env:POTATOCORD_NOTIFY_BODY, 'Info')
+Start-Sleep -Seconds 10
+$n.Dispose()`
+
+type DesktopNotifier struct{}
+
+func (DesktopNotifier) Notify(_ NotificationEvent, title, body string) error {
+	Log.Debug("Sending desktop notification:", title, "-", body)
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsNotificationScript)
+		// Pass via env so we don't have to worry about quoting
+		cmd.Env = append(os.Environ(), "POTATOCORD_NOTIFY_TITLE="+title, "POTATOCORD_NOTIFY_BODY="+body)
+		return cmd.Start()
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", "display notification "+appleScriptQuote(body)+" with title "+appleScriptQuote(title))
+	default:
+		cmd = exec.Command("notify-send", "--app-name=Potatocord Installer", title, body)
+	}
+	return cmd.Run()
+}
+
+// appleScriptQuote quotes s as AppleScript string. Unlike strconv.Quote, it leaves non-ASCII characters like emoji
+// or zero width joiners alone, as AppleScript doesn't understand \u escapes
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,60 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// How long notify-only mode trusts the cached latest release before fetching it again
+const latestReleaseCacheMaxAge = 6 * time.Hour
+
+// RunNotifyOnly sends a notification if Potatocord is outdated. It is meant for login scripts, so it never downloads
+// or modifies anything and uses the cached latest release while it is fresh, which keeps it fast and offline most of
+// the time. Returns whether an update is available
+func RunNotifyOnly() (bool, error) {
+	if isDevInstallEnv() {
+		Log.Debug("Not checking for updates as this is a dev install")
+		return false, nil
+	}
+
+	installed := ReadInstalledHash()
+	if installed == "" {
+		Log.Debug("Potatocord is not installed, nothing to check")
+		return false, nil
+	}
+
+	latest, err := getCachedLatestHash()
+	if err != nil {
+		return false, err
+	}
+
+	if HashesMatch(latest, installed) {
+		Log.Info("Potatocord", installed, "is up to date")
+		return false, nil
+	}
+
+	Log.Info("Potatocord", latest, "is available, installed is", installed)
+	Notify(EventUpdateAvailable, "Potatocord update available", "Potatocord "+latest+" is available. Run the installer to update.")
+	return true, nil
+}
+
+func getCachedLatestHash() (string, error) {
+	if data, cachedAt, err := ReadCachedRelease(); err == nil && time.Since(cachedAt) < latestReleaseCacheMaxAge {
+		return GetReleaseHash(data), nil
+	}
+
+	data, err := FetchLatestRelease()
+	if err != nil {
+		return "", errors.New("Failed to check for updates: " + err.Error())
+	}
+
+	latest := GetReleaseHash(data)
+	CacheRelease(data, latest)
+	return latest, nil
+}
@@ -0,0 +1,27 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "testing"
+
+func TestAppleScriptQuote(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"Discord", `"Discord"`},
+		{"/Applications/Discord PTB.app", `"/Applications/Discord PTB.app"`},
+		{`Say "hi"`, `"Say \"hi\""`},
+		{`C:\back\slash`, `"C:\\back\\slash"`},
+		{"/Users/jürgen/Discord.app", `"/Users/jürgen/Discord.app"`},
+		{"Potatocord updated 🥔‍🔥", `"Potatocord updated 🥔‍🔥"`},
+	}
+	for _, test := range tests {
+		if got := appleScriptQuote(test.s); got != test.want {
+			t.Errorf("appleScriptQuote(%q) = %s, want %s", test.s, got, test.want)
+		}
+	}
+}
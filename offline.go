@@ -0,0 +1,55 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// Read by every request, from whatever goroutine makes it
+var offline atomic.Bool
+
+func init() {
+	offline.Store(EnvOffline.IsEnabled())
+}
+
+// IsOffline reports whether the installer works from the cache alone, without any network requests. See UseOffline
+func IsOffline() bool {
+	return offline.Load()
+}
+
+// UseOffline stops all network requests. The release data and builds are taken from the cache instead, so only what
+// was downloaded before can be installed
+func UseOffline() {
+	offline.Store(true)
+	Log.Debug("Offline mode, only using the cache at", CacheDir)
+}
+
+// offlineTransport fails every request in offline mode, so nothing reaches the network even where the installer
+// doesn't check IsOffline itself
+type offlineTransport struct {
+	http.RoundTripper
+}
+
+func (t offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if IsOffline() {
+		return nil, withClass(ErrNotCached, errors.New("Not connecting to "+req.URL.Host+" in offline mode"))
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
+
+// errReleaseNotCached is why there is no release data in offline mode
+func errReleaseNotCached() error {
+	return withClass(ErrNotCached, errors.New("No release data is cached, so nothing can be installed offline. Run once without --offline first"))
+}
+
+// errBuildNotCached is why a build can't be installed in offline mode
+func errBuildNotCached(hash string) error {
+	return withClass(ErrNotCached, errors.New("Potatocord "+hash+" isn't cached, so it isn't available offline. Run once without --offline first"))
+}
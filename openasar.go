@@ -64,6 +64,11 @@ func (di *DiscordInstall) IsOpenAsar() (retBool bool) {
 }
 
 func (di *DiscordInstall) InstallOpenAsar() error {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
 	PreparePatch(di)
 
 	dir := path.Join(di.appPath, "..")
@@ -92,12 +97,22 @@ func (di *DiscordInstall) InstallOpenAsar() error {
 	if _, err = io.Copy(outFile, res.Body); err != nil {
 		return err
 	}
+	recordTouchedFiles(false, asarFile.Name())
+	recordTouchedFiles(true, path.Join(dir, "app.asar.backup"))
+	if err = ResignDiscord(di); err != nil {
+		Log.Warn(err)
+	}
 
 	di.isOpenAsar = Ptr(true)
 	return nil
 }
 
 func (di *DiscordInstall) UninstallOpenAsar() error {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
 	PreparePatch(di)
 
 	dir := path.Join(di.appPath, "..")
@@ -117,6 +132,12 @@ func (di *DiscordInstall) UninstallOpenAsar() error {
 		if err = os.Rename(file, asarFile.Name()); err != nil {
 			return err
 		}
+		forgetTouchedFiles(file, asarFile.Name())
+		recordRestored(asarFile.Name())
+		recordRemoved(file)
+		if err = ResignDiscord(di); err != nil {
+			Log.Warn(err)
+		}
 
 		di.isOpenAsar = Ptr(false)
 		return nil
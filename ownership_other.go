@@ -0,0 +1,46 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"os/user"
+	path "path/filepath"
+	"strconv"
+)
+
+// chownTo gives p and everything in it to the user
+func chownTo(p, username string) error {
+	Log.Debug("Fixing Ownership of", p)
+
+	Log.Debug("Looking up User", username)
+	u, err := user.Lookup(username)
+	if err != nil {
+		Log.Error("Lookup failed:", err)
+		return err
+	}
+	Log.Debug("Lookup successful, Uid", u.Uid, "Gid", u.Gid)
+	// This conversion is safe because of the build constraint above
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+
+	err = path.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		if err == nil {
+			err = os.Chown(path, uid, gid)
+			Log.Debug("chown", u.Uid+":"+u.Gid, path+":", Ternary(err == nil, "Success!", "Failed"))
+		}
+		return err
+	})
+
+	if err != nil {
+		Log.Error("Failed to fix ownership:", err)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"io/fs"
+	"os/user"
+	path "path/filepath"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// Making another account the owner of a file requires SeRestorePrivilege, which administrators have but is disabled
+var enableRestorePrivilege = sync.OnceValue(func() error {
+	var token windows.Token
+	if err := windows.OpenProcessToken(windows.CurrentProcess(), windows.TOKEN_ADJUST_PRIVILEGES|windows.TOKEN_QUERY, &token); err != nil {
+		return err
+	}
+	defer token.Close()
+
+	var luid windows.LUID
+	if err := windows.LookupPrivilegeValue(nil, windows.StringToUTF16Ptr("SeRestorePrivilege"), &luid); err != nil {
+		return err
+	}
+	privileges := windows.Tokenprivileges{
+		PrivilegeCount: 1,
+		Privileges:     [1]windows.LUIDAndAttributes{{Luid: luid, Attributes: windows.SE_PRIVILEGE_ENABLED}},
+	}
+	return windows.AdjustTokenPrivileges(token, false, &privileges, 0, nil, nil)
+})
+
+// chownTo makes the user the owner of p and everything in it
+func chownTo(p, username string) error {
+	Log.Debug("Fixing Ownership of", p)
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		Log.Error("Lookup failed:", err)
+		return err
+	}
+	// On Windows, the Uid is the account's SID
+	sid, err := windows.StringToSid(u.Uid)
+	if err != nil {
+		return err
+	}
+	if err = enableRestorePrivilege(); err != nil {
+		Log.Error("Failed to enable SeRestorePrivilege:", err)
+		return err
+	}
+
+	err = path.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+		if err == nil {
+			err = windows.SetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION, sid, nil, nil, nil)
+			Log.Debug("Owner", u.Username, path+":", Ternary(err == nil, "Success!", "Failed"))
+		}
+		return err
+	})
+
+	if err != nil {
+		Log.Error("Failed to fix ownership:", err)
+	}
+	return err
+}
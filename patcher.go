@@ -20,32 +20,55 @@ import (
 var BaseDir string
 var PotatocordDirectory string
 
+// Why BaseDir can't be used, reported once the flags are parsed
+var BaseDirError error
+
 func init() {
 	detectDevMode()
 
-	if dir := os.Getenv("POTATOCORD_USER_DATA_DIR"); dir != "" {
-		Log.Debug("Using POTATOCORD_USER_DATA_DIR")
-		BaseDir = dir
-	} else if dir := os.Getenv("VENCORD_USER_DATA_DIR"); dir != "" {
-		Log.Debug("Using VENCORD_USER_DATA_DIR")
+	if dir, name := EnvUserDataDir.Lookup(); dir != "" {
+		Log.Debug("Using", name)
 		BaseDir = dir
-	} else if dir = os.Getenv("DISCORD_USER_DATA_DIR"); dir != "" {
+	} else if dir = EnvDiscordUserDataDir.Get(); dir != "" {
 		Log.Debug("Using DISCORD_USER_DATA_DIR/../PotatocordData")
 		BaseDir = path.Join(dir, "..", "PotatocordData")
 	} else {
 		Log.Debug("Using UserConfig")
 		BaseDir = appdir.New("Potatocord").UserConfig()
 	}
+	// Unlike the cache, settings and data in the temp directory would be lost without anyone noticing
+	if !IsWritable(BaseDir) {
+		BaseDirError = errors.New(BaseDir + " is not writable, so Potatocord's settings and data can't be stored there. Set " +
+			EnvUserDataDir.Name + " to a writable directory")
+	}
 
-	if dir := os.Getenv("POTATOCORD_DIRECTORY"); dir != "" {
-		Log.Debug("Using POTATOCORD_DIRECTORY")
-		PotatocordDirectory = dir
-	} else if dir := os.Getenv("VENCORD_DIRECTORY"); dir != "" {
-		Log.Debug("Using VENCORD_DIRECTORY")
+	Config = ReadConfig()
+	UseConfigLogLevel()
+	UseLogFile()
+	Settings = ReadSettings()
+	UseLanguage(GetLanguage())
+
+	if dir, name := EnvDirectory.Lookup(); dir != "" {
+		Log.Debug("Using", name)
 		PotatocordDirectory = dir
+	} else if dir := EnvDevBuild.Get(); dir != "" {
+		Log.Debug("Using POTATOCORD_DEV_BUILD")
+		if err := UseDevBuild(dir); err != nil {
+			Log.Warn("Ignoring POTATOCORD_DEV_BUILD:", err)
+			PotatocordDirectory = getDefaultPotatocordFile()
+		}
+	} else if dir := EnvInstallDir.Get(); dir != "" {
+		Log.Debug("Using POTATOCORD_INSTALL_DIR")
+		PotatocordDirectory = path.Join(dir, potatocordAsarName)
+	} else if Settings.InstallDir != "" {
+		Log.Debug("Using custom install directory", Settings.InstallDir)
+		PotatocordDirectory = path.Join(Settings.InstallDir, potatocordAsarName)
 	} else {
-		PotatocordDirectory = path.Join(BaseDir, "potatocord.asar")
+		PotatocordDirectory = getDefaultPotatocordFile()
 	}
+
+	CacheDir = writableDirOr(appdir.New("Potatocord").UserCache(), "PotatocordCache")
+	BackupDir = path.Join(BaseDir, "backups")
 }
 
 func detectDevMode() {
@@ -70,17 +93,15 @@ func detectDevMode() {
 			return
 		}
 
-		os.Setenv("POTATOCORD_DEV_INSTALL", "1")
-		// maintain compatibility with Vencord env vars if needed
-		os.Setenv("VENCORD_DEV_INSTALL", "1")
+		os.Setenv(EnvDevInstall.Name, "1")
 
-		if os.Getenv("POTATOCORD_USER_DATA_DIR") == "" && os.Getenv("VENCORD_USER_DATA_DIR") == "" {
-			os.Setenv("POTATOCORD_USER_DATA_DIR", abs)
+		if !EnvUserDataDir.IsSet() {
+			os.Setenv(EnvUserDataDir.Name, abs)
 		}
 
-		if os.Getenv("POTATOCORD_DIRECTORY") == "" && os.Getenv("VENCORD_DIRECTORY") == "" {
+		if !EnvDevBuild.IsSet() {
 			// In potatocord, entries are in dist/patcher.js
-			os.Setenv("POTATOCORD_DIRECTORY", path.Join(abs, "dist", "patcher.js"))
+			os.Setenv(EnvDevBuild.Name, path.Join(abs, "dist"))
 		}
 	}
 }
@@ -91,6 +112,8 @@ type DiscordInstall struct {
 	appPath          string // List of app folder to patch
 	isPatched        bool
 	isFlatpak        bool
+	isSnap           bool // Read-only, can't be patched
+	isStore          bool // Microsoft Store package, can't be patched either
 	isSystemElectron bool // Needs special care https://aur.archlinux.org/packages/discord_arch_electron
 	isOpenAsar       *bool
 }
@@ -105,16 +128,17 @@ func patchAppAsar(dir string, isSystemElectron bool) (err error) {
 	defer func() {
 		if err != nil && len(renamesDone) > 0 {
 			Log.Error("Failed to patch. Undoing partial patch")
-			for _, rename := range renamesDone {
-				if innerErr := os.Rename(rename[1], rename[0]); innerErr != nil {
-					Log.Error("Failed to undo partial patch. This install is probably bricked.", innerErr)
-				} else {
-					Log.Info("Successfully undid all changes")
-				}
-			}
+			undoRenames(renamesDone)
 		}
 	}()
 
+	if err := ValidateDiscordAsar(appAsar); err != nil {
+		return errors.New("Refusing to patch, Discord's app.asar looks broken: " + err.Error())
+	}
+	if NeedsElevation(dir) {
+		return patchAppAsarPrivileged(dir, isSystemElectron)
+	}
+
 	Log.Debug("Renaming", appAsar, "to", _appAsar)
 	if err := os.Rename(appAsar, _appAsar); err != nil {
 		err = CheckIfErrIsCauseItsBusyRn(err)
@@ -134,26 +158,122 @@ func patchAppAsar(dir string, isSystemElectron bool) (err error) {
 	}
 
 	Log.Debug("Writing custom app.asar to", appAsar)
-	if err := WriteAppAsar(appAsar, PotatocordDirectory); err != nil {
+	if err := retryOnSharingViolation(appAsar, func() error {
+		return WriteAppAsar(appAsar, PotatocordDirectory)
+	}); err != nil {
+		return antivirusError(appAsar, err)
+	}
+
+	return verifyPatchedAsars(appAsar, _appAsar)
+}
+
+// verifyPatchedAsars makes sure Discord will find both our injection and its own app where it expects them
+func verifyPatchedAsars(appAsar, _appAsar string) error {
+	ReportStage(StageVerify, "Verifying "+appAsar)
+	if err := CheckNotQuarantined(appAsar); err != nil {
 		return err
 	}
+	if err := verifyInjectedAsar(appAsar, PotatocordDirectory); err != nil {
+		return withClass(ErrVerificationFailed, errors.New("The written app.asar is broken: "+err.Error()))
+	}
+	if err := ValidateDiscordAsar(_appAsar); err != nil {
+		return withClass(ErrVerificationFailed, errors.New("Discord's app.asar broke while patching: "+err.Error()))
+	}
+	return nil
+}
 
+// patchAppAsarPrivileged patches an install we can't write to, like one in /opt, with a single elevation
+func patchAppAsarPrivileged(dir string, isSystemElectron bool) error {
+	appAsar := path.Join(dir, "app.asar")
+	_appAsar := path.Join(dir, "_app.asar")
+
+	ops := []PrivilegedOp{{Op: "rename", Src: appAsar, Dst: _appAsar}}
+	undo := []PrivilegedOp{{Op: "rename", Src: _appAsar, Dst: appAsar}}
+	if isSystemElectron {
+		ops = append(ops, PrivilegedOp{Op: "rename", Src: appAsar + ".unpacked", Dst: _appAsar + ".unpacked"})
+		undo = append(undo, PrivilegedOp{Op: "rename", Src: _appAsar + ".unpacked", Dst: appAsar + ".unpacked"})
+	}
+	ops = append(ops, PrivilegedOp{Op: "write", Dst: appAsar, Data: BuildAppAsar(PotatocordDirectory)})
+
+	Log.Debug("Patching", dir, "with administrator rights")
+	if err := RunPrivileged(ops); err != nil {
+		return err
+	}
+	if err := verifyPatchedAsars(appAsar, _appAsar); err != nil {
+		Log.Error("Failed to patch. Undoing partial patch")
+		if undoErr := RunPrivileged(undo); undoErr != nil {
+			Log.Error("Failed to undo partial patch. This install is probably bricked.", undoErr)
+		}
+		return err
+	}
 	return nil
 }
 
+// needsLatestBuilds returns whether patching installs the latest build first. Without release data, e.g. when
+// re-patching in the background while offline, the installed build has to do
+func needsLatestBuilds(installedHash string) bool {
+	return !IsDevInstall && !HashesMatch(LatestHash, installedHash) && (GithubError == nil || !ExistsFile(PotatocordDirectory))
+}
+
+// patch injects Potatocord into the install, running the configured install hooks around it
 func (di *DiscordInstall) patch() error {
+	return withInstallHooks(di, di.applyPatch)
+}
+
+func (di *DiscordInstall) applyPatch() (err error) {
+	defer WithLogContext("patch:" + di.branch)()
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
 	Log.Info("Patching " + di.path + "...")
-	if LatestHash != InstalledHash {
-		if err := InstallLatestBuilds(); err != nil {
-			return nil // already shown dialog so don't return same error again
+	if di.isSnap {
+		return ErrSnapReadOnly
+	}
+	if di.isStore {
+		return ErrStoreReadOnly
+	}
+
+	// Whatever fails, don't leave the install half patched or with a build it wasn't patched for
+	var tx InstallTransaction
+	defer func() {
+		err = tx.Finish(err)
+	}()
+
+	if needsLatestBuilds(InstalledHash) {
+		// Patching with the installed build instead would look like it worked
+		if err := tx.Do("install latest builds", InstallLatestBuilds, undoInstallLatestBuilds()); err != nil {
+			return err
+		}
+	}
+
+	if DevBuildDir != "" {
+		if err := LinkDevBuild(); err != nil {
+			return err
 		}
 	}
 
 	PreparePatch(di)
+	ReportProgressMessage(StagePatch, 0, 1, "Patching Discord "+di.branch)
 
+	if err := BackupStockAsar(di); err != nil {
+		Log.Warn("Failed to back up stock app.asar:", err)
+	}
+
+	previousHash := ReadManifest().Patched[di.resourcesDir()]
 	if di.isPatched {
 		Log.Info(di.path, "is already patched. Unpatching first...")
-		if err := di.unpatch(); err != nil {
+		if err := tx.Do("unpatch", di.unpatch, func() error {
+			if err := di.patchAsar(); err != nil {
+				return err
+			}
+			di.isPatched = true
+			if previousHash != "" {
+				recordPatchedHash(di, previousHash)
+			}
+			return nil
+		}); err != nil {
 			if errors.Is(err, os.ErrPermission) {
 				return err
 			}
@@ -161,61 +281,107 @@ func (di *DiscordInstall) patch() error {
 		}
 	}
 
-	if di.isSystemElectron {
-		if err := patchAppAsar(di.path, true); err != nil {
+	if err := tx.Do("inject", di.patchAsar, func() error {
+		if err := di.unpatchAsar(); err != nil {
 			return err
 		}
-	} else {
-		if err := patchAppAsar(path.Join(di.appPath, ".."), false); err != nil {
-			return err
+		di.isPatched = false
+		if !IsDevInstall {
+			recordPatchedHash(di, previousHash)
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	Log.Info("Successfully patched", di.path)
+	fixWrittenFile(path.Join(di.resourcesDir(), "app.asar"))
+	if err := ResignDiscord(di); err != nil {
+		Log.Warn(err)
+	}
+	ReportProgress(StagePatch, 1, 1)
 	di.isPatched = true
+	if !IsDevInstall {
+		recordPatchedHash(di, InstalledHash)
+	} else {
+		// Dev installs aren't recorded in the manifest
+		recordWritten(path.Join(di.resourcesDir(), "app.asar"), path.Join(di.resourcesDir(), "_app.asar"))
+	}
 
 	if di.isFlatpak {
-		pathElements := strings.Split(di.path, "/")
-		var name string
-		for _, e := range pathElements {
-			if strings.HasPrefix(e, "com.discordapp") {
-				name = e
-				break
+		Log.Debug("This is a flatpak. Trying to grant the Flatpak access to", PotatocordDirectory+"...")
+		if err := di.flatpakOverride("--filesystem=" + PotatocordDirectory); err != nil {
+			return errors.New("Failed to grant Discord Flatpak access to " + PotatocordDirectory + ": " + err.Error())
+		}
+		// The sandbox can't follow the dev link without access to where it points
+		if DevBuildDir != "" {
+			if err := di.flatpakOverride("--filesystem=" + DevBuildDir); err != nil {
+				return errors.New("Failed to grant Discord Flatpak access to " + DevBuildDir + ": " + err.Error())
 			}
 		}
+	}
 
-		Log.Debug("This is a flatpak. Trying to grant the Flatpak access to", PotatocordDirectory+"...")
+	rememberDiscord(di)
+	return nil
+}
 
-		isSystemFlatpak := strings.HasPrefix(di.path, "/var")
-		var args []string
-		if !isSystemFlatpak {
-			args = append(args, "--user")
+// patchAsar injects Potatocord into the app.asar of the install
+func (di *DiscordInstall) patchAsar() error {
+	if di.isSystemElectron {
+		return patchAppAsar(di.path, true)
+	}
+	return patchAppAsar(path.Join(di.appPath, ".."), false)
+}
+
+// flatpakId returns the Flatpak app id, e.g. com.discordapp.Discord
+func (di *DiscordInstall) flatpakId() string {
+	for _, e := range strings.Split(di.path, "/") {
+		if strings.HasPrefix(e, "com.discordapp") {
+			return e
 		}
-		args = append(args, "override", name, "--filesystem="+PotatocordDirectory)
-		fullCmd := "flatpak " + strings.Join(args, " ")
+	}
+	return ""
+}
 
-		Log.Debug("Running", fullCmd)
+// flatpakOverride runs flatpak override with the given argument for this Flatpak install. User overrides also apply
+// to apps from system installations, so this changes the overrides of the actual user, even if we are root.
+// Only installs patched for all users change the system overrides, which apply to everyone
+func (di *DiscordInstall) flatpakOverride(arg string) error {
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		return errors.New("flatpak is not installed or not in PATH")
+	}
 
-		var err error
-		if !isSystemFlatpak && os.Getuid() == 0 {
-			// We are operating on a user flatpak but are root
-			actualUser := os.Getenv("SUDO_USER")
-			Log.Debug("This is a user install but we are root. Using su to run as", actualUser)
-			cmd := exec.Command("su", "-", actualUser, "-c", "sh", "-c", fullCmd)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			err = cmd.Run()
-		} else {
-			cmd := exec.Command("flatpak", args...)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			err = cmd.Run()
-		}
-		if err != nil {
-			return errors.New("Failed to grant Discord Flatpak access to " + PotatocordDirectory + ": " + err.Error())
+	if CurrentScope == ScopeSystem {
+		Log.Debug("Running flatpak --system override", di.flatpakId(), arg)
+		if out, err := exec.Command("flatpak", "--system", "override", di.flatpakId(), arg).CombinedOutput(); err != nil {
+			return errors.New(strings.TrimSpace(string(out)))
 		}
+		return nil
 	}
-	return nil
+	return runAsActualUser("flatpak", "--user", "override", di.flatpakId(), arg)
+}
+
+// runAsActualUser runs the command as the user who started the installer, even if it was started with sudo
+func runAsActualUser(name string, args ...string) error {
+	cmd := actualUserCommand(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func actualUserCommand(name string, args ...string) *exec.Cmd {
+	if actualUser := os.Getenv("SUDO_USER"); actualUser != "" && os.Getuid() == 0 {
+		Log.Debug("We are root. Using su to run as", actualUser)
+		fullCmd := strings.Join(SliceMap(Prepend(args, name), shellQuote), " ")
+		Log.Debug("Running", fullCmd)
+		return exec.Command("su", "-", actualUser, "-c", fullCmd)
+	}
+	Log.Debug("Running", name, strings.Join(args, " "))
+	return exec.Command(name, args...)
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 //endregion
@@ -227,17 +393,28 @@ func unpatchAppAsar(dir string, isSystemElectron bool) (errOut error) {
 	appAsarTmp := path.Join(dir, "app.asar.tmp")
 	_appAsar := path.Join(dir, "_app.asar")
 
+	// Rather stay patched than leave Discord with an app.asar it can't start from
+	if err := ValidateDiscordAsar(_appAsar); err != nil {
+		return errors.New("Refusing to unpatch, Discord's original app.asar is broken: " + err.Error())
+	}
+
+	if NeedsElevation(dir) {
+		ops := []PrivilegedOp{
+			{Op: "rename", Src: appAsar, Dst: appAsarTmp},
+			{Op: "rename", Src: _appAsar, Dst: appAsar},
+		}
+		if isSystemElectron {
+			ops = append(ops, PrivilegedOp{Op: "rename", Src: _appAsar + ".unpacked", Dst: appAsar + ".unpacked"})
+		}
+		Log.Debug("Unpatching", dir, "with administrator rights")
+		return RunPrivileged(append(ops, PrivilegedOp{Op: "remove", Dst: appAsarTmp}))
+	}
+
 	var renamesDone [][]string
 	defer func() {
 		if errOut != nil && len(renamesDone) > 0 {
 			Log.Error("Failed to unpatch. Undoing partial unpatch")
-			for _, rename := range renamesDone {
-				if innerErr := os.Rename(rename[1], rename[0]); innerErr != nil {
-					Log.Error("Failed to undo partial unpatch. This install is probably bricked.", innerErr)
-				} else {
-					Log.Info("Successfully undid all changes")
-				}
-			}
+			undoRenames(renamesDone)
 		} else if errOut == nil {
 			if innerErr := os.RemoveAll(appAsarTmp); innerErr != nil {
 				Log.Warn("Failed to delete temporary app.asar (patch folder) backup. This is whatever but you might want to delete it manually.", innerErr)
@@ -268,29 +445,62 @@ func unpatchAppAsar(dir string, isSystemElectron bool) (errOut error) {
 		if err := os.Rename(_appAsar+".unpacked", appAsar+".unpacked"); err != nil {
 			Log.Error(err.Error())
 			errOut = err
+		} else {
+			renamesDone = append(renamesDone, []string{_appAsar + ".unpacked", appAsar + ".unpacked"})
 		}
 	}
 	return
 }
 
+// undoRenames renames the files back, last rename first, so no rename overwrites a file an earlier one moved back
+func undoRenames(renamesDone [][]string) {
+	for i := len(renamesDone) - 1; i >= 0; i-- {
+		rename := renamesDone[i]
+		if err := os.Rename(rename[1], rename[0]); err != nil {
+			Log.Error("Failed to undo "+rename[0]+" -> "+rename[1]+". This install is probably bricked.", err)
+			return
+		}
+	}
+	Log.Info("Successfully undid all changes")
+}
+
 func (di *DiscordInstall) unpatch() error {
+	defer WithLogContext("unpatch:" + di.branch)()
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
 	Log.Info("Unpatching " + di.path + "...")
+	ReportStage(StageUnpatch, "Unpatching Discord "+di.branch)
 
 	PreparePatch(di)
 
-	if di.isSystemElectron {
-		if err := unpatchAppAsar(di.path, true); err != nil {
-			return err
-		}
-	} else {
-		if err := unpatchAppAsar(path.Join(di.appPath, ".."), false); err != nil {
-			return err
+	if _appAsar := path.Join(di.resourcesDir(), "_app.asar"); di.isPatched && !ExistsFile(_appAsar) {
+		if err := RestoreStockAsar(di, _appAsar); err != nil {
+			Log.Warn("Stock app.asar is missing and couldn't be restored from backup:", err)
 		}
 	}
 
+	if err := di.unpatchAsar(); err != nil {
+		return err
+	}
+
 	Log.Info("Successfully unpatched", di.path)
+	if err := ResignDiscord(di); err != nil {
+		Log.Warn(err)
+	}
 	di.isPatched = false
+	recordPatchedHash(di, "")
 	return nil
 }
 
+// unpatchAsar restores Discord's own app.asar of the install
+func (di *DiscordInstall) unpatchAsar() error {
+	if di.isSystemElectron {
+		return unpatchAppAsar(di.path, true)
+	}
+	return unpatchAppAsar(path.Join(di.appPath, ".."), false)
+}
+
 //endregion
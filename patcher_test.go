@@ -0,0 +1,92 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"testing"
+)
+
+func TestUnpatchKeepsInstallIfOriginalIsBroken(t *testing.T) {
+	dir := t.TempDir()
+	appAsar := path.Join(dir, "app.asar")
+	_appAsar := path.Join(dir, "_app.asar")
+	potatocordFile := path.Join(dir, "potatocord.asar")
+
+	if err := WriteAppAsar(appAsar, potatocordFile); err != nil {
+		t.Fatal(err)
+	}
+	broken := []byte("not an asar")
+	if err := os.WriteFile(_appAsar, broken, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unpatchAppAsar(dir, false); err == nil {
+		t.Fatal("unpatching with a broken original app.asar succeeded")
+	}
+
+	if err := verifyInjectedAsar(appAsar, potatocordFile); err != nil {
+		t.Error("patched app.asar was changed:", err)
+	}
+	if b, err := os.ReadFile(_appAsar); err != nil || string(b) != string(broken) {
+		t.Error("original app.asar was changed:", err)
+	}
+	if _, err := os.Stat(path.Join(dir, "app.asar.tmp")); !os.IsNotExist(err) {
+		t.Error("app.asar.tmp was left behind")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"/usr/bin/flatpak", `'/usr/bin/flatpak'`},
+		{"/home/me/My Discord", `'/home/me/My Discord'`},
+		{"it's", `'it'\''s'`},
+		{"/home/jürgen/ユーザー", `'/home/jürgen/ユーザー'`},
+		{"$HOME `id` \\ \"*\"", "'$HOME `id` \\ \"*\"'"},
+		{"", `''`},
+	}
+	sh, shErr := exec.LookPath("sh")
+	for _, test := range tests {
+		got := shellQuote(test.s)
+		if got != test.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", test.s, got, test.want)
+		}
+		// The shell has to get back exactly what was quoted
+		if shErr == nil {
+			if out, err := exec.Command(sh, "-c", "printf %s "+got).Output(); err != nil || string(out) != test.s {
+				t.Errorf("sh unquoted %s to %q, %v", got, out, err)
+			}
+		}
+	}
+}
+
+func TestUndoRenamesInReverse(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string) string { return path.Join(dir, name) }
+
+	// Like unpatching: app.asar is moved away, then _app.asar takes its place
+	os.WriteFile(file("app.asar"), []byte("patched"), 0644)
+	os.WriteFile(file("_app.asar"), []byte("original"), 0644)
+	renames := [][]string{{file("app.asar"), file("app.asar.tmp")}, {file("_app.asar"), file("app.asar")}}
+	for _, rename := range renames {
+		if err := os.Rename(rename[0], rename[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	undoRenames(renames)
+
+	for name, want := range map[string]string{"app.asar": "patched", "_app.asar": "original"} {
+		if b, err := os.ReadFile(file(name)); err != nil || string(b) != want {
+			t.Errorf("%s = %q, %v; want %q", name, b, err, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+// RegisterDiscord remembers a Discord install outside the known locations, like a portable one on a USB drive, so
+// it is patched, updated, repaired and uninstalled like any other install
+func RegisterDiscord(p string) (*DiscordInstall, error) {
+	di, err := ParseDiscordLocation(p)
+	if err != nil {
+		return nil, err
+	}
+	// Broken installs can still be repaired or unpatched when given by location, but aren't worth remembering
+	if err = ValidateDiscordAsar(path.Join(di.resourcesDir(), Ternary(di.isPatched, "_app.asar", "app.asar"))); err != nil {
+		return nil, errors.New(di.path + " is a broken Discord install: " + err.Error())
+	}
+
+	if !SliceContains(Settings.CustomDiscords, di.path) {
+		Log.Info("Registering Discord install at", di.path)
+		Settings.CustomDiscords = append(Settings.CustomDiscords, di.path)
+		if err = Settings.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return di, nil
+}
+
+// UnregisterDiscord forgets a registered install. It isn't unpatched
+func UnregisterDiscord(p string) error {
+	abs, err := path.Abs(p)
+	if err != nil {
+		return err
+	}
+	i := SliceIndex(Settings.CustomDiscords, abs)
+	if i == -1 {
+		return errors.New(abs + " is not registered")
+	}
+	Settings.CustomDiscords = append(Settings.CustomDiscords[:i], Settings.CustomDiscords[i+1:]...)
+	return Settings.Save()
+}
+
+// findRegisteredDiscords returns the registered installs that are currently available. Portable installs on
+// removable drives come and go, so missing ones are kept registered
+func findRegisteredDiscords() []any {
+	var discords []any
+	for _, p := range Settings.CustomDiscords {
+		if SliceContains(Config.Discords, p) {
+			continue
+		}
+		if di := ParseDiscord(p, ""); di != nil {
+			Log.Debug("Found registered Discord install at", p)
+			discords = append(discords, di)
+		} else {
+			Log.Debug("Registered Discord install", p, "is not available")
+		}
+	}
+	for _, p := range Config.Discords {
+		if di := ParseDiscord(p, ""); di != nil {
+			Log.Debug("Found Discord install from the config file at", p)
+			discords = append(discords, di)
+		} else {
+			Log.Warn("Discord install", p, "from the config file is not available")
+		}
+	}
+	return discords
+}
+
+// rememberDiscord registers installs outside the known locations once they were patched, as updating or
+// uninstalling wouldn't find them otherwise
+func rememberDiscord(di *DiscordInstall) {
+	if SliceContains(Settings.CustomDiscords, di.path) || SliceContains(Config.Discords, di.path) {
+		return
+	}
+	for _, dir := range getDiscordParentDirs() {
+		if strings.HasPrefix(di.path, dir+string(os.PathSeparator)) {
+			return
+		}
+	}
+	if _, err := RegisterDiscord(di.path); err != nil {
+		Log.Warn("Failed to remember the Discord install at", di.path+":", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "golang.org/x/sys/unix"
+
+func LowerProcessPriority() {
+	if err := unix.Setpriority(unix.PRIO_PROCESS, 0, 10); err != nil {
+		Log.Debug("Failed to lower process priority:", err)
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+import "golang.org/x/sys/windows"
+
+func LowerProcessPriority() {
+	if err := windows.SetPriorityClass(windows.CurrentProcess(), windows.BELOW_NORMAL_PRIORITY_CLASS); err != nil {
+		Log.Debug("Failed to lower process priority:", err)
+	}
+}
@@ -0,0 +1,305 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+// The installer re-runs itself elevated with this argument, the PrivilegedOps to perform and the file to write the
+// result to, to perform only those ops
+const elevatedHelperArg = "--elevated-helper"
+
+// PrivilegedOp is a single file operation the elevated helper performs
+type PrivilegedOp struct {
+	// mkdir, rename, copy, write or remove
+	Op   string `json:"op"`
+	Src  string `json:"src,omitempty"`
+	Dst  string `json:"dst"`
+	Data []byte `json:"data,omitempty"`
+	// The mode and owner copied and written files get. defaultFileAttrs if unset
+	Attrs *FileAttrs `json:"attrs,omitempty"`
+}
+
+func (op PrivilegedOp) attrs() FileAttrs {
+	if op.Attrs != nil {
+		return *op.Attrs
+	}
+	return defaultFileAttrs
+}
+
+type privilegedResult struct {
+	Error string `json:"error,omitempty"`
+}
+
+// NeedsElevation reports whether writing to dir requires running ops through the elevated helper
+func NeedsElevation(dir string) bool {
+	return !IsElevated() && !IsWritable(dir)
+}
+
+// RunPrivileged performs the ops in order. Unless we already are elevated, only they are run with administrator
+// rights, by starting the installer elevated, which asks the user to authenticate once for all ops. If any op fails,
+// the ones before it are undone
+func RunPrivileged(ops []PrivilegedOp) error {
+	if IsElevated() {
+		recordElevated()
+		return applyPrivilegedOps(ops)
+	}
+
+	tmp, err := os.MkdirTemp("", "potatocord-elevated-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// The ops are passed as argument, as a file could be changed after the user authenticated
+	resultFile := path.Join(tmp, "result.json")
+	Log.Info("Asking for administrator rights to modify", ops[len(ops)-1].Dst)
+	if err = runElevated(exe, elevatedHelperArg, base64.StdEncoding.EncodeToString(b), resultFile); err != nil {
+		Log.Debug("Elevated helper failed:", err)
+	}
+
+	b, err = os.ReadFile(resultFile)
+	if err != nil {
+		return withClass(ErrElevationDenied, errors.New("Administrator rights were denied, or the elevated helper failed to start"))
+	}
+	var result privilegedResult
+	if err = json.Unmarshal(b, &result); err != nil {
+		return err
+	}
+	// Whatever the helper did, it did as administrator
+	recordElevated()
+	if result.Error != "" {
+		return errors.New(result.Error)
+	}
+	return nil
+}
+
+// RunElevatedHelper is the entry point of the elevated helper. It performs the given ops, writes the result to the
+// given file and exits. Returns if the installer wasn't started as helper
+func RunElevatedHelper() {
+	if len(os.Args) != 4 || os.Args[1] != elevatedHelperArg {
+		return
+	}
+	defer WithLogContext("elevated")()
+
+	resultFile := os.Args[3]
+	var result privilegedResult
+	var ops []PrivilegedOp
+	b, err := base64.StdEncoding.DecodeString(os.Args[2])
+	if err == nil {
+		err = json.Unmarshal(b, &ops)
+	}
+	if err == nil {
+		err = checkPrivilegedOps(ops)
+	}
+	if err == nil {
+		err = applyPrivilegedOps(ops)
+	}
+	if err != nil {
+		Log.Error(err)
+		result.Error = err.Error()
+	}
+
+	b, _ = json.Marshal(result)
+	// Created exclusively, so a link put there can't make us overwrite another file
+	f, err := os.OpenFile(resultFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err == nil {
+		_, err = f.Write(b)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		Log.Error("Failed to write result:", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// checkPrivilegedOps makes sure the ops only modify Discord installs and Potatocord's own files. Whoever started the
+// helper may have put anything into them, and it must not be usable for modifying anything else as administrator
+func checkPrivilegedOps(ops []PrivilegedOp) error {
+	for _, op := range ops {
+		switch op.Op {
+		case "mkdir", "rename", "copy", "write", "remove":
+		default:
+			return errors.New("Unknown privileged operation " + op.Op)
+		}
+
+		// The directory Potatocord is installed to may be anywhere, but is only created along with installing it
+		createsPotatocordDir := op.Op == "mkdir" && SliceContainsFunc(ops, func(other PrivilegedOp) bool {
+			return isPotatocordFile(other.Dst) && path.Dir(other.Dst) == op.Dst
+		})
+		if !isPrivilegedTarget(op.Dst) && !createsPotatocordDir {
+			return errors.New("Refusing to modify " + op.Dst + " as administrator, it is neither part of a Discord install nor a Potatocord file")
+		}
+
+		switch op.Op {
+		case "rename":
+			if !isPrivilegedTarget(op.Src) {
+				return errors.New("Refusing to move " + op.Src + " as administrator, it is neither part of a Discord install nor a Potatocord file")
+			}
+		case "copy":
+			// Only Potatocord builds are copied, which also keeps other files from being read as administrator
+			if stat, err := os.Lstat(op.Src); err != nil || !stat.Mode().IsRegular() {
+				return errors.New("Refusing to copy " + op.Src + " as administrator, it is not a regular file")
+			}
+			if err := ValidatePotatocordAsar(op.Src, ""); err != nil {
+				return errors.New("Refusing to copy " + op.Src + " as administrator: " + err.Error())
+			}
+		}
+
+		if a := op.Attrs; a != nil {
+			// Files keep their owner, or get the one new files there get anyway
+			owner, _ := getFileAttrs(op.Dst)
+			if a.Mode != a.Mode.Perm() || a.Uid != -1 && a.Uid != owner.Uid || a.Gid != -1 && a.Gid != owner.Gid {
+				return errors.New("Refusing to change the owner or special mode bits of " + op.Dst + " as administrator")
+			}
+		}
+	}
+	return nil
+}
+
+// isPrivilegedTarget reports whether p may be modified by the elevated helper: files of a Discord install, in the
+// machine-wide Potatocord directory or the Potatocord file wherever it is installed
+func isPrivilegedTarget(p string) bool {
+	if !path.IsAbs(p) || path.Clean(p) != p {
+		return false
+	}
+	if isPotatocordFile(p) || isWithinDir(p, getSystemBaseDir()) {
+		return true
+	}
+	for dir := path.Dir(p); dir != path.Dir(dir); dir = path.Dir(dir) {
+		if di := ParseDiscord(dir, ""); di != nil && !di.isStore {
+			return isWithinDir(p, di.resourcesDir())
+		}
+	}
+	return false
+}
+
+func isPotatocordFile(p string) bool {
+	name := path.Base(p)
+	return name == potatocordAsarName || name == potatocordAsarName+".corrupted"
+}
+
+// isWithinDir reports whether p is dir or inside it, after following links, which could point anywhere
+func isWithinDir(p, dir string) bool {
+	// p itself may not exist yet
+	if parent, err := path.EvalSymlinks(path.Dir(p)); err == nil {
+		p = path.Join(parent, path.Base(p))
+	}
+	if resolved, err := path.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return p == dir || strings.HasPrefix(p, dir+string(os.PathSeparator))
+}
+
+func applyPrivilegedOps(ops []PrivilegedOp) (err error) {
+	var undos []func()
+	defer func() {
+		if err != nil {
+			for i := len(undos) - 1; i >= 0; i-- {
+				undos[i]()
+			}
+		}
+	}()
+
+	for _, op := range ops {
+		Log.Debug("Running", op.Op, op.Src, op.Dst)
+		if err = retryOnSharingViolation(op.Dst, func() error { return applyPrivilegedOp(op, &undos) }); err != nil {
+			return antivirusError(op.Dst, err)
+		}
+	}
+	return
+}
+
+func applyPrivilegedOp(op PrivilegedOp, undos *[]func()) (err error) {
+	// Files that are created or overwritten can only be undone by removing what we wrote
+	removeIfNew := func(p string) {
+		if !ExistsFile(p) {
+			*undos = append(*undos, func() { _ = os.Remove(p) })
+		}
+	}
+
+	switch op.Op {
+	case "mkdir":
+		removeIfNew(op.Dst)
+		err = os.MkdirAll(op.Dst, 0755)
+	case "rename":
+		if err = os.Rename(op.Src, op.Dst); err == nil {
+			*undos = append(*undos, func() { _ = os.Rename(op.Dst, op.Src) })
+		}
+	case "copy":
+		removeIfNew(op.Dst)
+		if err = copyFile(op.Src, op.Dst); err == nil {
+			err = op.attrs().apply(op.Dst)
+		}
+		fixWrittenFile(op.Dst)
+	case "write":
+		removeIfNew(op.Dst)
+		if err = os.WriteFile(op.Dst, op.Data, 0644); err == nil {
+			err = op.attrs().apply(op.Dst)
+		}
+		fixWrittenFile(op.Dst)
+	case "remove":
+		err = os.RemoveAll(op.Dst)
+	default:
+		err = errors.New("Unknown privileged operation " + op.Op)
+	}
+	return
+}
+
+// EnsureDir creates dir along with any missing parents, elevating only if the closest existing parent isn't
+// writable. On fresh machines, even the parents of the default locations may be missing
+func EnsureDir(dir string) error {
+	if IsDirectory(dir) {
+		return nil
+	}
+	if NeedsElevation(dir) {
+		return RunPrivileged([]PrivilegedOp{{Op: "mkdir", Dst: dir}})
+	}
+
+	topmost := dir
+	for parent := path.Dir(topmost); parent != topmost && !ExistsFile(parent); parent = path.Dir(topmost) {
+		topmost = parent
+	}
+	Log.Debug("Creating", dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.New("Failed to create " + dir + ": " + err.Error())
+	}
+	// Like the files in them, directories in the user's home must belong to them, not to root
+	_ = FixOwnership(topmost)
+	return nil
+}
+
+// writeFilePrivileged writes data to file, elevating only if its directory isn't writable
+func writeFilePrivileged(file string, data []byte) error {
+	if !NeedsElevation(path.Dir(file)) {
+		if err := EnsureDir(path.Dir(file)); err != nil {
+			return err
+		}
+		return os.WriteFile(file, data, 0644)
+	}
+	return RunPrivileged([]PrivilegedOp{
+		{Op: "mkdir", Dst: path.Dir(file)},
+		{Op: "write", Dst: file, Data: data},
+	})
+}
@@ -0,0 +1,101 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// How long Discord gets to exit gracefully before it is killed
+const discordCloseTimeout = 10 * time.Second
+
+type DiscordProcess struct {
+	Pid int
+	Exe string
+}
+
+// IsRunning reports whether any process of this install is running
+func (di *DiscordInstall) IsRunning() bool {
+	return len(findDiscordProcesses(di)) != 0
+}
+
+// CloseDiscord closes all processes of this install and waits for them to exit, killing them if they don't exit
+// in time. Returns the executable Discord was running from, which can be passed to RelaunchDiscord, or an empty
+// string if it wasn't running
+func (di *DiscordInstall) CloseDiscord() (string, error) {
+	procs := findDiscordProcesses(di)
+	if len(procs) == 0 {
+		return "", nil
+	}
+
+	Log.Info("Closing Discord", di.branch, "("+di.path+")")
+	for _, p := range procs {
+		if proc, err := os.FindProcess(p.Pid); err == nil {
+			// Windows has no graceful equivalent
+			if runtime.GOOS == "windows" {
+				_ = proc.Kill()
+			} else {
+				_ = proc.Signal(syscall.SIGTERM)
+			}
+		}
+	}
+
+	for deadline := time.Now().Add(discordCloseTimeout); time.Now().Before(deadline); {
+		if !di.IsRunning() {
+			return procs[0].Exe, nil
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	Log.Warn("Discord didn't exit in time, killing it")
+	for _, p := range findDiscordProcesses(di) {
+		if proc, err := os.FindProcess(p.Pid); err == nil {
+			_ = proc.Kill()
+		}
+	}
+	time.Sleep(time.Second)
+
+	if di.IsRunning() {
+		return procs[0].Exe, errors.New("Failed to close Discord " + di.branch + ". Please close it manually")
+	}
+	return procs[0].Exe, nil
+}
+
+// RelaunchDiscord starts Discord the same way the user normally would, so shortcuts and protocol handlers keep
+// working. exe is the executable it was running from before, used if there is no better way to start it
+func (di *DiscordInstall) RelaunchDiscord(exe string) error {
+	if runtime.GOOS == "linux" && os.Getuid() == 0 {
+		return errors.New("Not starting Discord as root. Please start it yourself")
+	}
+
+	cmd := discordLaunchCommand(di, exe)
+	if cmd == nil {
+		return errors.New("Don't know how to start Discord " + di.branch)
+	}
+
+	Log.Info("Starting Discord", di.branch)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
+
+// RestartDiscord closes the install if it is running and starts it again, so it loads the newly installed build
+func (di *DiscordInstall) RestartDiscord() error {
+	exe, err := di.CloseDiscord()
+	if err != nil {
+		return err
+	}
+	return di.RelaunchDiscord(exe)
+}
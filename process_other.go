@@ -0,0 +1,20 @@
+//go:build !windows
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+func isProcessAlive(pid int) bool {
+	// Signal 0 only checks whether the process exists. EPERM means it does, but belongs to someone else
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,173 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Progress is reported in a machine readable format, so wrappers around the cli can show native progress bars
+// without parsing log output. Bump ProgressProtocolVersion on breaking changes to the json events
+const ProgressProtocolVersion = 1
+
+type ProgressFormat string
+
+const (
+	ProgressNone ProgressFormat = "none"
+	// One ProgressEvent per line on stdout
+	ProgressJson ProgressFormat = "json"
+	// OSC 9;4 escape sequences on stderr, as understood by Windows Terminal, ConEmu and others
+	ProgressAnsi ProgressFormat = "ansi"
+	// A progress bar on stderr that is redrawn in place, for people watching the terminal
+	ProgressBar ProgressFormat = "bar"
+)
+
+type ProgressStage string
+
+const (
+	StageFetch    ProgressStage = "fetch"
+	StageDownload ProgressStage = "download"
+	StagePatch    ProgressStage = "patch"
+	StageUnpatch  ProgressStage = "unpatch"
+	StageVerify   ProgressStage = "verify"
+	StageDone     ProgressStage = "done"
+	StageFailed   ProgressStage = "failed"
+)
+
+type ProgressEvent struct {
+	Version int           `json:"version"`
+	Stage   ProgressStage `json:"stage"`
+	// Bytes for downloads, steps otherwise. Total is -1 if unknown
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
+	// Done as a percentage of Total, or -1 if the total is unknown
+	Percent int `json:"percent"`
+	// What is being done, e.g. "Downloading Potatocord", or the error once failed. Meant for display, not parsing
+	Message string `json:"message,omitempty"`
+}
+
+var progressFormat = ProgressNone
+
+// The progress bar currently shown, which log lines are printed above. Guarded by logLock
+var progressBar string
+
+// Don't flood wrappers with an event per read
+const progressInterval = 100 * time.Millisecond
+
+func SetProgressFormat(format string) error {
+	switch f := ProgressFormat(format); f {
+	case ProgressNone, ProgressJson, ProgressAnsi, ProgressBar:
+		progressFormat = f
+		return nil
+	default:
+		return fmt.Errorf("Unknown progress format %s. Supported are none, json, ansi and bar", format)
+	}
+}
+
+func ReportProgress(stage ProgressStage, done, total int64) {
+	ReportProgressMessage(stage, done, total, "")
+}
+
+// ReportStage reports starting a stage whose progress isn't known
+func ReportStage(stage ProgressStage, message string) {
+	ReportProgressMessage(stage, 0, -1, message)
+}
+
+func ReportProgressMessage(stage ProgressStage, done, total int64, message string) {
+	e := ProgressEvent{ProgressProtocolVersion, stage, done, total, -1, message}
+	if total > 0 {
+		e.Percent = int(min(done*100/total, 100))
+	}
+	switch progressFormat {
+	case ProgressJson:
+		b, _ := json.Marshal(e)
+		logLock.Lock()
+		defer logLock.Unlock()
+		_, _ = fmt.Fprintln(os.Stdout, string(b))
+	case ProgressAnsi:
+		// OSC 9;4;<state>;<percent>, states are 0 hidden, 1 normal, 2 error, 3 indeterminate
+		state, percent := 1, int64(0)
+		switch {
+		case e.Stage == StageDone:
+			state = 0
+		case e.Stage == StageFailed:
+			state, percent = 2, 100
+		case e.Total <= 0:
+			state = 3
+		default:
+			percent = int64(e.Percent)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "\x1b]9;4;%d;%d\x07", state, percent)
+	case ProgressBar:
+		logLock.Lock()
+		defer logLock.Unlock()
+		if e.Stage == StageDone || e.Stage == StageFailed {
+			clearProgressBar()
+			return
+		}
+		progressBar = renderProgressBar(e)
+		_, _ = fmt.Fprint(os.Stderr, "\r\x1b[K"+progressBar)
+	}
+}
+
+// ClearProgress removes the progress bar once an operation is done
+func ClearProgress() {
+	logLock.Lock()
+	defer logLock.Unlock()
+	clearProgressBar()
+}
+
+func clearProgressBar() {
+	if progressBar != "" {
+		_, _ = fmt.Fprint(os.Stderr, "\r\x1b[K")
+		progressBar = ""
+	}
+}
+
+func renderProgressBar(e ProgressEvent) string {
+	const width = 30
+	message := Ternary(e.Message != "", e.Message, string(e.Stage))
+	if e.Percent < 0 {
+		return "[" + strings.Repeat("·", width) + "]      " + message
+	}
+	filled := e.Percent * width / 100
+	return fmt.Sprintf("[%s%s] %3d%% %s", strings.Repeat("█", filled), strings.Repeat(" ", width-filled), e.Percent, message)
+}
+
+// progressReader reports the progress of reading from r
+type progressReader struct {
+	r          io.Reader
+	stage      ProgressStage
+	message    string
+	done       int64
+	total      int64
+	lastReport time.Time
+}
+
+func newProgressReader(r io.Reader, stage ProgressStage, total int64, message string) io.Reader {
+	r = cancellableReader{r}
+	if progressFormat == ProgressNone {
+		return r
+	}
+	ReportProgressMessage(stage, 0, total, message)
+	return &progressReader{r: r, stage: stage, message: message, total: total, lastReport: time.Now()}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.done += int64(n)
+	if err == io.EOF || time.Since(p.lastReport) >= progressInterval {
+		p.lastReport = time.Now()
+		ReportProgressMessage(p.stage, p.done, p.total, p.message)
+	}
+	return n, err
+}
@@ -0,0 +1,49 @@
+//go:build cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/manifoldco/promptui"
+)
+
+// canPrompt reports whether there is someone to answer questions: stdin is a terminal and neither --yes nor --json
+// was passed
+func canPrompt() bool {
+	return !assumeDefaults && !jsonOutput && isTerminal(os.Stdin)
+}
+
+// ask asks a yes or no question and returns the answer. Ctrl+C exits
+func ask(label string, defaultYes bool) bool {
+	_, err := (&promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+		Default:   Ternary(defaultYes, "y", ""),
+	}).Run()
+	if err != nil && !errors.Is(err, promptui.ErrAbort) {
+		handlePromptError(err)
+	}
+	return err == nil
+}
+
+// confirm asks before destructive actions like uninstalling. With --yes, or without a terminal to ask in, it goes
+// ahead without asking, so scripts keep working
+func confirm(label string) bool {
+	return !canPrompt() || ask(label, false)
+}
+
+// confirmOrExit exits without changing anything if the user doesn't confirm
+func confirmOrExit(label string) {
+	if !confirm(label) {
+		Log.Info(T("Cancelled, nothing was changed"))
+		exit(ExitSuccess)
+	}
+}
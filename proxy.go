@@ -0,0 +1,182 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SystemProxyConfig is the proxy configured in the operating system's settings
+type SystemProxyConfig struct {
+	Http   string // host:port
+	Https  string // host:port
+	Bypass []string
+	PacUrl string
+}
+
+var (
+	systemProxyOnce   sync.Once
+	systemProxyConfig *SystemProxyConfig
+	pacProxy          *url.URL
+)
+
+var pacReturnRegex = regexp.MustCompile(`return\s*["']([^"']+)["']`)
+
+func init() {
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.Proxy = SystemProxy
+	}
+	// Only after setting the proxy, as the wrappers hide the *http.Transport
+	http.DefaultTransport = offlineTransport{debugTransport{http.DefaultTransport}}
+}
+
+// SystemProxy is like http.ProxyFromEnvironment, but if no proxy environment variables are set,
+// it uses the proxy from the config file or the system settings, so GUI users behind a proxy don't have to set any.
+// POTATOCORD_PROXY takes precedence over all of them
+func SystemProxy(req *http.Request) (*url.URL, error) {
+	if proxy := EnvProxy.Get(); proxy != "" {
+		return parseProxy(proxy)
+	}
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if os.Getenv(env) != "" {
+			return http.ProxyFromEnvironment(req)
+		}
+	}
+
+	if Config.Proxy != "" {
+		return parseProxy(Config.Proxy)
+	}
+
+	systemProxyOnce.Do(loadSystemProxy)
+	cfg := systemProxyConfig
+	if cfg == nil || isProxyBypassed(req.URL.Hostname(), cfg.Bypass) {
+		return nil, nil
+	}
+
+	if cfg.PacUrl != "" {
+		return pacProxy, nil
+	}
+
+	proxy := Ternary(req.URL.Scheme == "https" && cfg.Https != "", cfg.Https, cfg.Http)
+	if proxy == "" {
+		return nil, nil
+	}
+	return parseProxy(proxy)
+}
+
+// parseProxy parses a proxy url, which may omit the scheme like host:port
+func parseProxy(proxy string) (*url.URL, error) {
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+	return url.Parse(proxy)
+}
+
+func loadSystemProxy() {
+	cfg, err := GetSystemProxyConfig()
+	if err != nil {
+		Log.Debug("Failed to read system proxy settings:", err)
+		return
+	}
+	if cfg == nil {
+		return
+	}
+
+	Log.Debug("Using system proxy settings", *cfg)
+	systemProxyConfig = cfg
+
+	if cfg.PacUrl != "" {
+		if pacProxy, err = evaluatePac(cfg.PacUrl); err != nil {
+			Log.Warn("Failed to evaluate proxy auto-config", cfg.PacUrl+":", err)
+		} else {
+			Log.Debug("Proxy auto-config resolved to", Ternary(pacProxy == nil, "DIRECT", pacProxy.String()))
+		}
+	}
+}
+
+func isProxyBypassed(host string, bypass []string) bool {
+	for _, pattern := range bypass {
+		pattern = strings.TrimSpace(pattern)
+		switch {
+		case pattern == "":
+			continue
+		case pattern == "<local>":
+			if !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*"):
+			if strings.HasSuffix(host, pattern[1:]) {
+				return true
+			}
+		case strings.EqualFold(host, pattern):
+			return true
+		}
+	}
+	return false
+}
+
+// evaluatePac does a basic evaluation of a proxy auto-config file. There is no JavaScript engine, so instead of running
+// FindProxyForURL, its default result, the last return statement, is used. This covers the common "send everything
+// through the corporate proxy" setup. Returns nil if the result is DIRECT
+func evaluatePac(pacUrl string) (*url.URL, error) {
+	var script []byte
+	if path, ok := strings.CutPrefix(pacUrl, "file://"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		script = b
+	} else {
+		// The PAC itself must not be fetched through the proxy we are trying to figure out, but still not in offline mode
+		client := http.Client{Transport: offlineTransport{debugTransport{&http.Transport{}}}}
+		res, err := client.Get(pacUrl)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode >= 300 {
+			return nil, errors.New(res.Status)
+		}
+		if script, err = io.ReadAll(io.LimitReader(res.Body, 1024*1024)); err != nil {
+			return nil, err
+		}
+	}
+
+	returns := pacReturnRegex.FindAllSubmatch(script, -1)
+	if len(returns) == 0 {
+		return nil, errors.New("No return statement found")
+	}
+
+	// "PROXY a:1; PROXY b:2; DIRECT" - use the first option we understand
+	for _, option := range strings.Split(string(returns[len(returns)-1][1]), ";") {
+		fields := strings.Fields(option)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "DIRECT":
+			return nil, nil
+		case "PROXY", "HTTP":
+			if len(fields) > 1 {
+				return url.Parse("http://" + fields[1])
+			}
+		case "HTTPS":
+			if len(fields) > 1 {
+				return url.Parse("https://" + fields[1])
+			}
+		}
+	}
+	return nil, errors.New("Unsupported proxy auto-config result " + string(returns[len(returns)-1][1]))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// GetSystemProxyConfig reads the proxy settings of the current network service via scutil
+func GetSystemProxyConfig() (*SystemProxyConfig, error) {
+	out, err := exec.Command("scutil", "--proxy").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// <dictionary> {
+	//   ExceptionsList : <array> {
+	//     0 : *.local
+	//   }
+	//   HTTPEnable : 1
+	//   HTTPPort : 8080
+	//   HTTPProxy : proxy.example.com
+	// }
+	values := make(map[string]string)
+	cfg := &SystemProxyConfig{}
+	inExceptions := false
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "ExceptionsList") {
+			inExceptions = true
+			continue
+		}
+		if inExceptions {
+			if line == "}" {
+				inExceptions = false
+			} else if _, exception, ok := strings.Cut(line, " : "); ok {
+				cfg.Bypass = append(cfg.Bypass, exception)
+			}
+			continue
+		}
+		if key, value, ok := strings.Cut(line, " : "); ok {
+			values[key] = value
+		}
+	}
+
+	if values["HTTPEnable"] == "1" && values["HTTPProxy"] != "" {
+		cfg.Http = values["HTTPProxy"] + ":" + values["HTTPPort"]
+	}
+	if values["HTTPSEnable"] == "1" && values["HTTPSProxy"] != "" {
+		cfg.Https = values["HTTPSProxy"] + ":" + values["HTTPSPort"]
+	}
+	if values["ProxyAutoConfigEnable"] == "1" {
+		cfg.PacUrl = values["ProxyAutoConfigURLString"]
+	}
+
+	if cfg.PacUrl == "" && cfg.Http == "" && cfg.Https == "" {
+		return nil, nil
+	}
+	return cfg, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// GetSystemProxyConfig reads GNOME's proxy settings, which most other desktops also respect.
+// Everything else is expected to set the usual proxy environment variables
+func GetSystemProxyConfig() (*SystemProxyConfig, error) {
+	get := func(schema, key string) string {
+		out, err := exec.Command("gsettings", "get", schema, key).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.Trim(strings.TrimSpace(string(out)), "'")
+	}
+
+	mode := get("org.gnome.system.proxy", "mode")
+	switch mode {
+	case "auto":
+		if pac := get("org.gnome.system.proxy", "autoconfig-url"); pac != "" {
+			return &SystemProxyConfig{PacUrl: pac}, nil
+		}
+	case "manual":
+		cfg := &SystemProxyConfig{}
+		if host := get("org.gnome.system.proxy.http", "host"); host != "" {
+			cfg.Http = host + ":" + get("org.gnome.system.proxy.http", "port")
+		}
+		if host := get("org.gnome.system.proxy.https", "host"); host != "" {
+			cfg.Https = host + ":" + get("org.gnome.system.proxy.https", "port")
+		}
+		// ['localhost', '127.0.0.0/8']
+		for _, host := range strings.Split(strings.Trim(get("org.gnome.system.proxy", "ignore-hosts"), "[]"), ",") {
+			cfg.Bypass = append(cfg.Bypass, strings.Trim(strings.TrimSpace(host), "'"))
+		}
+		if cfg.Http != "" || cfg.Https != "" {
+			return cfg, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// GetSystemProxyConfig reads the WinINET proxy settings, which is what "Proxy settings" in the Windows settings changes
+func GetSystemProxyConfig() (*SystemProxyConfig, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\Internet Settings`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	cfg := &SystemProxyConfig{}
+
+	if pac, _, err := key.GetStringValue("AutoConfigURL"); err == nil && pac != "" {
+		cfg.PacUrl = pac
+	}
+
+	if enabled, _, err := key.GetIntegerValue("ProxyEnable"); err == nil && enabled != 0 {
+		server, _, _ := key.GetStringValue("ProxyServer")
+		// Either "host:port" for all protocols or "http=host:port;https=host:port"
+		if !strings.Contains(server, "=") {
+			cfg.Http, cfg.Https = server, server
+		} else {
+			for _, entry := range strings.Split(server, ";") {
+				protocol, proxy, _ := strings.Cut(entry, "=")
+				switch strings.ToLower(protocol) {
+				case "http":
+					cfg.Http = proxy
+				case "https":
+					cfg.Https = proxy
+				}
+			}
+		}
+
+		if override, _, err := key.GetStringValue("ProxyOverride"); err == nil {
+			cfg.Bypass = strings.Split(override, ";")
+		}
+	}
+
+	if cfg.PacUrl == "" && cfg.Http == "" && cfg.Https == "" {
+		return nil, nil
+	}
+	return cfg, nil
+}
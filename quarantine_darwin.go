@@ -0,0 +1,31 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	path "path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+const quarantineAttr = "com.apple.quarantine"
+
+// ClearQuarantine removes the quarantine attribute from p and, for folders, from everything inside. Files written
+// by an installer that was downloaded with a browser inherit it, and Gatekeeper may then block Discord from loading them
+func ClearQuarantine(p string) error {
+	return path.WalkDir(p, func(file string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = unix.Lremovexattr(file, quarantineAttr); err != nil && !errors.Is(err, unix.ENOATTR) {
+			return errors.New("Failed to clear quarantine of " + file + ": " + err.Error())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,14 @@
+//go:build !darwin
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+// ClearQuarantine is only needed on macOS, where Gatekeeper blocks quarantined files
+func ClearQuarantine(_ string) error {
+	return nil
+}
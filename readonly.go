@@ -0,0 +1,70 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	path "path/filepath"
+	"sync"
+)
+
+// IsWritable reports whether files can be created in dir, or in its closest existing parent if it doesn't exist yet
+func IsWritable(dir string) bool {
+	dir = findExistingDir(dir)
+	if dir == "" {
+		return false
+	}
+
+	f, err := os.CreateTemp(dir, ".potatocord-write-test")
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return true
+}
+
+// findExistingDir returns dir or, if it doesn't exist yet, its closest existing parent. Empty if there is none
+func findExistingDir(dir string) string {
+	for !ExistsFile(dir) {
+		parent := path.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	return dir
+}
+
+// writableDirOr returns dir if it is writable, otherwise a directory of the given name in the temp directory.
+// Used for the cache and logs, so the installer keeps working when started from a read-only medium or with a
+// read-only home
+func writableDirOr(dir, name string) string {
+	if IsWritable(dir) {
+		return dir
+	}
+	fallback := path.Join(os.TempDir(), name)
+	Log.Warn(dir, "is not writable, using", fallback, "instead")
+	return fallback
+}
+
+var isExeDirReadOnly = sync.OnceValue(func() bool {
+	exe, err := os.Executable()
+	if err != nil {
+		return false
+	}
+	readOnly := !IsWritable(path.Dir(exe))
+	if readOnly {
+		Log.Debug("Running from a read-only location:", path.Dir(exe))
+	}
+	return readOnly
+})
+
+// IsRunningFromReadOnly reports whether the installer was started from a read-only location like a mounted ISO or network share
+func IsRunningFromReadOnly() bool {
+	return isExeDirReadOnly()
+}
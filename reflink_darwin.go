@@ -0,0 +1,8 @@
+package main
+
+import "golang.org/x/sys/unix"
+
+// Reflink creates a copy-on-write clone of src at dst on APFS
+func Reflink(src, dst string) error {
+	return unix.Clonefile(src, dst, 0)
+}
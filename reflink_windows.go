@@ -0,0 +1,8 @@
+package main
+
+import "errors"
+
+// Reflink is not supported, as block cloning only exists on ReFS
+func Reflink(_, _ string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,272 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Discord's own download endpoint, which redirects to its CDN
+const discordDownloadUrl = "https://discord.com/api/download"
+
+// Hosts Discord's downloads may be served from. Anything else is refused, so a hijacked redirect can't make us
+// install something else as Discord
+var discordDownloadHosts = []string{"discord.com", "discordapp.net", "discordapp.com"}
+
+func isDiscordDownloadHost(host string) bool {
+	return SliceContainsFunc(discordDownloadHosts, func(h string) bool {
+		return host == h || strings.HasSuffix(host, "."+h)
+	})
+}
+
+// CheckDiscordHost checks that Discord's own files are intact enough to patch. Returns an error if its app.asar is
+// gone and there is no backup to restore it from, in which case only reinstalling Discord helps
+func (di *DiscordInstall) CheckDiscordHost() error {
+	dir := di.resourcesDir()
+	stock := path.Join(dir, Ternary(di.isPatched, "_app.asar", "app.asar"))
+	if ExistsFile(stock) {
+		return nil
+	}
+	if _, ok := readBackupIndex()[dir]; ok {
+		return nil
+	}
+	return errors.New("Discord's app.asar is missing from " + dir + " and there is no backup of it")
+}
+
+func getDiscordDownloadUrl(branch string) string {
+	u := discordDownloadUrl
+	if branch != "stable" {
+		u += "/" + branch
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		return u + "?platform=win"
+	case "darwin":
+		return u + "?platform=osx"
+	default:
+		return u + "?platform=linux&format=tar.gz"
+	}
+}
+
+func downloadDiscord(branch, dst string) error {
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, _ []*http.Request) error {
+			if !isDiscordDownloadHost(req.URL.Hostname()) {
+				return errors.New("Refusing to download Discord from " + req.URL.Host)
+			}
+			return nil
+		},
+	}
+
+	downloadUrl := getDiscordDownloadUrl(branch)
+	Log.Info("Downloading Discord", branch, "from", downloadUrl)
+	req, err := http.NewRequestWithContext(cancelCtx, "GET", downloadUrl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return cancelledOr(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return errors.New("Failed to download Discord: " + res.Status)
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, newProgressReader(res.Body, StageDownload, res.ContentLength, "Downloading Discord"))
+	return cancelledOr(err)
+}
+
+// ReinstallDiscord downloads the official Discord for the install's branch and installs it over the broken install.
+// Returns the fresh install, which is not patched yet
+func ReinstallDiscord(di *DiscordInstall) (*DiscordInstall, error) {
+	defer enterCancellable()()
+	if di.isSnap || di.isStore {
+		return nil, errors.New("Reinstall Discord from the store you installed it from")
+	}
+
+	if di.isFlatpak {
+		installation := Ternary(strings.HasPrefix(di.path, os.Getenv("HOME")), "--user", "--system")
+		if err := runAsActualUser("flatpak", "install", "--reinstall", "-y", installation, "flathub", di.flatpakId()); err != nil {
+			return nil, errors.New("Failed to reinstall the Discord Flatpak: " + err.Error())
+		}
+		return parseReinstalledDiscord(di)
+	}
+
+	if runtime.GOOS == "linux" && strings.HasPrefix(di.path, "/usr/") {
+		return nil, errors.New(di.path + " is managed by your package manager. Reinstall Discord with it")
+	}
+
+	tmp, err := os.MkdirTemp("", "potatocord-discord-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err = di.CloseDiscord(); err != nil {
+		return nil, err
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		setup := path.Join(tmp, "DiscordSetup.exe")
+		if err = downloadDiscord(di.branch, setup); err != nil {
+			return nil, err
+		}
+		Log.Info("Running Discord setup")
+		// Squirrel installs into the same place again, no matter where the broken install is
+		if err = exec.Command(setup).Run(); err != nil {
+			return nil, errors.New("Discord setup failed: " + err.Error())
+		}
+	case "darwin":
+		dmg := path.Join(tmp, "Discord.dmg")
+		if err = downloadDiscord(di.branch, dmg); err != nil {
+			return nil, err
+		}
+		err = installDiscordDmg(dmg, path.Join(tmp, "mnt"), di.path)
+	default:
+		archive := path.Join(tmp, "discord.tar.gz")
+		if err = downloadDiscord(di.branch, archive); err != nil {
+			return nil, err
+		}
+		err = installDiscordTarball(archive, di.path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseReinstalledDiscord(di)
+}
+
+func parseReinstalledDiscord(di *DiscordInstall) (*DiscordInstall, error) {
+	fresh := ParseDiscord(di.path, di.branch)
+	if fresh == nil {
+		return nil, errors.New("Discord was reinstalled, but " + di.path + " is still not a valid install")
+	}
+	Log.Info("Successfully reinstalled Discord", di.branch)
+	return fresh, nil
+}
+
+func installDiscordDmg(dmg, mountPoint, bundle string) error {
+	if out, err := exec.Command("hdiutil", "attach", "-nobrowse", "-readonly", "-mountpoint", mountPoint, dmg).CombinedOutput(); err != nil {
+		return errors.New("Failed to open Discord's disk image: " + strings.TrimSpace(string(out)))
+	}
+	defer exec.Command("hdiutil", "detach", mountPoint).Run()
+
+	return replaceDir(bundle, func(dst string) error {
+		// ditto keeps the code signature intact, which a plain copy doesn't
+		if out, err := exec.Command("ditto", path.Join(mountPoint, path.Base(bundle)), dst).CombinedOutput(); err != nil {
+			return errors.New(strings.TrimSpace(string(out)))
+		}
+		return nil
+	})
+}
+
+// installDiscordTarball replaces dir with the Discord folder in archive
+func installDiscordTarball(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+
+	return replaceDir(dir, func(dst string) error {
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// Strip the top level Discord/ folder
+			_, name, _ := strings.Cut(hdr.Name, "/")
+			if name == "" {
+				continue
+			}
+			target := path.Join(dst, name)
+			if !strings.HasPrefix(target, dst+string(os.PathSeparator)) {
+				return errors.New("Refusing to extract " + hdr.Name + " outside of " + dst)
+			}
+
+			switch hdr.Typeflag {
+			case tar.TypeDir:
+				err = os.MkdirAll(target, 0755)
+			case tar.TypeReg:
+				err = extractTarFile(tr, target, hdr.FileInfo().Mode())
+			case tar.TypeSymlink:
+				err = os.Symlink(hdr.Linkname, target)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+func extractTarFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(path.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
+
+// replaceDir fills a new directory next to dir using fill and swaps it with dir once complete, so a failure
+// halfway through leaves the old install in place
+func replaceDir(dir string, fill func(dst string) error) error {
+	tmp, err := os.MkdirTemp(path.Dir(dir), ".potatocord-reinstall-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	fresh := path.Join(tmp, path.Base(dir))
+	if err = fill(fresh); err != nil {
+		return errors.New("Failed to extract Discord: " + err.Error())
+	}
+
+	broken := path.Join(tmp, "broken")
+	if err = os.Rename(dir, broken); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err = os.Rename(fresh, dir); err != nil {
+		_ = os.Rename(broken, dir)
+		return err
+	}
+	_ = FixOwnership(dir)
+	fixWrittenFile(dir)
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// VerifyPotatocordFile checks that the installed Potatocord file is intact. If the release publishes a checksum,
+// the whole file is compared against it, otherwise only the embedded hash is checked
+func VerifyPotatocordFile() error {
+	stat, err := os.Stat(PotatocordDirectory)
+	if err != nil {
+		return errors.New(PotatocordDirectory + " is missing")
+	}
+	if stat.IsDir() {
+		// dev install, nothing to compare against
+		return nil
+	}
+
+	hash := ReadInstalledHash()
+	if hash == "" {
+		return errors.New(PotatocordDirectory + " is corrupted")
+	}
+	if !HashesMatch(LatestHash, hash) {
+		return errors.New(PotatocordDirectory + " is outdated")
+	}
+
+	// Without a published checksum, compare against the one recorded when the build was installed
+	checksum := ""
+	if installed := ReadManifest().Installed; installed != nil && installed.File == PotatocordDirectory && HashesMatch(installed.Hash, hash) {
+		checksum = installed.Sha256
+	}
+	if asset := findAsarAsset(&ReleaseData); asset != nil {
+		if published, err := releaseText(&ReleaseData, asset.Name+ipfsChecksumSuffix); err == nil {
+			checksum = published
+		} else {
+			Log.Debug("No checksum available for", asset.Name+":", err)
+		}
+	}
+	if checksum == "" {
+		return nil
+	}
+
+	if sum, err := hashFile(PotatocordDirectory); err != nil {
+		return err
+	} else if !strings.EqualFold(sum, checksum) {
+		return errors.New(PotatocordDirectory + " is corrupted (checksum mismatch)")
+	}
+	return nil
+}
+
+// Repair re-validates the Potatocord files, re-downloads them if they are damaged, re-applies the injection into
+// the given install and fixes ownership and permissions. Useful after Discord updates or antivirus software mangled files
+func (di *DiscordInstall) Repair() error {
+	defer WithLogContext("repair:" + di.branch)()
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+	Log.Info("Repairing", di.path+"...")
+
+	if err := di.CheckDiscordHost(); err != nil {
+		return errors.New(err.Error() + ". Use Troubleshoot to reinstall Discord")
+	}
+
+	if !IsDevInstall {
+		if err := VerifyPotatocordFile(); err != nil {
+			Log.Warn(err.Error() + ". Reinstalling...")
+			if ReadInstalledHash() == "" {
+				// Don't keep a broken file around as rollback target
+				_ = os.Remove(PotatocordDirectory)
+			}
+			if err = installLatestBuilds(); err != nil {
+				return err
+			}
+			if err = VerifyPotatocordFile(); err != nil {
+				return withClass(ErrVerificationFailed, errors.New("Potatocord files are still broken after reinstalling: "+err.Error()))
+			}
+		} else {
+			Log.Info("Potatocord files are intact")
+		}
+
+		// Discord has to be able to read it, but otherwise the mode is kept
+		if stat, err := os.Stat(PotatocordDirectory); err == nil {
+			if err = os.Chmod(PotatocordDirectory, stat.Mode().Perm()|0444); err != nil {
+				Log.Warn("Failed to fix permissions of", PotatocordDirectory+":", err)
+			}
+		}
+		_ = FixOwnership(PotatocordDirectory)
+		_ = FixOwnership(BaseDir)
+		fixWrittenFile(PotatocordDirectory)
+	}
+
+	if err := di.VerifyInjection(); err != nil {
+		Log.Info("Injection needs repairing:", err)
+	}
+	// Always re-apply, the check above can't detect everything a Discord update might break
+	if err := di.patch(); err != nil {
+		return err
+	}
+	if err := di.VerifyInjection(); err != nil {
+		return withClass(ErrVerificationFailed, errors.New("Injection is still broken after repairing: "+err.Error()))
+	}
+
+	Log.Info("Successfully repaired", di.path)
+	return nil
+}
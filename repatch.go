@@ -0,0 +1,181 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+)
+
+const UpdateWatchInterval = 10 * time.Second
+
+// RepatchMode is what to do when a Discord update removed Potatocord
+type RepatchMode string
+
+const (
+	RepatchAsk    RepatchMode = "ask"
+	RepatchAlways RepatchMode = "always"
+	RepatchNever  RepatchMode = "never"
+)
+
+func ParseRepatchMode(s string) (RepatchMode, error) {
+	switch mode := RepatchMode(strings.ToLower(s)); mode {
+	case RepatchAsk, RepatchAlways, RepatchNever:
+		return mode, nil
+	}
+	return "", errors.New("Unknown re-patch mode " + s + ". Use ask, always or never")
+}
+
+func GetRepatchMode() RepatchMode {
+	switch {
+	case Settings.RepatchAfterUpdate != "":
+		return Settings.RepatchAfterUpdate
+	case Config.RepatchAfterUpdates != "":
+		return Config.RepatchAfterUpdates
+	default:
+		return RepatchAsk
+	}
+}
+
+// Modification times that change when Discord updates: on Windows, updates add a new app-x.y.z folder to the
+// install, elsewhere they replace the resources
+type installStamp struct {
+	install, resources, appAsar time.Time
+}
+
+func getInstallStamp(di *DiscordInstall) installStamp {
+	var stamp installStamp
+	if stat, err := os.Stat(di.path); err == nil {
+		stamp.install = stat.ModTime()
+	}
+	if stat, err := os.Stat(di.resourcesDir()); err == nil {
+		stamp.resources = stat.ModTime()
+	}
+	if stat, err := os.Stat(path.Join(di.resourcesDir(), "app.asar")); err == nil {
+		stamp.appAsar = stat.ModTime()
+	}
+	return stamp
+}
+
+// findInstallsToKeepPatched returns all installs that are patched or were patched before an update removed it
+func findInstallsToKeepPatched() []*DiscordInstall {
+	patched := ReadManifest().Patched
+	var installs []*DiscordInstall
+	for _, d := range FindDiscords() {
+		di := d.(*DiscordInstall)
+		wasPatched := false
+		for dir := range patched {
+			if strings.HasPrefix(dir, di.path+string(os.PathSeparator)) || dir == di.path {
+				wasPatched = true
+				break
+			}
+		}
+		if di.isPatched || wasPatched {
+			installs = append(installs, di)
+		}
+	}
+	return installs
+}
+
+// WatchDiscordUpdates calls onUpdate with the updated install whenever a Discord update removed Potatocord from an
+// install that was patched. Changes are only checked once they settled, so a running update isn't interrupted.
+// It never returns
+func WatchDiscordUpdates(onUpdate func(di *DiscordInstall)) {
+	defer WithLogContext("updates")()
+
+	manifestStamp := time.Time{}
+	var installs []*DiscordInstall
+	last := make(map[string]installStamp)
+	settling := make(map[string]bool)
+	for {
+		// Patching or unpatching changes which installs to watch, and always updates the manifest
+		if stat, err := os.Stat(getManifestPath()); err == nil && !stat.ModTime().Equal(manifestStamp) {
+			manifestStamp = stat.ModTime()
+			installs = findInstallsToKeepPatched()
+			Log.Debug("Watching", len(installs), "installs for Discord updates")
+		}
+
+		stamps := make(map[string]installStamp)
+		for _, di := range installs {
+			stamps[di.path] = getInstallStamp(di)
+		}
+
+		for _, di := range installs {
+			if prev, ok := last[di.path]; !ok || stamps[di.path] != prev {
+				// Still changing, or new
+				settling[di.path] = ok
+				continue
+			}
+			if !settling[di.path] {
+				continue
+			}
+			delete(settling, di.path)
+
+			current := ParseDiscord(di.path, di.branch)
+			if current == nil || current.VerifyInjection() == nil {
+				continue
+			}
+			if err := current.CheckDiscordHost(); err != nil {
+				Log.Warn("Discord", di.branch, "changed, but is broken:", err)
+				continue
+			}
+			Log.Info("Discord", di.branch, "updated and removed Potatocord")
+			onUpdate(current)
+		}
+
+		last = stamps
+		time.Sleep(UpdateWatchInterval)
+	}
+}
+
+// RepatchAfterUpdate re-applies Potatocord after a Discord update removed it. Discord is restarted if it is running,
+// as it already loaded the update without Potatocord
+func RepatchAfterUpdate(di *DiscordInstall) error {
+	defer WithLogContext("repatch:" + di.branch)()
+	// Held from closing Discord until it's started again, so nothing else modifies it in between
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	exe, err := di.CloseDiscord()
+	if err != nil {
+		return err
+	}
+	if err = di.patch(); err != nil {
+		return err
+	}
+	// Only report success if Discord loads Potatocord again
+	if err = di.VerifyInjection(); err != nil {
+		return err
+	}
+	if exe != "" {
+		if err = di.RelaunchDiscord(exe); err != nil {
+			Log.Warn("Failed to start Discord:", err)
+		}
+	}
+	return nil
+}
+
+// handleDiscordUpdateInBackground re-applies Potatocord if the user wants that. Asking is left to the verifier's
+// notification, as there is no way to answer from here
+func handleDiscordUpdateInBackground(di *DiscordInstall) {
+	if GetRepatchMode() != RepatchAlways {
+		return
+	}
+
+	if err := RepatchAfterUpdate(di); err != nil {
+		Log.Error("Failed to re-patch", di.path+":", err)
+		Notify(EventInstallBroken, "Failed to re-apply Potatocord", "Discord "+di.branch+" updated and Potatocord couldn't be re-applied: "+err.Error())
+		return
+	}
+	Notify(EventRepatched, "Potatocord re-applied", "Discord "+di.branch+" updated, so Potatocord was re-applied.")
+}
@@ -0,0 +1,92 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+)
+
+// The shortest interval updates can be scheduled at, so scheduled jobs can't get us rate limited
+const minScheduleInterval = time.Hour
+
+var scheduleIntervalNames = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// ScheduledJob is the job registered with the OS scheduler that runs the update command: a Scheduled Task on Windows,
+// a systemd user timer on Linux and a launchd agent on macOS
+type ScheduledJob struct {
+	Registered bool   `json:"registered"`
+	Interval   string `json:"interval,omitempty"`
+	Command    string `json:"command,omitempty"`
+	// Where the job is registered, e.g. the timer file or the task name
+	Location   string `json:"location,omitempty"`
+	NextRun    string `json:"nextRun,omitempty"`
+	LastRun    string `json:"lastRun,omitempty"`
+	LastResult string `json:"lastResult,omitempty"`
+}
+
+// ParseScheduleInterval parses hourly, daily, weekly or a duration like 12h. Intervals are rounded to whole hours,
+// as that's what all schedulers support
+func ParseScheduleInterval(s string) (time.Duration, error) {
+	if d, ok := scheduleIntervalNames[strings.ToLower(s)]; ok {
+		return d, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.New("Invalid interval " + s + ". Use hourly, daily, weekly or a duration like 12h")
+	}
+	if d < minScheduleInterval {
+		return 0, errors.New("Updates can be scheduled at most once an hour")
+	}
+	return d.Round(time.Hour), nil
+}
+
+func formatScheduleInterval(d time.Duration) string {
+	for name, interval := range scheduleIntervalNames {
+		if d == interval {
+			return name
+		}
+	}
+	return strings.TrimSuffix(d.String(), "0m0s")
+}
+
+// ScheduleUpdates registers a job running the update command at the interval, replacing an already registered one
+func ScheduleUpdates(interval time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := path.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	if IsRunningFromReadOnly() || strings.HasPrefix(exe, os.TempDir()) {
+		Log.Warn("The installer is running from", path.Dir(exe)+". The scheduled job stops working if it is moved or deleted")
+	}
+
+	Log.Debug("Scheduling updates every", interval, "with", exe)
+	return scheduleUpdates(interval, exe, []string{"update", "--yes"})
+}
+
+// UnscheduleUpdates removes the job registered by ScheduleUpdates. Returns os.ErrNotExist if there is none
+func UnscheduleUpdates() error {
+	if job, err := GetScheduledJob(); err == nil && !job.Registered {
+		return os.ErrNotExist
+	}
+	return unscheduleUpdates()
+}
+
+// GetScheduledJob returns the registered job and when it ran, as far as the scheduler tells
+func GetScheduledJob() (ScheduledJob, error) {
+	return getScheduledJob()
+}
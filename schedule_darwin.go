@@ -0,0 +1,111 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const scheduleLabel = "dev.potatocord.installer.update"
+
+var (
+	startIntervalRegex  = regexp.MustCompile(`<key>StartInterval</key>\s*<integer>(\d+)</integer>`)
+	lastExitStatusRegex = regexp.MustCompile(`"LastExitStatus" = (-?\d+);`)
+)
+
+func getLaunchAgentPath() string {
+	return path.Join(os.Getenv("HOME"), "Library", "LaunchAgents", scheduleLabel+".plist")
+}
+
+func scheduleUpdates(interval time.Duration, exe string, args []string) error {
+	var arguments strings.Builder
+	for _, arg := range Prepend(args, exe) {
+		arguments.WriteString("\t\t<string>" + xmlEscape(arg) + "</string>\n")
+	}
+	logFile := xmlEscape(path.Join(BaseDir, "scheduled-update.log"))
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + scheduleLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+` + arguments.String() + `	</array>
+	<key>StartInterval</key>
+	<integer>` + strconv.Itoa(int(interval.Seconds())) + `</integer>
+	<key>StandardOutPath</key>
+	<string>` + logFile + `</string>
+	<key>StandardErrorPath</key>
+	<string>` + logFile + `</string>
+</dict>
+</plist>
+`
+
+	file := getLaunchAgentPath()
+	if err := os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+	// launchd keeps using the old job until it is unloaded
+	_ = exec.Command("launchctl", "unload", file).Run()
+	if err := os.WriteFile(file, []byte(plist), 0644); err != nil {
+		return err
+	}
+	if out, err := exec.Command("launchctl", "load", "-w", file).CombinedOutput(); err != nil {
+		return errors.New("Failed to load the launch agent: " + strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func unscheduleUpdates() error {
+	file := getLaunchAgentPath()
+	if out, err := exec.Command("launchctl", "unload", "-w", file).CombinedOutput(); err != nil {
+		Log.Warn("Failed to unload the launch agent:", strings.TrimSpace(string(out)))
+	}
+	return os.Remove(file)
+}
+
+func getScheduledJob() (ScheduledJob, error) {
+	file := getLaunchAgentPath()
+	job := ScheduledJob{Location: file}
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return job, nil
+	}
+	if err != nil {
+		return job, err
+	}
+	job.Registered = true
+	if m := startIntervalRegex.FindSubmatch(b); m != nil {
+		seconds, _ := strconv.Atoi(string(m[1]))
+		job.Interval = formatScheduleInterval(time.Duration(seconds) * time.Second)
+	}
+
+	out, err := exec.Command("launchctl", "list", scheduleLabel).Output()
+	if err != nil {
+		job.NextRun = "never, the launch agent is not loaded"
+		return job, nil
+	}
+	if m := lastExitStatusRegex.FindSubmatch(out); m != nil {
+		job.LastResult = "exit code " + string(m[1])
+	}
+	return job, nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
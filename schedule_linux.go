@@ -0,0 +1,157 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const scheduleUnit = "potatocord-update"
+
+func getSystemdUserDir() string {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" && os.Getenv("SUDO_USER") == "" {
+		return path.Join(configHome, "systemd", "user")
+	}
+	return path.Join(Home, ".config", "systemd", "user")
+}
+
+func scheduleUpdates(interval time.Duration, exe string, args []string) error {
+	if _, err := exec.LookPath("systemctl"); err != nil {
+		return errors.New("Scheduling updates needs systemd, but systemctl was not found")
+	}
+
+	dir := getSystemdUserDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	service := "[Unit]\n" +
+		"Description=Update Potatocord\n" +
+		"Wants=network-online.target\n" +
+		"After=network-online.target\n\n" +
+		"[Service]\n" +
+		"Type=oneshot\n" +
+		"ExecStart=" + strings.Join(SliceMap(Prepend(args, exe), systemdQuote), " ") + "\n"
+	timer := "[Unit]\n" +
+		"Description=Update Potatocord every " + formatScheduleInterval(interval) + "\n\n" +
+		"[Timer]\n" +
+		"OnBootSec=5min\n" +
+		"OnUnitActiveSec=" + strconv.Itoa(int(interval.Seconds())) + "s\n" +
+		"RandomizedDelaySec=10min\n\n" +
+		"[Install]\n" +
+		"WantedBy=timers.target\n"
+
+	for name, content := range map[string]string{scheduleUnit + ".service": service, scheduleUnit + ".timer": timer} {
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	// The systemd folder may have been created by us just now
+	if err := FixOwnership(path.Dir(dir)); err != nil {
+		return err
+	}
+
+	err := runAsActualUser("systemctl", "--user", "daemon-reload")
+	if err == nil {
+		err = runAsActualUser("systemctl", "--user", "enable", "--now", scheduleUnit+".timer")
+	}
+	if err != nil {
+		// Don't leave behind units that look registered but never run
+		_ = removeScheduleUnits()
+		return errors.New("Failed to enable " + scheduleUnit + ".timer. Is a systemd user session running? " + err.Error())
+	}
+	return nil
+}
+
+func unscheduleUpdates() error {
+	if err := runAsActualUser("systemctl", "--user", "disable", "--now", scheduleUnit+".timer"); err != nil {
+		Log.Warn("Failed to disable "+scheduleUnit+".timer:", err)
+	}
+	if err := removeScheduleUnits(); err != nil {
+		return err
+	}
+	if err := runAsActualUser("systemctl", "--user", "daemon-reload"); err != nil {
+		Log.Warn("Failed to reload systemd, the timer stays loaded until the next login:", err)
+	}
+	return nil
+}
+
+func removeScheduleUnits() error {
+	for _, name := range []string{scheduleUnit + ".timer", scheduleUnit + ".service"} {
+		if err := os.Remove(path.Join(getSystemdUserDir(), name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func getScheduledJob() (ScheduledJob, error) {
+	timerFile := path.Join(getSystemdUserDir(), scheduleUnit+".timer")
+	job := ScheduledJob{Location: timerFile}
+	timer, err := os.ReadFile(timerFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return job, nil
+	}
+	if err != nil {
+		return job, err
+	}
+	job.Registered = true
+	if seconds, ok := readSystemdKey(string(timer), "OnUnitActiveSec"); ok {
+		if d, err := time.ParseDuration(seconds); err == nil {
+			job.Interval = formatScheduleInterval(d)
+		}
+	}
+	if service, err := os.ReadFile(path.Join(getSystemdUserDir(), scheduleUnit+".service")); err == nil {
+		job.Command, _ = readSystemdKey(string(service), "ExecStart")
+	}
+
+	// systemctl may be unable to reach the user's manager, e.g. over su without a login session
+	out, err := actualUserCommand("systemctl", "--user", "show", scheduleUnit+".timer",
+		"--property=NextElapseUSecRealtime,LastTriggerUSec,ActiveState").Output()
+	if err != nil {
+		Log.Debug("Failed to query the timer:", err)
+		return job, nil
+	}
+	timerProps := string(out)
+	job.NextRun, _ = readSystemdKey(timerProps, "NextElapseUSecRealtime")
+	if last, _ := readSystemdKey(timerProps, "LastTriggerUSec"); last != "n/a" {
+		job.LastRun = last
+	}
+	if state, _ := readSystemdKey(timerProps, "ActiveState"); state != "" && state != "active" {
+		job.NextRun = "never, the timer is " + state
+	}
+	if job.LastRun != "" {
+		if out, err = actualUserCommand("systemctl", "--user", "show", scheduleUnit+".service", "--property=Result").Output(); err == nil {
+			job.LastResult, _ = readSystemdKey(string(out), "Result")
+		}
+	}
+	return job, nil
+}
+
+// readSystemdKey returns the value of the first Key=value line
+func readSystemdKey(content, key string) (string, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if value, ok := strings.CutPrefix(line, key+"="); ok {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// systemdQuote quotes a word of ExecStart, which systemd splits like a shell but doesn't expand
+func systemdQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\"'\\$%") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%").Replace(s) + `"`
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !linux && !darwin
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+var errScheduleUnsupported = errors.New("Scheduling updates is not supported on " + runtime.GOOS + ". Use cron to run the update command instead")
+
+func scheduleUpdates(_ time.Duration, _ string, _ []string) error {
+	return errScheduleUnsupported
+}
+
+func unscheduleUpdates() error {
+	return errScheduleUnsupported
+}
+
+func getScheduledJob() (ScheduledJob, error) {
+	return ScheduledJob{}, errScheduleUnsupported
+}
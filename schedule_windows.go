@@ -0,0 +1,80 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const scheduleTaskName = "Potatocord Installer Update"
+
+func scheduleUpdates(interval time.Duration, exe string, args []string) error {
+	hours := int(interval.Hours())
+	schedule, modifier := "HOURLY", hours
+	switch {
+	case hours%(7*24) == 0:
+		schedule, modifier = "WEEKLY", hours/(7*24)
+	case hours%24 == 0:
+		schedule, modifier = "DAILY", hours/24
+	case hours > 23:
+		return errors.New("Intervals over a day must be whole days on Windows")
+	}
+
+	command := strings.Join(SliceMap(Prepend(args, exe), func(arg string) string {
+		return Ternary(strings.Contains(arg, " "), `"`+arg+`"`, arg)
+	}), " ")
+	out, err := exec.Command("schtasks", "/Create", "/F", "/TN", scheduleTaskName, "/TR", command,
+		"/SC", schedule, "/MO", strconv.Itoa(modifier)).CombinedOutput()
+	if err != nil {
+		return errors.New("Failed to create the scheduled task: " + strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func unscheduleUpdates() error {
+	out, err := exec.Command("schtasks", "/Delete", "/F", "/TN", scheduleTaskName).CombinedOutput()
+	if err != nil {
+		return errors.New("Failed to delete the scheduled task: " + strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func getScheduledJob() (ScheduledJob, error) {
+	job := ScheduledJob{Location: scheduleTaskName}
+	// Exits with 1 if the task doesn't exist
+	out, err := exec.Command("schtasks", "/Query", "/TN", scheduleTaskName, "/FO", "LIST", "/V").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return job, nil
+	}
+	if err != nil {
+		return job, err
+	}
+	job.Registered = true
+
+	fields := map[string]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	// Field names are localized, so this only works on English systems
+	job.Command = fields["Task To Run"]
+	job.NextRun = fields["Next Run Time"]
+	if last := fields["Last Run Time"]; last != "" && !strings.HasPrefix(last, "11/30/1999") {
+		job.LastRun = last
+		job.LastResult = "exit code " + fields["Last Result"]
+	}
+	if repeat := fields["Schedule Type"]; repeat != "" {
+		job.Interval = strings.ToLower(repeat)
+	}
+	return job, nil
+}
@@ -0,0 +1,120 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"runtime"
+	"strings"
+)
+
+// InstallScope is who Potatocord is installed for. Installs patched for all users load Potatocord from a
+// machine-wide directory only administrators can write to, instead of the user data directory of whoever patched it
+type InstallScope string
+
+const (
+	ScopeUser   InstallScope = "user"
+	ScopeSystem InstallScope = "system"
+)
+
+var CurrentScope = ScopeUser
+
+// The user's own directories, to go back to after switching to the system scope
+var userBaseDir, userPotatocordFile string
+
+func getSystemBaseDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		return path.Join(Ternary(os.Getenv("ProgramData") != "", os.Getenv("ProgramData"), `C:\ProgramData`), "Potatocord")
+	case "darwin":
+		return "/Library/Application Support/Potatocord"
+	default:
+		return "/usr/local/share/potatocord"
+	}
+}
+
+func isSystemPath(p string) bool {
+	return strings.HasPrefix(p, getSystemBaseDir()+string(os.PathSeparator)) || p == getSystemBaseDir()
+}
+
+func getSystemManifestPath() string {
+	return path.Join(getSystemBaseDir(), "manifest.json")
+}
+
+// ParseScope parses a scope given by the user. Empty means the scope the install was patched in
+func ParseScope(s string) (InstallScope, error) {
+	switch scope := InstallScope(strings.ToLower(s)); scope {
+	case "", ScopeUser, ScopeSystem:
+		return scope, nil
+	}
+	return "", errors.New("Unknown install scope " + s + ". Use user or system")
+}
+
+// ScopeOf returns the scope the install was patched in, according to the machine-wide manifest. Updates and
+// uninstalls have to use the same scope, otherwise they'd leave the machine-wide files behind
+func ScopeOf(di *DiscordInstall) InstallScope {
+	if scope := readManifestFile(getSystemManifestPath()).Scopes[di.resourcesDir()]; scope != "" {
+		return scope
+	}
+	return ScopeUser
+}
+
+// IsMachineWide reports whether the install is shared by all users rather than living in a user's home
+func (di *DiscordInstall) IsMachineWide() bool {
+	home := getUserHome()
+	if home != "" && strings.HasPrefix(di.path, home+string(os.PathSeparator)) {
+		return false
+	}
+	return runtime.GOOS != "linux" || !strings.HasPrefix(di.path, "/home/")
+}
+
+// UseScope makes all following operations install Potatocord for the given scope. The system scope keeps
+// Potatocord, its backups and the manifest in a machine-wide directory, which requires administrator rights for
+// writing. If we don't have them, they are requested for each step that writes there
+func UseScope(scope InstallScope) error {
+	if scope == CurrentScope {
+		return nil
+	}
+
+	switch scope {
+	case ScopeSystem:
+		if IsDevInstall {
+			return errors.New("Dev installs can't be installed for all users")
+		}
+		// Without administrator rights, only the steps writing machine-wide files are elevated
+		if !IsElevated() && !CanElevate() {
+			return withClass(ErrElevationDenied, errors.New("Installing for all users requires administrator rights. "+elevationHint))
+		}
+		userBaseDir, userPotatocordFile = BaseDir, PotatocordDirectory
+		BaseDir = getSystemBaseDir()
+		PotatocordDirectory = path.Join(BaseDir, potatocordAsarName)
+	case ScopeUser:
+		BaseDir, PotatocordDirectory = userBaseDir, userPotatocordFile
+	default:
+		return errors.New("Unknown install scope " + string(scope))
+	}
+
+	Log.Info("Installing for", Ternary(scope == ScopeSystem, "all users to", "the current user to"), PotatocordDirectory)
+	CurrentScope = scope
+	BackupDir = path.Join(BaseDir, "backups")
+	hash := ReadInstalledHash()
+	InstalledHash = Ternary(hash != "", hash, "None")
+	return nil
+}
+
+// UseScopeFor switches to the scope the install should be modified in. If scope is empty, the scope it was
+// patched in is used. Installing for all users is refused for installs in a user's home
+func UseScopeFor(di *DiscordInstall, scope InstallScope) error {
+	if scope == "" {
+		scope = ScopeOf(di)
+	} else if scope == ScopeSystem && !di.IsMachineWide() {
+		return errors.New(di.path + " belongs to a single user, so it can't be patched for all users")
+	}
+	return UseScope(scope)
+}
@@ -7,26 +7,37 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"path"
+	path "path/filepath"
 	"potatocordinstaller/buildinfo"
 	"runtime"
+	"strings"
 	"time"
 )
 
 var IsSelfOutdated = false
+var LatestInstallerRelease *GithubRelease
 var SelfUpdateCheckDoneChan = make(chan bool, 1)
 
-func init() {
+// CheckForSelfUpdate checks for a newer installer in the background. Only called once the flags are parsed, so
+// --offline is honoured
+func CheckForSelfUpdate() {
 	//goland:noinspection GoBoolExpressions
 	if buildinfo.InstallerTag == buildinfo.VersionUnknown {
 		Log.Debug("Disabling self updater as this is not a release build")
 		return
 	}
+	if IsOffline() {
+		Log.Debug("Not checking for installer updates in offline mode")
+		SelfUpdateCheckDoneChan <- false
+		return
+	}
 
 	go DeleteOldExecutable()
 
@@ -38,6 +49,7 @@ func init() {
 			Log.Warn("Failed to check for self updates:", err)
 			SelfUpdateCheckDoneChan <- false
 		} else {
+			LatestInstallerRelease = res
 			IsSelfOutdated = res.TagName != buildinfo.InstallerTag
 			Log.Debug("Is self outdated?", IsSelfOutdated)
 			SelfUpdateCheckDoneChan <- true
@@ -45,46 +57,84 @@ func init() {
 	}()
 }
 
-func GetInstallerDownloadLink() string {
-	const BaseUrl = "https://github.com/potatocord/Installer/releases/latest/download/"
+func getInstallerAssetName() string {
 	switch runtime.GOOS {
 	case "windows":
-		filename := Ternary(buildinfo.UiType == buildinfo.UiTypeCli, "PotatocordInstallerCli.exe", "PotatocordInstaller.exe")
-		return BaseUrl + filename
+		return Ternary(buildinfo.UiType == buildinfo.UiTypeCli, "PotatocordInstallerCli.exe", "PotatocordInstaller.exe")
 	case "darwin":
-		return BaseUrl + "PotatocordInstaller.MacOS.zip"
+		return "PotatocordInstaller.MacOS.zip"
 	case "linux":
-		return BaseUrl + "PotatocordInstallerCli-linux"
+		return "PotatocordInstallerCli-linux"
 	default:
 		return ""
 	}
 }
 
+func GetInstallerDownloadLink() string {
+	const BaseUrl = "https://github.com/potatocord/Installer/releases/latest/download/"
+	if name := getInstallerAssetName(); name != "" {
+		return BaseUrl + name
+	}
+	return ""
+}
+
+// findInstallerAsset returns the asset of the release built for this platform. Builds for other architectures than
+// amd64 are suffixed with it, e.g. PotatocordInstallerCli-linux-arm64 or PotatocordInstallerCli-arm64.exe
+func findInstallerAsset(release *GithubRelease) (name, url string) {
+	name = getInstallerAssetName()
+	if name == "" {
+		return "", ""
+	}
+	ext := path.Ext(name)
+	archName := strings.TrimSuffix(name, ext) + "-" + runtime.GOARCH + ext
+	if url = findReleaseAsset(release, archName); url != "" {
+		return archName, url
+	}
+	if runtime.GOARCH != "amd64" {
+		// Don't replace ourselves with a binary that can't run here
+		return "", ""
+	}
+	return name, findReleaseAsset(release, name)
+}
+
 func CanUpdateSelf() bool {
 	//goland:noinspection GoBoolExpressions
-	return IsSelfOutdated && runtime.GOOS != "darwin"
+	return IsSelfOutdated && runtime.GOOS != "darwin" && !IsRunningFromReadOnly()
 }
 
+// UpdateSelf downloads the installer for this platform from the latest release, verifies it and replaces the running
+// executable with it. The new version runs from the next start
 func UpdateSelf() error {
+	defer enterCancellable()()
 	if !CanUpdateSelf() {
-		return errors.New("Cannot update self. Either no update available or macos")
+		if IsSelfOutdated && IsRunningFromReadOnly() {
+			return errors.New("Cannot update self as the installer was started from a read-only location. Please download the latest installer from " + GetInstallerDownloadLink())
+		}
+		if IsSelfOutdated {
+			return errors.New("Cannot update self on macOS. Please download the latest installer from " + GetInstallerDownloadLink())
+		}
+		return errors.New("Cannot update self as no update is available")
 	}
 
-	url := GetInstallerDownloadLink()
+	name, url := findInstallerAsset(LatestInstallerRelease)
 	if url == "" {
-		return errors.New("Failed to get installer download link")
+		return fmt.Errorf("Release %s has no installer for %s/%s. Please download it manually from %s",
+			LatestInstallerRelease.TagName, runtime.GOOS, runtime.GOARCH, GetInstallerDownloadLink())
 	}
 
-	Log.Debug("Updating self from", url)
+	Log.Debug("Updating self to", LatestInstallerRelease.TagName, "from", url)
 
 	ownExePath, err := os.Executable()
 	if err != nil {
 		return err
 	}
+	if resolved, err := path.EvalSymlinks(ownExePath); err == nil {
+		ownExePath = resolved
+	}
 
 	ownExeDir := path.Dir(ownExePath)
 
-	res, err := http.Get(url)
+	res, err := DownloadWithFailover([]string{url})
 	if err != nil {
 		return err
 	}
@@ -99,17 +149,31 @@ func UpdateSelf() error {
 		_ = os.Remove(tmp.Name())
 	}()
 	if err = tmp.Chmod(0o755); err != nil {
-		return fmt.Errorf("Failed to chmod 755", tmp.Name()+":", err)
+		return fmt.Errorf("Failed to chmod 755 %s: %w", tmp.Name(), err)
 	}
 
-	if _, err = io.Copy(tmp, res.Body); err != nil {
-		return err
+	h := sha256.New()
+	body := newProgressReader(res.Body, StageDownload, res.ContentLength, "Downloading installer "+LatestInstallerRelease.TagName)
+	n, err := io.Copy(io.MultiWriter(tmp, h), body)
+	if err != nil {
+		return cancelledOr(err)
 	}
 
 	if err = tmp.Close(); err != nil {
 		return err
 	}
 
+	ReportStage(StageVerify, "Verifying the installer update")
+	if err = verifyInstallerUpdate(tmp.Name(), name, n, res.ContentLength, hex.EncodeToString(h.Sum(nil))); err != nil {
+		return withClass(ErrVerificationFailed, err)
+	}
+
+	// Past this point, cancelling could leave no executable behind
+	if IsCancelled() {
+		return ErrCancelled
+	}
+
+	// Windows can't delete a running executable, but it can rename it. DeleteOldExecutable removes it on the next start
 	if err = os.Remove(ownExePath); err != nil {
 		if err = os.Rename(ownExePath, ownExePath+".old"); err != nil {
 			return fmt.Errorf("Failed to remove/rename own executable: %w", err)
@@ -117,9 +181,51 @@ func UpdateSelf() error {
 	}
 
 	if err = os.Rename(tmp.Name(), ownExePath); err != nil {
+		if ExistsFile(ownExePath + ".old") {
+			if restoreErr := os.Rename(ownExePath+".old", ownExePath); restoreErr != nil {
+				Log.Error("Failed to restore own executable:", restoreErr)
+			}
+		}
 		return fmt.Errorf("Failed to replace self with updated executable. Please manually redownload the installer: %w", err)
 	}
 
+	Log.Info("Updated the installer to", LatestInstallerRelease.TagName)
+	recordWritten(ownExePath)
+	recordVersion("Installer", buildinfo.InstallerTag, LatestInstallerRelease.TagName)
+	IsSelfOutdated = false
+	return nil
+}
+
+// verifyInstallerUpdate makes sure the download is complete, matches the checksum the release publishes as
+// <asset>.sha256 or GitHub computed for it, and is an executable for this platform. The installer replaces itself
+// with the download, so without a checksum it's not used
+func verifyInstallerUpdate(file, assetName string, size, expectedSize int64, checksum string) error {
+	if expectedSize > 0 && size != expectedSize {
+		return fmt.Errorf("Downloaded installer is incomplete: Got %d of %d bytes", size, expectedSize)
+	}
+
+	expected, err := releaseText(LatestInstallerRelease, assetName+ipfsChecksumSuffix)
+	if err != nil {
+		return withClass(ErrVerificationFailed, fmt.Errorf("Can't verify the downloaded installer as its release has no checksum: %w", err))
+	}
+	if !strings.EqualFold(expected, checksum) {
+		return withClass(ErrVerificationFailed, fmt.Errorf("Downloaded installer has checksum %s, but the release says %s", checksum, expected))
+	}
+	Log.Debug("Installer update matches its published checksum", checksum)
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err = io.ReadFull(f, magic); err != nil {
+		return fmt.Errorf("Downloaded installer is too small to be an executable: %w", err)
+	}
+	expectedMagic := Ternary(runtime.GOOS == "windows", []byte("MZ"), []byte("\x7fELF"))
+	if !bytes.HasPrefix(magic, expectedMagic) {
+		return errors.New("Downloaded installer is not an executable for " + runtime.GOOS)
+	}
 	return nil
 }
 
@@ -129,6 +235,11 @@ func DeleteOldExecutable() {
 		return
 	}
 
+	// Also nothing to delete on read-only media, we never could have written it
+	if !ExistsFile(ownExePath + ".old") {
+		return
+	}
+
 	for attempts := 0; attempts < 10; attempts += 1 {
 		err = os.Remove(ownExePath + ".old")
 
@@ -0,0 +1,39 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+func isSELinuxEnabled() bool {
+	return ExistsFile("/sys/fs/selinux/enforce")
+}
+
+// RestoreSecurityContext relabels p like restorecon does. With SELinux enforcing, files we create may inherit a
+// context Discord isn't allowed to read, e.g. when moved from the temp directory
+func RestoreSecurityContext(p string) error {
+	if !isSELinuxEnabled() {
+		return nil
+	}
+
+	if _, err := exec.LookPath("restorecon"); err != nil {
+		return errors.New("SELinux is enabled, but restorecon is missing. If Discord fails to load Potatocord, run restorecon -R " + shellQuote(p))
+	}
+
+	Log.Debug("Restoring SELinux context of", p)
+	if out, err := exec.Command("restorecon", "-R", p).CombinedOutput(); err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return errors.New("Failed to restore the SELinux context of " + p + ": " + msg + ". If Discord fails to load Potatocord, run sudo restorecon -R " + shellQuote(p))
+	}
+	return nil
+}
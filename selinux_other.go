@@ -0,0 +1,14 @@
+//go:build !linux
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+// RestoreSecurityContext is only needed on Linux with SELinux
+func RestoreSecurityContext(_ string) error {
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+)
+
+// InstallerSettings are the installer's own settings. Potatocord's settings live elsewhere in BaseDir
+type InstallerSettings struct {
+	// Unlocks risky actions that novice users should not stumble upon, like overwriting other mods or custom update sources
+	AdvancedMode bool `json:"advancedMode"`
+	// Mirrors suggested by release metadata that the user agreed to use or declined
+	AcceptedMirrors []string `json:"acceptedMirrors,omitempty"`
+	DeclinedMirrors []string `json:"declinedMirrors,omitempty"`
+	// Where to install Potatocord to instead of BaseDir. Changed via SetInstallDir, which moves the installed build
+	InstallDir string `json:"installDir,omitempty"`
+	// Whether the user doesn't want to be asked about migrating from Vencord again
+	DeclinedVencordMigration bool `json:"declinedVencordMigration,omitempty"`
+	// What to do when a Discord update removed Potatocord. Defaults to asking
+	RepatchAfterUpdate RepatchMode `json:"repatchAfterUpdate,omitempty"`
+	// Discord installs outside the known locations, e.g. portable ones, to include in every operation
+	CustomDiscords []string `json:"customDiscords,omitempty"`
+	// How many previously installed Potatocord versions to keep for downgrading. Defaults to defaultKeptVersions
+	KeptVersions int `json:"keptVersions,omitempty"`
+	// The gui's color scheme. Defaults to following the OS
+	Theme Theme `json:"theme,omitempty"`
+	// The language chosen in the gui, which takes precedence over the config file and the system's
+	Language string `json:"language,omitempty"`
+}
+
+var Settings InstallerSettings
+
+func getSettingsPath() string {
+	// The installer settings are always the user's own, even when installing for all users
+	return path.Join(Ternary(CurrentScope == ScopeSystem, userBaseDir, BaseDir), "installer.json")
+}
+
+func ReadSettings() InstallerSettings {
+	var settings InstallerSettings
+	// The other user can write to them, e.g. to have files written or Discords patched anywhere as administrator
+	if TargetUser != "" {
+		Log.Debug("Not reading the installer settings of", TargetUser)
+		return settings
+	}
+	b, err := os.ReadFile(getSettingsPath())
+	if err == nil {
+		if err = json.Unmarshal(b, &settings); err != nil {
+			Log.Warn("Failed to parse installer settings:", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		Log.Warn("Failed to read installer settings:", err)
+	}
+	return settings
+}
+
+func (s *InstallerSettings) Save() error {
+	if TargetUser != "" {
+		Log.Debug("Not saving the installer settings of", TargetUser)
+		return nil
+	}
+	if err := os.MkdirAll(path.Dir(getSettingsPath()), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(getSettingsPath(), b, 0644); err != nil {
+		return err
+	}
+	_ = FixOwnership(getSettingsPath())
+	return nil
+}
+
+// IsAdvancedMode reports whether advanced mode is enabled, either in the settings or via POTATOCORD_ADVANCED=1
+func IsAdvancedMode() bool {
+	return Settings.AdvancedMode || EnvAdvanced.IsEnabled()
+}
@@ -0,0 +1,174 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultSharePort = 8734
+
+const shareAssetName = "desktop.asar"
+
+// ShareSourceUrl is the address of another installer in share mode. If set, it replaces all other update sources
+var ShareSourceUrl string
+
+var sharePage = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Potatocord Installer</title></head>
+<body style="font-family: sans-serif; max-width: 40em; margin: 2em auto">
+<h1>Potatocord {{.Hash}}</h1>
+<p>This Potatocord build is shared by an installer on your local network.</p>
+<h2>Install it with the installer</h2>
+<p>Run the Potatocord Installer Cli on this machine with</p>
+<pre>--share-from {{.Url}}</pre>
+<h2>Install it manually</h2>
+<p>Download <a href="/{{.Asset}}">{{.Asset}}</a> (sha256 <code>{{.Sha256}}</code>)</p>
+</body>
+</html>
+`))
+
+// ServeShare serves the installed Potatocord build, a manifest matching ManifestRelease and a small install page
+// on the local network, so machines without internet access can install from this one. It never returns on success
+func ServeShare(port int) error {
+	if IsDevInstall {
+		return errors.New("Dev installs can't be shared")
+	}
+	if !WaitForGithub() {
+		Log.Warn("Couldn't fetch the latest release, so the shared build can only be checked for corruption")
+		if ReadInstalledHash() == "" {
+			return errors.New("There is no intact Potatocord build to share. Install Potatocord first")
+		}
+	} else if err := VerifyPotatocordFile(); err != nil {
+		return errors.New("Refusing to share: " + err.Error() + ". Repair Potatocord first")
+	}
+
+	hash := ReadInstalledHash()
+	checksum, err := hashFile(PotatocordDirectory)
+	if err != nil {
+		return err
+	}
+
+	baseUrl := func(r *http.Request) string {
+		return "http://" + r.Host
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = sharePage.Execute(w, map[string]string{
+			"Hash":   hash,
+			"Url":    baseUrl(r),
+			"Asset":  shareAssetName,
+			"Sha256": checksum,
+		})
+	})
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ManifestRelease{
+			Name: "Potatocord " + hash,
+			Tag:  "shared-" + hash,
+			Hash: hash,
+			Assets: []ReleaseAsset{
+				{Name: shareAssetName, DownloadURL: baseUrl(r) + "/" + shareAssetName, Digest: "sha256:" + checksum},
+				{Name: shareAssetName + ipfsChecksumSuffix, DownloadURL: baseUrl(r) + "/" + shareAssetName + ipfsChecksumSuffix},
+			},
+		})
+	})
+	mux.HandleFunc("/"+shareAssetName, func(w http.ResponseWriter, r *http.Request) {
+		Log.Info("Sending Potatocord to", r.RemoteAddr)
+		http.ServeFile(w, r, PotatocordDirectory)
+	})
+	mux.HandleFunc("/"+shareAssetName+ipfsChecksumSuffix, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte(checksum + "  " + shareAssetName + "\n"))
+	})
+
+	addr := ":" + strconv.Itoa(port)
+	Log.Info("Sharing Potatocord", hash, "on port", port, "- press Ctrl+C to stop")
+	for _, ip := range getLanAddresses() {
+		Log.Info("Open http://" + net.JoinHostPort(ip, strconv.Itoa(port)) + " on the other machine")
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           onlyLocalNetwork(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return server.ListenAndServe()
+}
+
+// onlyLocalNetwork refuses requests from outside the local network. The share mode is unauthenticated, but listens
+// on all interfaces, which may include public ones
+func onlyLocalNetwork(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if ip := net.ParseIP(host); err != nil || ip == nil || !isLocalNetworkIp(ip) {
+			Log.Warn("Refusing to share with", r.RemoteAddr, "as it's not on the local network")
+			http.Error(w, "Only available on the local network", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isLocalNetworkIp(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()
+}
+
+func getLanAddresses() []string {
+	var ips []string
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		Log.Warn("Failed to list network addresses:", err)
+		return ips
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsPrivate() {
+			ips = append(ips, ipNet.IP.String())
+		}
+	}
+	return ips
+}
+
+// UseShareSource makes the installer install from another installer in share mode. Only local network addresses
+// are accepted, as the share mode is unauthenticated
+func UseShareSource(rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Host == "" {
+		if u, err = url.Parse("http://" + rawUrl); err != nil {
+			return errors.New("Invalid share address " + rawUrl)
+		}
+	}
+
+	host := u.Hostname()
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return errors.New("Failed to resolve " + host)
+	}
+	for _, ip := range ips {
+		if !isLocalNetworkIp(ip) {
+			return errors.New(host + " is not on your local network")
+		}
+	}
+
+	ShareSourceUrl = u.Scheme + "://" + u.Host
+	// The shared build is what we want, not whatever the public mirrors have
+	AssetMirrors = nil
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// What the tui and the numbered menu offer for an install
+type tuiAction struct {
+	label string
+	// Past tense for the result, e.g. "patched"
+	done string
+	fn   func(di *DiscordInstall) error
+}
+
+var (
+	tuiInstall   = tuiAction{"Install Potatocord", "patched", (*DiscordInstall).patch}
+	tuiRepair    = tuiAction{"Repair Potatocord", "repaired", (*DiscordInstall).Repair}
+	tuiUninstall = tuiAction{"Uninstall Potatocord", "unpatched", (*DiscordInstall).unpatch}
+)
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// HasDisplay reports whether the gui can open a window. Only Linux and the BSDs may run without one, e.g. over SSH
+func HasDisplay() bool {
+	return runtime.GOOS == "windows" || runtime.GOOS == "darwin" || os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+}
+
+// CanUseArrowMenus reports whether the terminal can show the arrow key menus. Dumb terminals, like editor shells,
+// get the numbered menu instead
+func CanUseArrowMenus() bool {
+	return os.Getenv("TERM") != "dumb"
+}
+
+var menuInput = bufio.NewReader(os.Stdin)
+
+// promptNumber lists the items numbered from 1 and returns the index of the one picked, or -1 for q or end of input
+func promptNumber(label string, items []string) int {
+	for {
+		fmt.Println()
+		for i, item := range items {
+			fmt.Printf("  %d) %s\n", i+1, item)
+		}
+		fmt.Print(label + " ")
+
+		line, err := menuInput.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "q" || (line == "" && err != nil) {
+			return -1
+		}
+		if n, convErr := strconv.Atoi(line); convErr == nil && n >= 1 && n <= len(items) {
+			return n - 1
+		}
+		fmt.Println(T("Not a valid choice"))
+	}
+}
+
+// promptYesNo asks a question that defaults to no
+func promptYesNo(label string) bool {
+	fmt.Print(label + " [y/N] ")
+	line, _ := menuInput.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// RunSimpleMenu asks for an install, then what to do with it and runs that once confirmed, using numbered menus
+// that work in any terminal. Returns false if that failed
+func RunSimpleMenu() bool {
+	installs := FindPatchableDiscords(FindDiscords())
+	if len(installs) == 0 {
+		fmt.Println(T("No Discord install found"))
+		return false
+	}
+
+	i := promptNumber(T("Select a Discord install by number, or q to quit:"), SliceMap(installs, func(di *DiscordInstall) string {
+		return "Discord " + di.DisplayName() + " - " + di.path + di.StatusText()
+	}))
+	if i == -1 {
+		return true
+	}
+	di := installs[i]
+
+	actions := Ternary(di.isPatched, []tuiAction{tuiRepair, tuiUninstall}, []tuiAction{tuiInstall})
+	i = promptNumber(T("Select what to do with Discord %s by number, or q to quit:", di.branch), SliceMap(actions, func(a tuiAction) string {
+		return T(a.label)
+	}))
+	if i == -1 {
+		return true
+	}
+	action := actions[i]
+	if !promptYesNo(T("%s for Discord %s?", T(action.label), di.branch)) {
+		fmt.Println(T("Cancelled, nothing was changed"))
+		return true
+	}
+
+	exe := ""
+	if di.IsRunning() {
+		if !promptYesNo(T("Discord %s is running. Close it now and restart it afterwards", di.branch)) {
+			fmt.Println(T("Cancelled, nothing was changed"))
+			return true
+		}
+		var err error
+		if exe, err = di.CloseDiscord(); err != nil {
+			fmt.Println(T("Failed to close Discord %s: %s", di.branch, err))
+			return false
+		}
+	}
+
+	if action.label != tuiUninstall.label && !WaitForGithub() {
+		Log.Warn(T("Couldn't check for updates:"), GithubError)
+	}
+	err := UseScopeFor(di, "")
+	if err == nil {
+		err = action.fn(di)
+	}
+	if scopeErr := UseScope(ScopeUser); scopeErr != nil {
+		Log.Warn(scopeErr)
+	}
+	if exe != "" {
+		if relaunchErr := di.RelaunchDiscord(exe); relaunchErr != nil {
+			Log.Warn(T("Failed to start Discord:"), relaunchErr)
+		}
+	}
+
+	if err != nil {
+		if !errors.Is(err, ErrCancelled) {
+			fmt.Println(T("Discord %s was not "+action.done+": %s", di.branch, localize(err)))
+		}
+		return false
+	}
+	fmt.Println(T("Discord %s was "+action.done, di.branch))
+	return true
+}
@@ -0,0 +1,18 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "errors"
+
+var ErrSnapReadOnly = errors.New("Discord from Snap can't be patched, as snaps are read-only.\n" +
+	"Migrate to the Flatpak version of Discord instead, the installer can do that for you and keep you logged in")
+
+// Flatpak ids of the snap packages' counterparts by branch
+var snapFlatpakIds = map[string]string{
+	"stable": "com.discordapp.Discord",
+	"canary": "com.discordapp.DiscordCanary",
+}
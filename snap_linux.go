@@ -0,0 +1,91 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	path "path/filepath"
+	"strings"
+)
+
+const flathubRepo = "https://dl.flathub.org/repo/flathub.flatpakrepo"
+
+// snapName returns the name of the snap package, e.g. discord-canary
+func (di *DiscordInstall) snapName() string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(di.path, "/snap/"), "/")
+	return name
+}
+
+// MigrateSnapToFlatpak installs the Flatpak version of the given snap Discord for the actual user and copies Discord's
+// data over, so the user stays logged in. The snap is left in place, removing it is up to the user
+func MigrateSnapToFlatpak(di *DiscordInstall) (*DiscordInstall, error) {
+	id, ok := snapFlatpakIds[di.branch]
+	if !ok {
+		return nil, errors.New("There is no Flatpak of Discord " + di.branch)
+	}
+	if _, err := exec.LookPath("flatpak"); err != nil {
+		return nil, errors.New("Flatpak is not installed. Install it first, see https://flatpak.org/setup")
+	}
+
+	Log.Info("Installing", id, "from Flathub...")
+	if err := runAsActualUser("flatpak", "remote-add", "--user", "--if-not-exists", "flathub", flathubRepo); err != nil {
+		return nil, errors.New("Failed to add Flathub: " + err.Error())
+	}
+	if err := runAsActualUser("flatpak", "install", "--user", "--noninteractive", "-y", "flathub", id); err != nil {
+		return nil, errors.New("Failed to install " + id + ": " + err.Error())
+	}
+
+	flatpak := ParseDiscord(path.Join(getFlatpakAppDirs()[0], id), "")
+	if flatpak == nil {
+		return nil, errors.New(id + " was installed, but couldn't be found")
+	}
+
+	src, dst := GetDiscordDataDir(di), GetDiscordDataDir(flatpak)
+	if !ExistsFile(src) {
+		Log.Debug("No snap data to migrate at", src)
+	} else if ExistsFile(dst) {
+		Log.Info("Not migrating Discord data, as", dst, "already exists")
+	} else {
+		Log.Info("Copying Discord data from", src, "to", dst)
+		if err := copyDir(src, dst); err != nil {
+			Log.Warn("Failed to copy Discord data, you will have to log in again:", err)
+		}
+		_ = FixOwnership(path.Join(Home, ".var/app", id))
+	}
+
+	Log.Info("Migrated to the Flatpak. You can now remove the snap with: sudo snap remove", di.snapName())
+	return flatpak, nil
+}
+
+func copyDir(src, dst string) error {
+	return path.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := path.Rel(src, p)
+		target := path.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			return copyFile(p, target)
+		default:
+			// sockets and the like, e.g. SingletonSocket
+			return nil
+		}
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "errors"
+
+func MigrateSnapToFlatpak(_ *DiscordInstall) (*DiscordInstall, error) {
+	return nil, errors.New("Snap is only supported on Linux")
+}
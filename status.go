@@ -0,0 +1,82 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+type PotatocordStatus struct {
+	File          string `json:"file"`
+	Present       bool   `json:"present"`
+	InstalledHash string `json:"installedHash,omitempty"`
+	// Set if the installed file's content doesn't match its version, in which case --heal fixes it
+	CorruptionError string `json:"corruptionError,omitempty"`
+	LatestHash      string `json:"latestHash,omitempty"`
+	// Set if the latest release couldn't be fetched, in which case UpToDate is false
+	LatestError string          `json:"latestError,omitempty"`
+	UpToDate    bool            `json:"upToDate"`
+	Installs    []InstallStatus `json:"installs"`
+}
+
+type InstallStatus struct {
+	Branch  string `json:"branch"`
+	Path    string `json:"path"`
+	Patched bool   `json:"patched"`
+	// The Potatocord version the install was patched with, if known
+	PatchedHash     string `json:"patchedHash,omitempty"`
+	UpToDate        bool   `json:"upToDate"`
+	InjectionIntact bool   `json:"injectionIntact"`
+	InjectionError  string `json:"injectionError,omitempty"`
+}
+
+// GetStatus reports whether Potatocord is installed and up to date and, for every Discord install, whether it is
+// patched with the latest version and the injection is still intact. Waits for the latest release to be fetched
+func GetStatus() PotatocordStatus {
+	installed := ReadInstalledHash()
+	status := PotatocordStatus{
+		File:          PotatocordDirectory,
+		Present:       ExistsFile(PotatocordDirectory),
+		InstalledHash: installed,
+		Installs:      []InstallStatus{},
+	}
+
+	if WaitForGithub() {
+		status.LatestHash = LatestHash
+		status.UpToDate = installed != "" && HashesMatch(LatestHash, installed)
+	} else if GithubError != nil {
+		status.LatestError = GithubError.Error()
+	}
+	if _, err := CheckPotatocordIntegrity(); err != nil {
+		status.CorruptionError = err.Error()
+	}
+
+	for _, d := range FindDiscords() {
+		di := d.(*DiscordInstall)
+		s := InstallStatus{Branch: di.branch, Path: di.path, Patched: di.isPatched, PatchedHash: di.PatchedHash()}
+		if di.isPatched {
+			if err := di.VerifyInjection(); err != nil {
+				s.InjectionError = err.Error()
+			} else {
+				s.InjectionIntact = true
+			}
+			// Installs patched before hashes were recorded load whatever is installed
+			s.UpToDate = status.UpToDate && (s.PatchedHash == "" || HashesMatch(LatestHash, s.PatchedHash))
+		}
+		status.Installs = append(status.Installs, s)
+	}
+	return status
+}
+
+// Healthy reports whether Potatocord is up to date and every patched install loads it
+func (s PotatocordStatus) Healthy() bool {
+	if !s.Present || !s.UpToDate || s.CorruptionError != "" {
+		return false
+	}
+	for _, i := range s.Installs {
+		if i.Patched && (!i.InjectionIntact || !i.UpToDate) {
+			return false
+		}
+	}
+	return true
+}
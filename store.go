@@ -0,0 +1,12 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import "errors"
+
+var ErrStoreReadOnly = errors.New("Discord from the Microsoft Store can't be patched, as Windows protects the files of Store apps.\n" +
+	"Uninstall it and install Discord from " + DiscordDownloadUrl + " instead. You will have to log in again")
@@ -0,0 +1,61 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	path "path/filepath"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Every installed MSIX package has a key here, even though WindowsApps itself can't be listed without admin rights
+const storePackagesKey = `Software\Classes\Local Settings\Software\Microsoft\Windows\CurrentVersion\AppModel\Repository\Packages`
+
+// findStoreDiscords returns Discord packages installed from the Microsoft Store. They can't be patched, but are
+// listed so the user is told why instead of being told no Discord was found
+func findStoreDiscords() []any {
+	var discords []any
+
+	key, err := registry.OpenKey(registry.CURRENT_USER, storePackagesKey, registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		return discords
+	}
+	defer key.Close()
+
+	packages, err := key.ReadSubKeyNames(-1)
+	if err != nil {
+		return discords
+	}
+
+	for _, pkg := range packages {
+		// <name>_<version>_<arch>__<publisher id>, e.g. Discord.Discord_1.0.9.0_x64__...
+		name, _, _ := strings.Cut(pkg, "_")
+		if !strings.Contains(strings.ToLower(name), "discord") {
+			continue
+		}
+
+		pkgKey, err := registry.OpenKey(key, pkg, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		root, _, err := pkgKey.GetStringValue("PackageRootFolder")
+		pkgKey.Close()
+		if err != nil || root == "" {
+			continue
+		}
+
+		Log.Debug("Found Microsoft Store Discord", pkg, "at", root)
+		discords = append(discords, &DiscordInstall{
+			path:    root,
+			branch:  GetBranch(name),
+			appPath: path.Join(root, "app", "resources", "app"),
+			isStore: true,
+		})
+	}
+	return discords
+}
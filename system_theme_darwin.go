@@ -0,0 +1,19 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// SystemPrefersDark reports whether the appearance is set to dark. AppleInterfaceStyle is only set in dark mode,
+// including when the appearance is automatic and it's currently dark
+func SystemPrefersDark() bool {
+	out, err := exec.Command("defaults", "read", "-g", "AppleInterfaceStyle").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "Dark"
+}
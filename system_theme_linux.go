@@ -0,0 +1,30 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// SystemPrefersDark reports whether the desktop asks for dark apps. There is no standard way to tell without D-Bus,
+// but GNOME's settings are also set by most other desktops for GTK apps. Without them, this falls back to the
+// GTK theme, whose dark variants are named like Adwaita-dark
+func SystemPrefersDark() bool {
+	if theme := os.Getenv("GTK_THEME"); theme != "" {
+		return strings.Contains(strings.ToLower(theme), "dark")
+	}
+	// 'prefer-dark', 'prefer-light' or 'default', which is light
+	if out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "color-scheme").Output(); err == nil {
+		if scheme := strings.Trim(strings.TrimSpace(string(out)), "'"); scheme != "default" {
+			return scheme == "prefer-dark"
+		}
+	}
+	out, err := exec.Command("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme").Output()
+	return err == nil && strings.Contains(strings.ToLower(string(out)), "dark")
+}
@@ -0,0 +1,73 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// TargetUser is the account to install for instead of the one running the installer, chosen with --user or
+// POTATOCORD_USER. Its files are given to it, so an administrator can patch another user's Discord
+var TargetUser string
+
+// Why --user can't be used, reported once the flags are parsed
+var TargetUserError error
+
+// targetUserName returns the value of --user. Flags aren't parsed yet when the data directories are looked up, so
+// like the log level, it is taken from the arguments directly
+func targetUserName() string {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg[1:], "-"), "=")
+		if name != "user" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return EnvUser.Get()
+}
+
+// lookupTargetUser looks up the account chosen with --user, if any. The platform inits call it before anything
+// else, then point the home and data directories to it
+func lookupTargetUser() *user.User {
+	name := targetUserName()
+	if name == "" {
+		return nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		TargetUserError = errors.New("Failed to look up the user " + name + ": " + err.Error())
+		return nil
+	}
+	if u.Uid == "0" {
+		TargetUserError = errors.New("Potatocord can't be installed for root. Pass the user whose Discord to modify")
+		return nil
+	}
+	if !IsElevated() {
+		TargetUserError = withClass(ErrElevationDenied, errors.New("Installing for another user requires administrator rights. "+elevationHint))
+		return nil
+	}
+
+	Log.Debug("Installing for", u.Username+", whose home is", u.HomeDir)
+	TargetUser = u.Username
+	return u
+}
@@ -0,0 +1,26 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+// Theme is the gui's color scheme
+type Theme string
+
+const (
+	// ThemeSystem follows the dark mode setting of the OS
+	ThemeSystem Theme = "system"
+	ThemeDark   Theme = "dark"
+	ThemeLight  Theme = "light"
+)
+
+var Themes = []Theme{ThemeSystem, ThemeDark, ThemeLight}
+
+func GetTheme() Theme {
+	if SliceContains(Themes, Settings.Theme) {
+		return Settings.Theme
+	}
+	return ThemeSystem
+}
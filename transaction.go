@@ -0,0 +1,85 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+)
+
+// InstallTransaction runs the steps of an install and remembers how to undo each of them. If a step fails, Finish
+// undoes the completed ones in reverse, so a failed install leaves everything as it was before
+type InstallTransaction struct {
+	completed []installStep
+}
+
+type installStep struct {
+	name string
+	undo func() error
+}
+
+// Do runs a step. undo is remembered once it succeeded, and may be nil for steps that need no undoing
+func (t *InstallTransaction) Do(name string, do, undo func() error) error {
+	// Steps are only cancelled in between, as a step cancelled halfway couldn't be undone
+	if IsCancelled() {
+		return ErrCancelled
+	}
+	Log.Debug("Running step", name)
+	if err := do(); err != nil {
+		return err
+	}
+	if undo != nil {
+		t.completed = append(t.completed, installStep{name, undo})
+	}
+	return nil
+}
+
+// Finish undoes all completed steps if err is set. Returns err, along with anything that couldn't be undone
+func (t *InstallTransaction) Finish(err error) error {
+	if err == nil || len(t.completed) == 0 {
+		return err
+	}
+
+	Log.Error("Install failed. Undoing", len(t.completed), "completed steps")
+	for i := len(t.completed) - 1; i >= 0; i-- {
+		step := t.completed[i]
+		Log.Info("Undoing", step.name)
+		if undoErr := step.undo(); undoErr != nil {
+			Log.Error("Failed to undo", step.name+":", undoErr)
+			err = errors.Join(err, errors.New("Failed to undo "+step.name+", so the install may be left half done: "+undoErr.Error()))
+		}
+	}
+	t.completed = nil
+	return err
+}
+
+// undoInstallLatestBuilds returns how to go back to the currently installed Potatocord build after installing
+// another one. It has to be called before installing
+func undoInstallLatestBuilds() func() error {
+	previousBackup := ReadManifest().LatestBackup()
+	previousHash := InstalledHash
+	existed := ExistsFile(PotatocordDirectory)
+
+	return func() error {
+		// Installing backs up the build it replaces, which is exactly what rolling back restores
+		if backup := ReadManifest().LatestBackup(); backup != nil && (previousBackup == nil || backup.File != previousBackup.File) {
+			return Rollback()
+		}
+		if existed {
+			return errors.New("The previous Potatocord build wasn't backed up, so it can't be restored")
+		}
+
+		InstalledHash = previousHash
+		recordVersion("Potatocord", "", "")
+		recordRemoved(PotatocordDirectory)
+		if NeedsElevation(path.Dir(PotatocordDirectory)) {
+			return RunPrivileged([]PrivilegedOp{{Op: "remove", Dst: PotatocordDirectory}})
+		}
+		return os.Remove(PotatocordDirectory)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	path "path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Stock discord_desktop_core/index.js only loads core.asar, anything else was injected by another mod
+const stockDesktopCoreIndex = "module.exports = require('./core.asar');"
+
+type TroubleshootResult struct {
+	Check   string `json:"check"`
+	Problem string `json:"problem,omitempty"` // empty if the check passed
+	// Fixes the problem. nil if it can't safely be fixed automatically, in which case Problem explains what to do
+	Fix func() error `json:"-"`
+}
+
+// Troubleshoot checks the most common reasons for Potatocord not loading in the given install
+func Troubleshoot(di *DiscordInstall) []TroubleshootResult {
+	Log.Info("Troubleshooting", di.path+"...")
+
+	results := []TroubleshootResult{
+		checkPotatocordFiles(),
+		checkDiscordHost(di),
+		checkInjection(di),
+		checkConflictingMods(di),
+		checkDiscordVersion(di),
+		checkDiscordCache(di),
+	}
+
+	for _, r := range results {
+		if r.Problem == "" {
+			Log.Debug(r.Check+":", "OK")
+		} else {
+			Log.Warn(r.Check+":", r.Problem)
+		}
+	}
+	return results
+}
+
+// ApplyTroubleshootFixes applies all automatic fixes and returns whether every problem was fixed
+func ApplyTroubleshootFixes(results []TroubleshootResult) bool {
+	ok := true
+	for _, r := range results {
+		if r.Problem == "" {
+			continue
+		}
+		if r.Fix == nil {
+			ok = false
+			continue
+		}
+
+		Log.Info("Fixing:", r.Check)
+		if err := r.Fix(); err != nil {
+			Log.Error("Failed to fix", r.Check+":", err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func checkPotatocordFiles() TroubleshootResult {
+	r := TroubleshootResult{Check: "Potatocord files"}
+	if IsDevInstall {
+		return r
+	}
+
+	if !ExistsFile(PotatocordDirectory) {
+		r.Problem = PotatocordDirectory + " is missing. It might have been removed by your antivirus"
+	} else if ReadInstalledHash() == "" {
+		r.Problem = PotatocordDirectory + " is corrupted. It might have been modified by your antivirus"
+	} else {
+		return r
+	}
+
+	r.Fix = func() error {
+		if GithubError != nil {
+			return GithubError
+		}
+		return installLatestBuilds()
+	}
+	return r
+}
+
+func checkDiscordHost(di *DiscordInstall) TroubleshootResult {
+	r := TroubleshootResult{Check: "Discord files"}
+	if err := di.CheckDiscordHost(); err != nil {
+		r.Problem = err.Error() + ". Discord will be reinstalled from discord.com, your data is kept"
+		r.Fix = func() error {
+			fresh, err := ReinstallDiscord(di)
+			if err != nil {
+				return err
+			}
+			// Later fixes must see the fresh install, not the broken one
+			*di = *fresh
+			return di.patch()
+		}
+	}
+	return r
+}
+
+func checkInjection(di *DiscordInstall) TroubleshootResult {
+	r := TroubleshootResult{Check: "Injection"}
+	if !di.isPatched {
+		r.Problem = "Potatocord is not installed on " + di.path
+		r.Fix = di.patch
+	} else if err := di.VerifyInjection(); err != nil {
+		r.Problem = err.Error()
+		r.Fix = di.patch
+	}
+	return r
+}
+
+func checkConflictingMods(di *DiscordInstall) TroubleshootResult {
+	r := TroubleshootResult{Check: "Conflicting mods"}
+
+	mods := FindConflictingMods(di)
+	if len(mods) == 0 {
+		return r
+	}
+
+	var problems []string
+	canFix := true
+	for _, mod := range mods {
+		problems = append(problems, mod.File+" was modified by "+mod.DisplayName())
+		canFix = canFix && mod.disable != nil
+	}
+	r.Problem = strings.Join(problems, ", ")
+	if canFix {
+		r.Problem += ". The modified files will be kept with the suffix " + disabledModSuffix
+		r.Fix = func() error {
+			PreparePatch(di)
+			return DisableConflictingMods(mods)
+		}
+	} else {
+		r.Problem += ". Uninstall it or reinstall Discord, or enable advanced mode to force overwriting it"
+	}
+	return r
+}
+
+func checkDiscordVersion(di *DiscordInstall) TroubleshootResult {
+	r := TroubleshootResult{Check: "Discord version"}
+
+	installed := GetDiscordVersion(di)
+	if installed == "" {
+		return r
+	}
+
+	latest, err := GetLatestDiscordVersion(di.branch)
+	if err != nil {
+		Log.Debug("Failed to fetch latest Discord version:", err)
+		return r
+	}
+
+	if compareVersions(installed, latest) < 0 {
+		r.Problem = "Discord " + installed + " is outdated, the latest version is " + latest + ". Start Discord and let it update"
+	}
+	return r
+}
+
+func checkDiscordCache(di *DiscordInstall) TroubleshootResult {
+	r := TroubleshootResult{Check: "Discord cache"}
+
+	// When Discord's own app.asar was last replaced, which is when this Discord version was installed
+	var installed time.Time
+	if stat, err := os.Stat(getStockAsar(di)); err == nil {
+		installed = stat.ModTime()
+	}
+
+	var caches, problems []string
+	for _, name := range []string{"Cache", "Code Cache", "GPUCache"} {
+		dir := path.Join(GetDiscordDataDir(di), name)
+		if problem := cacheProblem(dir, installed); problem != "" {
+			caches = append(caches, dir)
+			problems = append(problems, name+" "+problem)
+		}
+	}
+	if len(caches) == 0 {
+		return r
+	}
+
+	r.Problem = strings.Join(problems, ", ")
+	r.Fix = func() error {
+		PreparePatch(di)
+		for _, dir := range caches {
+			Log.Debug("Deleting", dir)
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return r
+}
+
+// cacheProblem returns why the given Chromium cache is stale or corrupted, or an empty string if it's fine or missing.
+// A cache nothing was written to since Discord was updated still holds the code of the previous version
+func cacheProblem(dir string, installed time.Time) string {
+	var indexes []string
+	var newest time.Time
+	_ = path.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		// Each cache backend keeps an index at the top or one level down, e.g. Cache_Data/index or js/index
+		if d.Name() == "index" && strings.Count(strings.TrimPrefix(p, dir), string(os.PathSeparator)) <= 2 {
+			indexes = append(indexes, p)
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if newest.IsZero() {
+		return ""
+	}
+
+	if len(indexes) == 0 {
+		return "has no index and is probably corrupted"
+	}
+	for _, index := range indexes {
+		if info, err := os.Stat(index); err == nil && info.Size() == 0 {
+			return "has an empty index and is probably corrupted"
+		}
+	}
+	if !installed.IsZero() && newest.Before(installed) {
+		return "is from before Discord was updated and may contain outdated code"
+	}
+	return ""
+}
+
+// GetDiscordVersion returns the version of the given install or an empty string if unknown
+func GetDiscordVersion(di *DiscordInstall) string {
+	b, err := os.ReadFile(path.Join(di.resourcesDir(), "build_info.json"))
+	if err == nil {
+		var info struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(b, &info) == nil && info.Version != "" {
+			return info.Version
+		}
+	}
+
+	// Windows installs live in app-<version>
+	if dir := path.Base(path.Join(di.appPath, "..", "..")); strings.HasPrefix(dir, "app-") {
+		return dir[4:]
+	}
+	return ""
+}
+
+// GetLatestDiscordVersion asks Discord's update server for the latest host version of the given branch
+func GetLatestDiscordVersion(branch string) (string, error) {
+	platform := map[string]string{"windows": "win", "darwin": "osx"}[runtime.GOOS]
+	if platform == "" {
+		platform = "linux"
+	}
+
+	req, err := http.NewRequest("GET", "https://discord.com/api/updates/"+branch+"?platform="+platform, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return "", errors.New(res.Status)
+	}
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return "", err
+	}
+	return data.Name, nil
+}
@@ -0,0 +1,186 @@
+//go:build cli
+
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"potatocordinstaller/buildinfo"
+	"runtime"
+
+	"github.com/fatih/color"
+	"github.com/manifoldco/promptui"
+)
+
+// runTui is a keyboard driven menu with what the gui offers, for terminals without a display like over SSH. Unlike
+// the other actions it keeps running until quit, showing all installs and their status after each action
+func runTui() {
+	interactive = true
+	if progressFormat == ProgressNone {
+		progressFormat = ProgressBar
+	}
+
+	ReportStage(StageFetch, "Fetching the latest release")
+	WaitForGithub()
+	ClearProgress()
+
+	for {
+		discords = FindDiscords()
+		printTuiHeader()
+
+		items := SliceMap(discords, func(d any) string {
+			di := d.(*DiscordInstall)
+			return di.DisplayName() + " " + GetDiscordVersion(di) + " - " + di.path + di.StatusText()
+		})
+		const updateAll, rescan, quit = "Update all patched installs", "Rescan", "Quit"
+		if LatestHash != "Unknown" && len(findOutdatedInstalls()) != 0 {
+			items = append(items, updateAll)
+		}
+		items = append(items, rescan, quit)
+
+		i, choice, err := (&promptui.Select{
+			Label: T("Select a Discord install (Press Enter to confirm)"),
+			Items: SliceMap(items, func(item string) string { return T(item) }),
+			Size:  10,
+		}).Run()
+		if err == nil {
+			choice = items[i]
+		}
+		if errors.Is(err, promptui.ErrInterrupt) || choice == quit {
+			return
+		}
+		handlePromptError(err)
+
+		switch choice {
+		case rescan:
+			continue
+		case updateAll:
+			for _, di := range findOutdatedInstalls() {
+				runTuiAction(di, tuiRepair)
+			}
+		default:
+			selectTuiAction(discords[i].(*DiscordInstall))
+		}
+	}
+}
+
+func printTuiHeader() {
+	fmt.Println()
+	color.New(color.Bold).Println("Potatocord Installer " + buildinfo.InstallerTag)
+	switch {
+	case LatestHash == "Unknown":
+		fmt.Println(Ternary(InstalledHash == "None", T("Potatocord is not installed"), T("Potatocord %s is installed", InstalledHash))+
+			". "+T("Couldn't check for updates:"), GithubError)
+	case InstalledHash == "None":
+		fmt.Println(T("Potatocord is not installed. Latest version: %s", LatestHash))
+	case HashesMatch(LatestHash, InstalledHash):
+		fmt.Println(T("Potatocord %s is installed and up to date", InstalledHash))
+	default:
+		color.HiYellow(T("Potatocord %s is installed, %s is available", InstalledHash, LatestHash))
+	}
+	if len(discords) == 0 {
+		color.HiYellow(T("No Discord install found. Rescan once Discord is installed"))
+	}
+	fmt.Println()
+}
+
+// findOutdatedInstalls returns the patched installs that don't load the latest version or lost the injection
+func findOutdatedInstalls() []*DiscordInstall {
+	var installs []*DiscordInstall
+	for _, di := range FindPatchableDiscords(discords) {
+		if di.isPatched && (!HashesMatch(LatestHash, di.PatchedHash()) || di.VerifyInjection() != nil) {
+			installs = append(installs, di)
+		}
+	}
+	return installs
+}
+
+func selectTuiAction(di *DiscordInstall) {
+	if di.isSnap {
+		color.HiYellow(T(ErrSnapReadOnly.Error()) + ". " + T("Run the installer with the install command to migrate to the Flatpak"))
+		return
+	}
+	if di.isStore {
+		color.HiYellow(T("Microsoft Store installs of Discord can't be modified. Install Discord from discord.com instead"))
+		return
+	}
+
+	actions := Ternary(di.isPatched, []tuiAction{tuiRepair, tuiUninstall}, []tuiAction{tuiInstall})
+	items := append(SliceMap(actions, func(a tuiAction) string { return T(a.label) }), T("Back"))
+	i, _, err := (&promptui.Select{
+		Label: "Discord " + di.DisplayName() + " - " + di.path + di.StatusText(),
+		Items: items,
+	}).Run()
+	if errors.Is(err, promptui.ErrInterrupt) || i == len(actions) {
+		return
+	}
+	handlePromptError(err)
+	if actions[i].label == tuiUninstall.label && !ask(T("Uninstall Potatocord from Discord %s", di.branch), false) {
+		return
+	}
+	runTuiAction(di, actions[i])
+}
+
+// runTuiAction runs the action with a progress bar and shows how it went. Failures are shown instead of exiting
+func runTuiAction(di *DiscordInstall, action tuiAction) {
+	exe, ok := closeDiscordForTui(di)
+	if !ok {
+		return
+	}
+
+	err := UseScopeFor(di, "")
+	if err == nil {
+		err = action.fn(di)
+	}
+	ClearProgress()
+	if scopeErr := UseScope(ScopeUser); scopeErr != nil {
+		Log.Warn(scopeErr)
+	}
+
+	if exe != "" {
+		if err := di.RelaunchDiscord(exe); err != nil {
+			Log.Warn(T("Failed to start Discord:"), err)
+		}
+	}
+
+	LogChanges()
+	if errors.Is(err, ErrCancelled) {
+		exit(ExitCancelled)
+	}
+	if err != nil {
+		color.HiRed("❌ " + T("Discord %s was not "+action.done+": %s", di.branch, localize(err)))
+	} else {
+		color.HiGreen("✔ " + T("Discord %s was "+action.done, di.branch))
+	}
+}
+
+// closeDiscordForTui asks to close Discord if it's running. Returns the executable to relaunch afterwards and
+// whether to go on
+func closeDiscordForTui(di *DiscordInstall) (string, bool) {
+	if !di.IsRunning() {
+		return "", true
+	}
+
+	if !ask(T("Discord %s is running. Close it now and restart it afterwards", di.branch), false) {
+		// Windows doesn't let us replace files that are in use
+		if runtime.GOOS == "windows" {
+			color.HiYellow(T("Close Discord %s first", di.branch))
+			return "", false
+		}
+		Log.Warn(T("Restart Discord afterwards for the changes to take effect"))
+		return "", true
+	}
+
+	exe, err := di.CloseDiscord()
+	if err != nil {
+		color.HiRed("❌ " + T("Failed to close Discord %s: %s", di.branch, err))
+		return "", false
+	}
+	return exe, true
+}
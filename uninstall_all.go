@@ -0,0 +1,230 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	path "path/filepath"
+	"sort"
+	"strings"
+)
+
+// UninstallEverything returns every detected Discord install to stock: it removes the scheduled update job, unpatches
+// all patched installs, restores backups taken by OpenAsar, revokes Flatpak access and deletes the downloaded Potatocord files along with the
+// installer's own state (manifest, backups, cache and everything else it created). Afterwards, every install is verified to be unmodified.
+// If removeUserData is set, Potatocord's settings and themes and the installer settings are deleted too.
+// It keeps going on errors and returns all of them
+func UninstallEverything(removeUserData bool) (errs []error) {
+	release, err := AcquireInstallLock()
+	if err != nil {
+		return []error{err}
+	}
+	defer func() {
+		release()
+		// Only now that the lock in it is gone
+		if removeUserData {
+			removeDataDir()
+		}
+	}()
+	Log.Info("Removing Potatocord from everything...")
+
+	// Otherwise it would patch Discord again the next time it runs
+	if err := UnscheduleUpdates(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, errors.New("Failed to remove the scheduled update job: "+err.Error()))
+	}
+
+	manifest := ReadManifest()
+	installs := FindDiscords()
+	// The manifest knows about injections into installs that aren't detected anymore, e.g. moved portable ones
+	detected := make(map[string]bool)
+	for _, d := range installs {
+		detected[d.(*DiscordInstall).resourcesDir()] = true
+	}
+	for dir, injection := range manifest.Injections {
+		if detected[dir] {
+			continue
+		}
+		if di := ParseDiscord(injection.Discord, injection.Branch); di != nil && di.resourcesDir() == dir {
+			Log.Debug("Found Potatocord injected into", injection.Discord, "according to the install manifest")
+			installs = append(installs, di)
+		}
+	}
+
+	for _, d := range installs {
+		di := d.(*DiscordInstall)
+		injection, injected := manifest.Injections[di.resourcesDir()]
+
+		if di.isPatched {
+			if err := di.unpatch(); err != nil {
+				errs = append(errs, errors.New("Failed to unpatch "+di.path+": "+err.Error()))
+			}
+		}
+
+		if di.IsOpenAsar() {
+			if err := di.UninstallOpenAsar(); err != nil {
+				errs = append(errs, errors.New("Failed to restore original app.asar of "+di.path+": "+err.Error()))
+			}
+		}
+
+		if di.isFlatpak && !IsDevInstall {
+			// Access was granted to wherever Potatocord was installed to at the time
+			granted := Ternary(injected && injection.Loads != "", injection.Loads, PotatocordDirectory)
+			if err := di.flatpakOverride("--nofilesystem=" + granted); err != nil {
+				Log.Warn("Failed to revoke Discord Flatpak access to", granted+":", err)
+			}
+		}
+	}
+
+	for _, d := range installs {
+		di := d.(*DiscordInstall)
+		// Re-parse, as the in-memory state only reflects what we think we did
+		current := ParseDiscord(di.path, di.branch)
+		if current == nil {
+			errs = append(errs, errors.New(di.path+" is no longer a valid Discord install"))
+			continue
+		}
+		if err := current.VerifyStock(); err != nil {
+			errs = append(errs, withClass(ErrVerificationFailed, errors.New("Discord at "+di.path+" is still modified: "+err.Error())))
+		} else {
+			Log.Debug("Verified that", di.path, "is stock")
+		}
+	}
+
+	if IsDevInstall {
+		Log.Info("Not deleting Potatocord files as this is a dev install")
+		if err := UnlinkDevBuild(); err != nil {
+			errs = append(errs, errors.New("Failed to remove dev link: "+err.Error()))
+		}
+		return
+	}
+
+	Log.Debug("Deleting", PotatocordDirectory)
+	if err := os.Remove(PotatocordDirectory); err != nil && !errors.Is(err, os.ErrNotExist) {
+		errs = append(errs, errors.New("Failed to delete "+PotatocordDirectory+": "+err.Error()))
+	} else {
+		if err == nil {
+			if manifest.Installed != nil {
+				recordVersion("Potatocord", manifest.Installed.Hash, "")
+			}
+			recordRemoved(PotatocordDirectory)
+		}
+		InstalledHash = "None"
+	}
+	// A build installed to a location that isn't used anymore
+	if installed := manifest.Installed; installed != nil && installed.File != PotatocordDirectory {
+		Log.Debug("Deleting", installed.File)
+		if err := os.Remove(installed.File); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, errors.New("Failed to delete "+installed.File+": "+err.Error()))
+		} else if err == nil {
+			recordRemoved(installed.File)
+		}
+	}
+
+	errs = append(errs, removeInstallerState(len(errs) == 0, removeUserData)...)
+
+	if removeUserData {
+		errs = append(errs, removeUserDataFiles()...)
+	}
+
+	if len(errs) == 0 {
+		Log.Info("Successfully removed Potatocord from everything")
+	}
+	return
+}
+
+// removeInstallerState deletes the files the installer keeps for itself, along with whatever else the manifest says
+// it created. Backups and created files are only deleted if everything else succeeded, as they are the last resort
+// for repairing an install that is still modified. The settings are only deleted along with the user's data
+func removeInstallerState(complete, removeUserData bool) (errs []error) {
+	// Read again, as unpatching forgot the files it restored
+	manifest := ReadManifest()
+	files := []string{getManifestPath(), CacheDir, getModRequestDir()}
+	if removeUserData {
+		files = append(files, getSettingsPath())
+		Settings = InstallerSettings{}
+	}
+	bundles, _ := path.Glob(path.Join(BaseDir, "diagnostics-*.zip"))
+	files = append(files, bundles...)
+	if complete {
+		files = append(files, BackupDir)
+	} else {
+		Log.Warn("Keeping stock backups in", BackupDir, "as not everything was uninstalled")
+	}
+
+	errs = removeFiles(files)
+	if complete {
+		errs = append(errs, removeCreatedFiles(manifest)...)
+	}
+	return
+}
+
+// removeUserDataFiles deletes Potatocord's settings and themes. The data directory may have been set with
+// POTATOCORD_USER_DATA_DIR to one that has other files too, so only Potatocord's own entries are deleted
+func removeUserDataFiles() []error {
+	return removeFiles([]string{
+		path.Join(BaseDir, "settings"),
+		path.Join(BaseDir, "themes"),
+		path.Join(BaseDir, "installer-config.json"),
+		path.Join(BaseDir, "scheduled-update.log"),
+	})
+}
+
+// removeDataDir deletes the data directory once Potatocord's files are gone, unless other files are left
+func removeDataDir() {
+	Log.Debug("Deleting", BaseDir)
+	if err := os.Remove(BaseDir); err == nil {
+		recordRemoved(BaseDir)
+	} else if IsDirectory(BaseDir) {
+		Log.Info("Keeping", BaseDir, "as it contains files that aren't Potatocord's")
+	}
+}
+
+func removeFiles(files []string) (errs []error) {
+	for _, file := range files {
+		existed := ExistsFile(file)
+		Log.Debug("Deleting", file)
+		if err := os.RemoveAll(file); err != nil {
+			errs = append(errs, errors.New("Failed to delete "+file+": "+err.Error()))
+		} else if existed {
+			recordRemoved(file)
+		}
+	}
+	return
+}
+
+// removeCreatedFiles deletes the files and directories the manifest says the installer created and that are still
+// around. Directories are only deleted if they are empty, as the user may have put their own files there since
+func removeCreatedFiles(manifest *InstallManifest) (errs []error) {
+	var created []string
+	for file, touched := range manifest.Files {
+		if touched.Created {
+			created = append(created, file)
+		}
+	}
+	// Deepest first, so directories are emptied before deleting them
+	sort.Slice(created, func(i, j int) bool {
+		return strings.Count(created[i], string(os.PathSeparator)) > strings.Count(created[j], string(os.PathSeparator))
+	})
+
+	for _, file := range created {
+		if !ExistsFile(file) {
+			continue
+		}
+		Log.Debug("Deleting", file)
+		if err := os.Remove(file); err != nil {
+			if IsDirectory(file) {
+				Log.Warn("Keeping", file, "as it isn't empty")
+				continue
+			}
+			errs = append(errs, errors.New("Failed to delete "+file+": "+err.Error()))
+			continue
+		}
+		recordRemoved(file)
+	}
+	return
+}
@@ -0,0 +1,184 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// UpdateSource is somewhere Potatocord releases can be fetched from
+type UpdateSource interface {
+	Name() string
+	LatestRelease() (*GithubRelease, error)
+}
+
+// GetUpdateSources returns the configured update sources in order of preference. If installing from another
+// installer's share mode, that is the only source.
+// In advanced mode, POTATOCORD_UPDATE_SOURCE, POTATOCORD_RELEASE_REPO or the updateSource of the config file replaces the default GitHub sources and may be one of
+//
+//	github:<api url of the release>
+//	gitlab:<api url of the release>, e.g. https://gitlab.com/api/v4/projects/<id>/releases/permalink/latest
+//	manifest:<url of a json file matching ManifestRelease>
+func GetUpdateSources() []UpdateSource {
+	if ShareSourceUrl != "" {
+		return []UpdateSource{ManifestSource{ShareSourceUrl + "/manifest.json"}}
+	}
+
+	if source, origin := getCustomUpdateSource(); source != "" && !IsAdvancedMode() {
+		Log.Warn("Ignoring", origin, "as custom update sources require advanced mode")
+	} else if source != "" {
+		kind, url, _ := strings.Cut(source, ":")
+		switch kind {
+		case "github":
+			return []UpdateSource{GithubSource{url, url}}
+		case "gitlab":
+			return []UpdateSource{GitlabSource{url}}
+		case "manifest":
+			return []UpdateSource{ManifestSource{url}}
+		default:
+			Log.Warn("Ignoring invalid", origin, source)
+		}
+	}
+
+	return []UpdateSource{
+		GithubSource{ReleaseUrl, ReleaseUrlFallback},
+		BuildsRepoSource{},
+	}
+}
+
+// getCustomUpdateSource returns the custom update source, if any, and where it was set
+func getCustomUpdateSource() (source, origin string) {
+	if source = EnvUpdateSource.Get(); source != "" {
+		return source, EnvUpdateSource.Name
+	}
+	if repo := EnvReleaseRepo.Get(); repo != "" {
+		return "github:https://api.github.com/repos/" + repo + "/releases/tags/devbuild", EnvReleaseRepo.Name
+	}
+	if Config.UpdateSource != "" {
+		return Config.UpdateSource, "the updateSource from the config file"
+	}
+	return "", ""
+}
+
+type GithubSource struct {
+	Url         string
+	FallbackUrl string
+}
+
+func (s GithubSource) Name() string {
+	return "GitHub Release"
+}
+
+func (s GithubSource) LatestRelease() (*GithubRelease, error) {
+	return GetGithubRelease(s.Url, s.FallbackUrl)
+}
+
+type BuildsRepoSource struct{}
+
+func (BuildsRepoSource) Name() string {
+	return "builds repo"
+}
+
+func (BuildsRepoSource) LatestRelease() (*GithubRelease, error) {
+	return GetBuildsRepoRelease()
+}
+
+type GitlabSource struct {
+	Url string
+}
+
+type gitlabRelease struct {
+	Name        string `json:"name"`
+	TagName     string `json:"tag_name"`
+	Description string `json:"description"`
+	Assets      struct {
+		Links []struct {
+			Name           string `json:"name"`
+			Url            string `json:"url"`
+			DirectAssetUrl string `json:"direct_asset_url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (s GitlabSource) Name() string {
+	return "GitLab Release"
+}
+
+func (s GitlabSource) LatestRelease() (*GithubRelease, error) {
+	var data gitlabRelease
+	if err := fetchJson(s.Url, &data); err != nil {
+		return nil, err
+	}
+
+	release := &GithubRelease{
+		Name:    data.Name,
+		TagName: data.TagName,
+		Body:    data.Description,
+	}
+	for _, link := range data.Assets.Links {
+		url := Ternary(link.DirectAssetUrl != "", link.DirectAssetUrl, link.Url)
+		release.Assets = append(release.Assets, ReleaseAsset{Name: link.Name, DownloadURL: url})
+	}
+	return release, nil
+}
+
+// ManifestSource reads releases from a static json file, for self-hosting without any forge
+type ManifestSource struct {
+	Url string
+}
+
+type ManifestRelease struct {
+	Name   string         `json:"name"`
+	Tag    string         `json:"tag"`
+	Hash   string         `json:"hash"`
+	Notes  string         `json:"notes,omitempty"`
+	Assets []ReleaseAsset `json:"assets"`
+}
+
+func (s ManifestSource) Name() string {
+	return "manifest"
+}
+
+func (s ManifestSource) LatestRelease() (*GithubRelease, error) {
+	var data ManifestRelease
+	if err := fetchJson(s.Url, &data); err != nil {
+		return nil, err
+	}
+
+	return &GithubRelease{
+		Name:    data.Name,
+		TagName: data.Tag,
+		Body:    data.Notes,
+		Assets:  data.Assets,
+		Hash:    data.Hash,
+	}, nil
+}
+
+func fetchJson(url string, v any) error {
+	Log.Debug("Fetching", url)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %w", url, &HttpStatusError{res.StatusCode, res.Status})
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -52,6 +52,16 @@ func ExistsFile(path string) bool {
 	return err == nil
 }
 
+// fixWrittenFile removes what the OS attached to a file we wrote that would stop Discord from loading it
+func fixWrittenFile(p string) {
+	if err := ClearQuarantine(p); err != nil {
+		Log.Warn(err)
+	}
+	if err := RestoreSecurityContext(p); err != nil {
+		Log.Warn(err)
+	}
+}
+
 func IsDirectory(path string) bool {
 	s, err := os.Stat(path)
 	if err != nil {
@@ -93,10 +103,10 @@ func CheckIfErrIsCauseItsBusyRn(err error) error {
 	// bruhhhh
 	if linkError, ok := err.(*os.LinkError); ok {
 		if errno, ok := linkError.Err.(syscall.Errno); ok && errno == 32 /* ERROR_SHARING_VIOLATION */ {
-			return errors.New(
-				"Cannot patch because Discord's files are used by a different process." +
+			return withClass(ErrDiscordRunning, errors.New(
+				"Cannot patch because Discord's files are used by a different process."+
 					"\nMake sure you close Discord before trying to patch!",
-			)
+			))
 		}
 	}
 
@@ -107,6 +117,19 @@ func Prepend[T any](slice []T, elems ...T) []T {
 	return append(elems, slice...)
 }
 
+func FormatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatUint(n, 10) + " B"
+	}
+	div, exp := uint64(unit), 0
+	for i := n / unit; i >= unit; i /= unit {
+		div *= unit
+		exp++
+	}
+	return strconv.FormatFloat(float64(n)/float64(div), 'f', 1, 64) + " " + string("KMGTPE"[exp]) + "iB"
+}
+
 func compareVersions(v1, v2 string) int {
 	s1 := strings.Split(v1, ".")
 	s2 := strings.Split(v2, ".")
@@ -0,0 +1,183 @@
+/*
+ * SPDX-License-Identifier: GPL-3.0
+ * Potatocord Installer, a cross platform gui/cli app for installing Potatocord
+ * Copyright (c) 2023 Potatocord and contributors
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	path "path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const BackgroundVerifyInterval = time.Hour
+
+func (di *DiscordInstall) resourcesDir() string {
+	if di.isSystemElectron {
+		return di.path
+	}
+	return path.Join(di.appPath, "..")
+}
+
+// VerifyInjection checks that the install is still patched and that its app.asar still loads Potatocord
+func (di *DiscordInstall) VerifyInjection() error {
+	dir := di.resourcesDir()
+
+	if !ExistsFile(path.Join(dir, "_app.asar")) {
+		return errors.New("Discord's original app.asar is gone from " + dir + ". Discord probably updated")
+	}
+
+	b, err := os.ReadFile(path.Join(dir, "app.asar"))
+	if err != nil {
+		return err
+	}
+
+	patcherPath, _ := json.Marshal(PotatocordDirectory)
+	if !bytes.Contains(b, patcherPath) {
+		return errors.New(path.Join(dir, "app.asar") + " no longer loads Potatocord")
+	}
+	return nil
+}
+
+// VerifyStock checks that the install no longer contains any trace of Potatocord, so Discord launches unmodified
+func (di *DiscordInstall) VerifyStock() error {
+	dir := di.resourcesDir()
+	appAsar := path.Join(dir, "app.asar")
+
+	if ExistsFile(path.Join(dir, "_app.asar")) {
+		return errors.New(dir + " still contains _app.asar")
+	}
+
+	b, err := os.ReadFile(appAsar)
+	if err != nil {
+		return errors.New("Discord's app.asar is missing: " + err.Error())
+	}
+
+	patcherPath, _ := json.Marshal(PotatocordDirectory)
+	if bytes.Contains(b, patcherPath) {
+		return errors.New(appAsar + " still loads Potatocord")
+	}
+
+	return nil
+}
+
+func findPatchedInstalls() []*DiscordInstall {
+	var installs []*DiscordInstall
+	for _, d := range FindDiscords() {
+		if di := d.(*DiscordInstall); di.isPatched {
+			installs = append(installs, di)
+		}
+	}
+	return installs
+}
+
+// RunBackgroundVerifier periodically re-verifies all installs that are currently patched and notifies the user if
+// something external (Discord updates, antivirus) removed or altered Potatocord or if an update is available. To stay unnoticeable, it runs at low
+// priority and spreads the checks over the interval instead of doing them all at once. It never returns
+func RunBackgroundVerifier(interval time.Duration) {
+	LowerProcessPriority()
+	go WatchModRequests()
+	go WatchDiscordUpdates(handleDiscordUpdateInBackground)
+	defer WithLogContext("verifier")()
+
+	var installsChanged atomic.Bool
+	go WatchDiscordInstalls(func() {
+		installsChanged.Store(true)
+	})
+
+	installs := findPatchedInstalls()
+	Log.Info("Verifying", len(installs), "patched installs every", interval)
+
+	expectedHash := ReadInstalledHash()
+	broken := make(map[string]bool)
+	slice := interval / time.Duration(len(installs)+1)
+
+	notifiedHash := ""
+	for {
+		time.Sleep(slice)
+		if installsChanged.Swap(false) {
+			installs = findPatchedInstalls()
+			slice = interval / time.Duration(len(installs)+1)
+			Log.Info("Discord installs changed, now verifying", len(installs), "patched installs")
+		}
+
+		if latest := checkForUpdate(expectedHash); latest != "" && latest != notifiedHash {
+			notifiedHash = latest
+			Notify(EventUpdateAvailable, "Potatocord update available", "Potatocord "+latest+" is available. Run the installer to update.")
+		}
+
+		switch hash := ReadInstalledHash(); {
+		case HashesMatch(hash, expectedHash):
+		case hash != "" && isRecordedBuild(hash):
+			// Installed by the installer in the meantime, e.g. on request of the mod
+			Log.Info("Potatocord was updated to", hash)
+			expectedHash = hash
+			delete(broken, PotatocordDirectory)
+		case !broken[PotatocordDirectory]:
+			broken[PotatocordDirectory] = true
+			Log.Warn("Potatocord files at", PotatocordDirectory, "changed. Expected hash", expectedHash, "but found", Ternary(hash == "", "none", hash))
+			Notify(EventInstallBroken, "Potatocord was removed or modified", "Potatocord's files at "+PotatocordDirectory+" were changed. Run the installer to repair it.")
+		}
+
+		for _, di := range installs {
+			time.Sleep(slice)
+
+			// Discord updates may move the install, e.g. to a new app-x.y.z folder on Windows
+			current := ParseDiscord(di.path, di.branch)
+			var err error
+			if current == nil {
+				err = errors.New(di.path + " is no longer a Discord install")
+			} else {
+				err = current.VerifyInjection()
+			}
+
+			if err == nil {
+				Log.Debug("Verified", di.path)
+				delete(broken, di.path)
+				continue
+			}
+
+			Log.Warn("Verification of", di.path, "failed:", err)
+			if !broken[di.path] {
+				broken[di.path] = true
+				Notify(EventInstallBroken, "Potatocord is no longer installed", "Discord "+di.branch+" is no longer patched: "+err.Error())
+			}
+		}
+	}
+}
+
+// isRecordedBuild reports whether the installed Potatocord file is the given build as the installer installed it,
+// according to the checksum recorded in the manifest. Anything else changing it isn't an update
+func isRecordedBuild(hash string) bool {
+	installed := ReadManifest().Installed
+	if installed == nil || installed.File != PotatocordDirectory || installed.Sha256 == "" || !HashesMatch(hash, installed.Hash) {
+		return false
+	}
+	sum, err := hashFile(PotatocordDirectory)
+	return err == nil && strings.EqualFold(sum, installed.Sha256)
+}
+
+// checkForUpdate returns the latest hash if it differs from installedHash
+func checkForUpdate(installedHash string) string {
+	if IsDevInstall {
+		return ""
+	}
+
+	data, err := FetchLatestRelease()
+	if err != nil {
+		Log.Warn("Failed to check for updates:", err)
+		return ""
+	}
+
+	if latest := GetReleaseHash(data); !HashesMatch(latest, installedHash) {
+		return latest
+	}
+	return ""
+}